@@ -17,6 +17,20 @@ else
     CUDA_FLAGS=""
     export USE_CUDA=0
 fi
+
+# Detect libspeexdsp for the speex echo canceller (aec_backend)
+SPEEX_TAG=""
+if pkg-config --exists speexdsp 2>/dev/null; then
+    echo "🔊 libspeexdsp detected - building with the speex echo canceller"
+    SPEEX_TAG="speex"
+fi
+
+# Detect librnnoise for the RNNoise noise suppressor (denoise_backend)
+RNNOISE_TAG=""
+if pkg-config --exists rnnoise 2>/dev/null; then
+    echo "🔇 librnnoise detected - building with the RNNoise noise suppressor"
+    RNNOISE_TAG="rnnoise"
+fi
 echo ""
 
 # Check for whisper.cpp
@@ -43,9 +57,9 @@ mkdir -p bin
 
 # Build single binary with CGo
 if [ "$USE_CUDA" = "1" ]; then
-    CGO_ENABLED=1 go build -tags cuda -o bin/hyprwhspr .
+    CGO_ENABLED=1 go build -tags "cuda $SPEEX_TAG $RNNOISE_TAG" -o bin/hyprwhspr .
 else
-    CGO_ENABLED=1 go build -o bin/hyprwhspr .
+    CGO_ENABLED=1 go build -tags "$SPEEX_TAG $RNNOISE_TAG" -o bin/hyprwhspr .
 fi
 
 echo ""
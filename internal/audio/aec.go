@@ -8,6 +8,8 @@ import (
 
 // AECConfig contains configuration for acoustic echo cancellation
 type AECConfig struct {
+	Backend         string  // "nlms" (built-in) or "speex" (libspeexdsp)
+	SampleRate      int     // Sample rate of the recording
 	FilterLength    int     // Length of adaptive filter (typically 512-2048)
 	StepSize        float64 // Adaptation step size (0.01-0.1)
 	LeakageFactor   float64 // Leakage factor to prevent filter windup (0.99-1.0)
@@ -17,6 +19,8 @@ type AECConfig struct {
 // DefaultAECConfig returns default AEC configuration
 func DefaultAECConfig() AECConfig {
 	return AECConfig{
+		Backend:         "nlms",
+		SampleRate:      16000,
 		FilterLength:    1024,
 		StepSize:        0.05,
 		LeakageFactor:   0.999,
@@ -24,8 +28,28 @@ func DefaultAECConfig() AECConfig {
 	}
 }
 
-// AECProcessor implements acoustic echo cancellation using NLMS algorithm
-type AECProcessor struct {
+// AECProcessor cancels the system audio played through the speakers from a
+// recording. It is the "aec" stage of the processing chain.
+type AECProcessor interface {
+	Processor
+	Reset()
+	Close()
+}
+
+// NewAECProcessor creates the echo canceller selected by config.Backend
+func NewAECProcessor(config AECConfig) (AECProcessor, error) {
+	switch config.Backend {
+	case "", "nlms":
+		return NewNLMSProcessor(config), nil
+	case "speex":
+		return newSpeexProcessor(config)
+	default:
+		return nil, fmt.Errorf("unknown AEC backend %q", config.Backend)
+	}
+}
+
+// NLMSProcessor implements acoustic echo cancellation using NLMS algorithm
+type NLMSProcessor struct {
 	config AECConfig
 
 	// Adaptive filter coefficients
@@ -38,9 +62,9 @@ type AECProcessor struct {
 	mu sync.Mutex
 }
 
-// NewAECProcessor creates a new AEC processor
-func NewAECProcessor(config AECConfig) *AECProcessor {
-	return &AECProcessor{
+// NewNLMSProcessor creates a new NLMS echo canceller
+func NewNLMSProcessor(config AECConfig) *NLMSProcessor {
+	return &NLMSProcessor{
 		config:       config,
 		filter:       make([]float64, config.FilterLength),
 		farEndBuffer: make([]float64, config.FilterLength),
@@ -49,7 +73,7 @@ func NewAECProcessor(config AECConfig) *AECProcessor {
 }
 
 // ProcessFrame processes a single audio frame with echo cancellation
-func (aec *AECProcessor) ProcessFrame(micSignal, farEndSignal []float32) []float32 {
+func (aec *NLMSProcessor) ProcessFrame(micSignal, farEndSignal []float32) []float32 {
 	aec.mu.Lock()
 	defer aec.mu.Unlock()
 
@@ -108,7 +132,7 @@ func (aec *AECProcessor) ProcessFrame(micSignal, farEndSignal []float32) []float
 }
 
 // Reset resets the AEC processor state
-func (aec *AECProcessor) Reset() {
+func (aec *NLMSProcessor) Reset() {
 	aec.mu.Lock()
 	defer aec.mu.Unlock()
 
@@ -121,8 +145,11 @@ func (aec *AECProcessor) Reset() {
 	aec.farEndIndex = 0
 }
 
+// Close releases the processor, the NLMS filter holds no resources
+func (aec *NLMSProcessor) Close() {}
+
 // GetEchoReturnLossEnhancement calculates ERLE in dB
-func (aec *AECProcessor) GetEchoReturnLossEnhancement(micSignal, farendSignal, outputSignal []float32) float64 {
+func (aec *NLMSProcessor) GetEchoReturnLossEnhancement(micSignal, farendSignal, outputSignal []float32) float64 {
 	if len(micSignal) == 0 || len(outputSignal) == 0 {
 		return 0.0
 	}
@@ -149,3 +176,28 @@ func (aec *AECProcessor) GetEchoReturnLossEnhancement(micSignal, farendSignal, o
 
 	return erle
 }
+
+func (aec *NLMSProcessor) Name() string { return "aec" }
+
+// Process cancels the system audio in loopback from the recording. Without
+// loopback audio the recording is returned unchanged.
+func (aec *NLMSProcessor) Process(samples, loopback []float32) ([]float32, error) {
+	if len(loopback) == 0 {
+		fmt.Println("⚠️  AEC: No loopback samples captured!")
+		return samples, nil
+	}
+
+	fmt.Println("🔊 AEC: Processing with echo cancellation...")
+	// Ensure both samples have same length
+	minLen := len(samples)
+	if len(loopback) < minLen {
+		minLen = len(loopback)
+	}
+	if minLen == 0 {
+		return samples, nil
+	}
+
+	processed := aec.ProcessFrame(samples[:minLen], loopback[:minLen])
+	fmt.Printf("✅ AEC: Processed %d samples\n", minLen)
+	return processed, nil
+}
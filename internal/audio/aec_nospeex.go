@@ -0,0 +1,10 @@
+//go:build !speex
+
+package audio
+
+import "fmt"
+
+// newSpeexProcessor is unavailable in builds without libspeexdsp
+func newSpeexProcessor(config AECConfig) (AECProcessor, error) {
+	return nil, fmt.Errorf("this build has no speex support (install speexdsp and rebuild)")
+}
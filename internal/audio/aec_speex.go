@@ -0,0 +1,130 @@
+//go:build speex
+
+package audio
+
+/*
+#cgo pkg-config: speexdsp
+#include <speex/speex_echo.h>
+#include <speex/speex_preprocess.h>
+*/
+import "C"
+import (
+	"fmt"
+	"math"
+	"sync"
+	"unsafe"
+)
+
+// SpeexProcessor cancels echo with libspeexdsp's multi-delay block frequency
+// domain filter, followed by its residual echo suppressor. It copes with real
+// speaker echo (long tails, reverb) much better than the NLMS filter.
+type SpeexProcessor struct {
+	mu         sync.Mutex
+	echo       *C.SpeexEchoState
+	preprocess *C.SpeexPreprocessState
+	frameSize  int
+}
+
+// newSpeexProcessor creates a speex echo canceller. The echo tail is
+// config.FilterLength samples, but at least 200ms as speaker echo lasts longer
+// than the NLMS filter lengths.
+func newSpeexProcessor(config AECConfig) (AECProcessor, error) {
+	frameSize := config.SampleRate / 100 // 10ms frames
+	tail := config.FilterLength
+	if minTail := config.SampleRate / 5; tail < minTail {
+		tail = minTail
+	}
+
+	echo := C.speex_echo_state_init(C.int(frameSize), C.int(tail))
+	if echo == nil {
+		return nil, fmt.Errorf("failed to initialize speex echo canceller")
+	}
+	rate := C.spx_int32_t(config.SampleRate)
+	C.speex_echo_ctl(echo, C.SPEEX_ECHO_SET_SAMPLING_RATE, unsafe.Pointer(&rate))
+
+	preprocess := C.speex_preprocess_state_init(C.int(frameSize), C.int(config.SampleRate))
+	if preprocess == nil {
+		C.speex_echo_state_destroy(echo)
+		return nil, fmt.Errorf("failed to initialize speex preprocessor")
+	}
+	C.speex_preprocess_ctl(preprocess, C.SPEEX_PREPROCESS_SET_ECHO_STATE, unsafe.Pointer(echo))
+	// Only suppress residual echo, noise is left to the "denoise" stage
+	off := C.spx_int32_t(0)
+	C.speex_preprocess_ctl(preprocess, C.SPEEX_PREPROCESS_SET_DENOISE, unsafe.Pointer(&off))
+
+	fmt.Printf("🔊 AEC: speex echo canceller (%dms tail)\n", tail*1000/config.SampleRate)
+	return &SpeexProcessor{echo: echo, preprocess: preprocess, frameSize: frameSize}, nil
+}
+
+func (s *SpeexProcessor) Name() string { return "aec" }
+
+// Process cancels the system audio in loopback from the recording. Without
+// loopback audio the recording is returned unchanged.
+func (s *SpeexProcessor) Process(samples, loopback []float32) ([]float32, error) {
+	if len(loopback) == 0 {
+		fmt.Println("⚠️  AEC: No loopback samples captured!")
+		return samples, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.echo == nil {
+		return nil, fmt.Errorf("echo canceller closed")
+	}
+
+	n := len(samples)
+	if len(loopback) < n {
+		n = len(loopback)
+	}
+
+	fmt.Println("🔊 AEC: Processing with speex echo cancellation...")
+	rec := make([]C.spx_int16_t, s.frameSize)
+	play := make([]C.spx_int16_t, s.frameSize)
+	out := make([]C.spx_int16_t, s.frameSize)
+	output := make([]float32, n)
+
+	frames := n / s.frameSize
+	for f := 0; f < frames; f++ {
+		offset := f * s.frameSize
+		for i := 0; i < s.frameSize; i++ {
+			rec[i] = toInt16(samples[offset+i])
+			play[i] = toInt16(loopback[offset+i])
+		}
+		C.speex_echo_cancellation(s.echo, &rec[0], &play[0], &out[0])
+		C.speex_preprocess_run(s.preprocess, &out[0])
+		for i, v := range out {
+			output[offset+i] = float32(v) / 32768
+		}
+	}
+	// The last partial frame is kept as recorded
+	copy(output[frames*s.frameSize:], samples[frames*s.frameSize:n])
+
+	fmt.Printf("✅ AEC: Processed %d samples\n", n)
+	return output, nil
+}
+
+// Reset forgets the learned echo path
+func (s *SpeexProcessor) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.echo != nil {
+		C.speex_echo_state_reset(s.echo)
+	}
+}
+
+// Close frees the speex state
+func (s *SpeexProcessor) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.echo != nil {
+		C.speex_preprocess_state_destroy(s.preprocess)
+		C.speex_echo_state_destroy(s.echo)
+		s.echo = nil
+		s.preprocess = nil
+	}
+}
+
+// toInt16 converts a float sample to 16-bit PCM, clipping out of range values
+func toInt16(v float32) C.spx_int16_t {
+	return C.spx_int16_t(math.Max(-32768, math.Min(32767, float64(v)*32768)))
+}
@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveConfig contains the recording archive settings
+type ArchiveConfig struct {
+	Dir       string
+	MaxCount  int // Keep at most this many recordings (0 = unlimited)
+	MaxSizeMB int // Keep the archive below this size (0 = unlimited)
+}
+
+// Archive saves recordings as WAV files and enforces a retention policy
+type Archive struct {
+	config ArchiveConfig
+}
+
+// NewArchive creates a recording archive
+func NewArchive(config ArchiveConfig) *Archive {
+	return &Archive{config: config}
+}
+
+// Dir returns the archive directory
+func (a *Archive) Dir() string {
+	return a.config.Dir
+}
+
+// Save writes a recording to the archive and prunes old recordings.
+// Returns the path of the saved file.
+func (a *Archive) Save(samples []float32, sampleRate int) (string, error) {
+	if err := os.MkdirAll(a.config.Dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+
+	name := time.Now().Format("2006-01-02_15-04-05.000") + ".wav"
+	path := filepath.Join(a.config.Dir, name)
+	if err := WriteWAV(path, samples, sampleRate); err != nil {
+		return "", err
+	}
+
+	if err := a.prune(); err != nil {
+		fmt.Printf("⚠️  Failed to prune recordings: %v\n", err)
+	}
+	return path, nil
+}
+
+// prune removes the oldest recordings until the count and size limits are met
+func (a *Archive) prune() error {
+	if a.config.MaxCount <= 0 && a.config.MaxSizeMB <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(a.config.Dir)
+	if err != nil {
+		return err
+	}
+
+	type recording struct {
+		path string
+		size int64
+	}
+	var recordings []recording
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".wav") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, recording{filepath.Join(a.config.Dir, entry.Name()), info.Size()})
+		total += info.Size()
+	}
+
+	// File names are timestamps, so name order is age order
+	sort.Slice(recordings, func(i, j int) bool { return recordings[i].path < recordings[j].path })
+
+	maxSize := int64(a.config.MaxSizeMB) * 1024 * 1024
+	// Never delete the newest recording, even if it alone exceeds the size limit
+	for len(recordings) > 1 {
+		overCount := a.config.MaxCount > 0 && len(recordings) > a.config.MaxCount
+		overSize := maxSize > 0 && total > maxSize
+		if !overCount && !overSize {
+			break
+		}
+		if err := os.Remove(recordings[0].path); err != nil {
+			return err
+		}
+		total -= recordings[0].size
+		recordings = recordings[1:]
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package audio
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/gen2brain/malgo"
@@ -16,10 +19,23 @@ type Recorder struct {
 	deviceName *string
 	sampleRate uint32
 	channels   uint32
-
-	mu        sync.Mutex
-	recording bool
-	samples   []float32
+	nativeRate bool   // open the device at its own rate and resample
+	deviceRate uint32 // rate of samples
+	channel    int    // channel to record (1-based), 0 = average all channels
+
+	mu          sync.Mutex
+	recording   bool
+	samples     ringBuffer
+	maxDuration time.Duration // only the last maxDuration of a recording is kept (0 = unlimited)
+
+	preRoll   time.Duration // audio from before Start that is kept (0 = the device is only open while recording)
+	preBuffer ringBuffer    // the last preRoll of audio while listening
+	listening bool          // the device stays open between recordings for the pre-roll
+	preRolled int           // pre-roll samples at the start of the last recording (at the target rate)
+
+	fallback     bool        // the selected device was not available, the default is recording
+	live         atomic.Bool // the device is running and any stop is unexpected
+	onDisconnect func()      // called when the device stops during a recording
 }
 
 // LoopbackRecorder captures system audio for echo cancellation
@@ -28,16 +44,19 @@ type LoopbackRecorder struct {
 	device     *malgo.Device
 	sampleRate uint32
 	channels   uint32
+	nativeRate bool   // open the device at its own rate and resample
+	deviceRate uint32 // rate of samples
 
-	mu        sync.Mutex
-	recording bool
-	samples   []float32
+	mu          sync.Mutex
+	recording   bool
+	samples     ringBuffer
+	maxDuration time.Duration // only the last maxDuration of a recording is kept (0 = unlimited)
 }
 
 // NewRecorder creates a new audio recorder
-// deviceName: optional device name filter (e.g. "Mic1", "default", or nil for default)
+// deviceName: optional device selector (ID, index, name or part of the name, see MatchDevice; nil for default)
 func NewRecorder(sampleRate int, deviceName *string) (*Recorder, error) {
-	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	ctx, err := initContext()
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize audio context: %w", err)
 	}
@@ -52,13 +71,12 @@ func NewRecorder(sampleRate int, deviceName *string) (*Recorder, error) {
 		deviceName: deviceName,
 		sampleRate: uint32(sampleRate),
 		channels:   1, // mono
-		samples:    make([]float32, 0),
 	}, nil
 }
 
 // NewLoopbackRecorder creates a system audio loopback recorder
 func NewLoopbackRecorder(sampleRate int) (*LoopbackRecorder, error) {
-	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	ctx, err := initContext()
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize audio context: %w", err)
 	}
@@ -67,10 +85,109 @@ func NewLoopbackRecorder(sampleRate int) (*LoopbackRecorder, error) {
 		ctx:        ctx,
 		sampleRate: uint32(sampleRate),
 		channels:   1, // mono
-		samples:    make([]float32, 0),
 	}, nil
 }
 
+// CaptureDevice describes an available capture device
+type CaptureDevice struct {
+	Index     int
+	ID        string // Backend identifier, e.g. the PulseAudio source name (stable across reboots)
+	Name      string
+	IsDefault bool
+	IsMonitor bool // Monitor of an output (system audio), not a microphone
+}
+
+// ListCaptureDevices returns all available capture devices
+func ListCaptureDevices() ([]CaptureDevice, error) {
+	ctx, err := initContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize audio context: %w", err)
+	}
+	defer func() {
+		_ = ctx.Uninit()
+		ctx.Free()
+	}()
+
+	devices, err := ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	return captureDevices(devices), nil
+}
+
+// captureDevices converts malgo's device list
+func captureDevices(devices []malgo.DeviceInfo) []CaptureDevice {
+	result := make([]CaptureDevice, 0, len(devices))
+	for i, dev := range devices {
+		result = append(result, CaptureDevice{
+			Index:     i,
+			ID:        deviceID(dev.ID),
+			Name:      dev.Name(),
+			IsDefault: dev.IsDefault != 0,
+			IsMonitor: strings.Contains(strings.ToLower(dev.Name()), "monitor"),
+		})
+	}
+	return result
+}
+
+// deviceID returns a readable device identifier. Most backends (PulseAudio, ALSA,
+// JACK) identify devices by a name string, others by opaque bytes shown as hex.
+func deviceID(id malgo.DeviceID) string {
+	end := 0
+	for end < len(id) && id[end] != 0 {
+		if id[end] < 0x20 || id[end] > 0x7e {
+			return id.String()
+		}
+		end++
+	}
+	if end == 0 {
+		return id.String()
+	}
+	return string(id[:end])
+}
+
+// MatchDevice finds the device a selector refers to. It is tried as an exact ID,
+// an index from "hyprwhspr devices", an exact name and finally a part of the name
+// (case-insensitive). A partial name that matches several devices is an error.
+func MatchDevice(devices []CaptureDevice, selector string) (CaptureDevice, error) {
+	for _, dev := range devices {
+		if dev.ID == selector {
+			return dev, nil
+		}
+	}
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 0 || index >= len(devices) {
+			return CaptureDevice{}, fmt.Errorf("no device with index %d (%d devices)", index, len(devices))
+		}
+		return devices[index], nil
+	}
+	for _, dev := range devices {
+		if strings.EqualFold(dev.Name, selector) {
+			return dev, nil
+		}
+	}
+
+	var matches []CaptureDevice
+	for _, dev := range devices {
+		if containsIgnoreCase(dev.Name, selector) {
+			matches = append(matches, dev)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return CaptureDevice{}, fmt.Errorf("no device matches '%s'", selector)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, dev := range matches {
+		names[i] = fmt.Sprintf("[%d] %s", dev.Index, dev.Name)
+	}
+	return CaptureDevice{}, fmt.Errorf("'%s' matches %d devices (%s), use the ID or index from 'hyprwhspr devices'",
+		selector, len(matches), strings.Join(names, ", "))
+}
+
 // listAvailableDevices prints all available capture devices
 func listAvailableDevices(ctx *malgo.AllocatedContext) error {
 	devices, err := ctx.Devices(malgo.Capture)
@@ -79,12 +196,12 @@ func listAvailableDevices(ctx *malgo.AllocatedContext) error {
 	}
 
 	fmt.Println("[audio] Available capture devices:")
-	for i, device := range devices {
+	for _, device := range captureDevices(devices) {
 		deviceType := "🎤 MICROPHONE"
-		if strings.Contains(strings.ToLower(device.Name()), "monitor") {
+		if device.IsMonitor {
 			deviceType = "🔊 SYSTEM AUDIO (avoid this)"
 		}
-		fmt.Printf("  [%d] %s - %s\n", i, device.Name(), deviceType)
+		fmt.Printf("  [%d] %s - %s (%s)\n", device.Index, device.Name, deviceType, device.ID)
 	}
 	return nil
 }
@@ -98,62 +215,180 @@ func (r *Recorder) Start() error {
 		return fmt.Errorf("already recording")
 	}
 
-	// Reset samples buffer
-	r.samples = make([]float32, 0, r.sampleRate*10) // pre-allocate for ~10 seconds
+	// Keep listening with the open device, starting with the pre-roll
+	if r.listening && r.live.Load() {
+		pre := r.preBuffer.Samples()
+		r.samples = newRingBuffer(bufferLimit(r.maxDuration, r.deviceRate))
+		r.samples.Write(pre)
+		r.preBuffer = newRingBuffer(bufferLimit(r.preRoll, r.deviceRate))
+		r.preRolled = len(r.resampled(pre))
+		r.recording = true
+		fmt.Printf("🎤 Recording started (%.1fs pre-roll)\n", float64(len(pre))/float64(r.deviceRate))
+		return nil
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	// The device rate is known now, nothing is captured before recording is set
+	r.samples = newRingBuffer(bufferLimit(r.maxDuration, r.deviceRate))
+	r.preRolled = 0
+	r.recording = true
+	fmt.Println("🎤 Recording started")
+	return nil
+}
 
+// open opens the capture device, reconnecting to the sound server and falling
+// back to the default device if needed. Called with r.mu held.
+func (r *Recorder) open() error {
+	// A device that disappeared while listening is still allocated
+	r.closeDevice()
+
+	// The context is released while suspended
+	if r.ctx == nil {
+		if err := r.resetContext(); err != nil {
+			return err
+		}
+	}
+
+	err := r.openDevice(true)
+	if err != nil {
+		// The device list or the sound server connection may be stale after a
+		// device was unplugged or the sound server restarted
+		fmt.Printf("[WARN] %v, reconnecting to the sound server\n", err)
+		if ctxErr := r.resetContext(); ctxErr != nil {
+			return ctxErr
+		}
+		err = r.openDevice(true)
+		if err != nil && r.deviceName != nil && *r.deviceName != "" {
+			fmt.Printf("[WARN] %v, falling back to the default device\n", err)
+			err = r.openDevice(false)
+		}
+	}
+	return err
+}
+
+// closeDevice stops and releases the capture device. Called with r.mu held.
+func (r *Recorder) closeDevice() {
+	r.live.Store(false)
+	r.listening = false
+	if r.device != nil {
+		r.device.Stop()
+		r.device.Uninit()
+		r.device = nil
+	}
+}
+
+// SetPreRoll keeps the microphone open between recordings and prepends the
+// last preRoll of audio to every recording, so a word spoken while pressing
+// the hotkey isn't clipped (0 = only open the microphone while recording).
+// Listen opens the microphone.
+func (r *Recorder) SetPreRoll(preRoll time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preRoll = preRoll
+}
+
+// Listen opens the microphone for the pre-roll until the next recording.
+// It does nothing without a pre-roll or while the microphone is open.
+func (r *Recorder) Listen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.preRoll <= 0 || r.recording || (r.listening && r.live.Load()) {
+		return nil
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.preBuffer = newRingBuffer(bufferLimit(r.preRoll, r.deviceRate))
+	r.listening = true
+	fmt.Printf("👂 Microphone open for %v pre-roll\n", r.preRoll)
+	return nil
+}
+
+// PreRolled returns how many samples at the start of the last recording were
+// captured before Start
+func (r *Recorder) PreRolled() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.preRolled
+}
+
+// resetContext replaces the audio context with a new one
+func (r *Recorder) resetContext() error {
+	if r.ctx != nil {
+		_ = r.ctx.Uninit()
+		r.ctx.Free()
+		r.ctx = nil
+	}
+	ctx, err := initContext()
+	if err != nil {
+		return fmt.Errorf("failed to initialize audio context: %w", err)
+	}
+	r.ctx = ctx
+	return nil
+}
+
+// openDevice initializes and starts the capture device, the selected one if
+// selected is true and one is configured, otherwise the default. Called with r.mu held.
+func (r *Recorder) openDevice(selected bool) error {
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	deviceConfig.Capture.Format = malgo.FormatF32
 	deviceConfig.Capture.Channels = r.channels
 	deviceConfig.SampleRate = r.sampleRate
+	deviceConfig.Pulse.StreamNameCapture = captureStreamName
 	deviceConfig.Alsa.NoMMap = 1
+	if r.nativeRate {
+		deviceConfig.SampleRate = 0 // The device's native rate
+	}
 
 	// Select specific device if deviceName is provided
-	if r.deviceName != nil && *r.deviceName != "" {
+	r.fallback = false
+	if r.deviceName != nil && *r.deviceName != "" && selected {
 		devices, err := r.ctx.Devices(malgo.Capture)
 		if err != nil {
 			return fmt.Errorf("failed to list devices: %w", err)
 		}
 
-		deviceFound := false
-		for _, dev := range devices {
-			if containsIgnoreCase(dev.Name(), *r.deviceName) {
-				deviceConfig.Capture.DeviceID = dev.ID.Pointer()
-
-				// Warn if selecting a monitor device
-				if strings.Contains(strings.ToLower(dev.Name()), "monitor") {
-					fmt.Printf("⚠️  WARNING: Selected device '%s' is a MONITOR (system audio)\n", dev.Name())
-					fmt.Printf("⚠️  This will capture playing audio, not your microphone!\n")
-				} else {
-					fmt.Printf("✅ Using microphone: %s\n", dev.Name())
-				}
-
-				deviceFound = true
-				break
+		dev, err := MatchDevice(captureDevices(devices), *r.deviceName)
+		if err != nil {
+			fmt.Printf("[WARN] %v, using default device\n", err)
+			fmt.Println("[WARN] Check available devices with 'hyprwhspr devices'")
+			r.fallback = true
+		} else {
+			deviceConfig.Capture.DeviceID = devices[dev.Index].ID.Pointer()
+
+			// Warn if selecting a monitor device
+			if dev.IsMonitor {
+				fmt.Printf("⚠️  WARNING: Selected device '%s' is a MONITOR (system audio)\n", dev.Name)
+				fmt.Printf("⚠️  This will capture playing audio, not your microphone!\n")
+			} else {
+				fmt.Printf("✅ Using microphone: %s\n", dev.Name)
 			}
 		}
-
-		if !deviceFound {
-			fmt.Printf("[WARN] Device '%s' not found, using default device\n", *r.deviceName)
-			fmt.Println("[WARN] Check available devices list above")
-		}
 	} else {
+		r.fallback = r.deviceName != nil && *r.deviceName != ""
 		fmt.Println("[audio] Using default capture device")
 	}
 
-	// Callback to receive audio data
+	// Callback to receive audio data. Frames have as many samples as the device
+	// has channels, which is only known once it is initialized.
+	var channels uint32 = 1
 	onRecvFrames := func(pSample2, pSample []byte, framecount uint32) {
 		r.mu.Lock()
 		defer r.mu.Unlock()
 
-		if !r.recording {
+		if !r.recording && !r.listening {
 			return
 		}
 
-		// Convert bytes to float32 samples
-		samples := make([]float32, framecount)
-		for i := uint32(0); i < framecount; i++ {
+		// Convert bytes to float32 samples (interleaved frames)
+		samples := make([]float32, framecount*channels)
+		for i := range samples {
 			idx := i * 4 // 4 bytes per float32
-			if idx+3 < uint32(len(pSample)) {
+			if idx+3 < len(pSample) {
 				// Convert bytes to float32 (little-endian)
 				bits := uint32(pSample[idx]) |
 					uint32(pSample[idx+1])<<8 |
@@ -163,26 +398,105 @@ func (r *Recorder) Start() error {
 			}
 		}
 
-		r.samples = append(r.samples, samples...)
+		if r.recording {
+			r.samples.Write(toMono(samples, int(channels), r.channel))
+		} else {
+			r.preBuffer.Write(toMono(samples, int(channels), r.channel))
+		}
+	}
+
+	// Called when the device stops, by Stop or because it disappeared
+	onStop := func() {
+		if r.live.Swap(false) && r.onDisconnect != nil {
+			go r.onDisconnect()
+		}
 	}
 
 	var err error
-	r.device, err = malgo.InitDevice(r.ctx.Context, deviceConfig, malgo.DeviceCallbacks{
+	r.device, err = initDevice(r.ctx, deviceConfig, malgo.DeviceCallbacks{
 		Data: onRecvFrames,
+		Stop: onStop,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize device: %w", err)
 	}
+	channels = r.device.CaptureChannels()
+	if channels > 1 {
+		if r.channel > int(channels) {
+			fmt.Printf("[WARN] Device has %d channels, can't record channel %d, mixing all channels\n", channels, r.channel)
+		} else if r.channel > 0 {
+			fmt.Printf("[audio] Recording channel %d of %d\n", r.channel, channels)
+		} else {
+			fmt.Printf("[audio] Mixing %d channels to mono\n", channels)
+		}
+	}
 
 	if err := r.device.Start(); err != nil {
+		r.device.Uninit()
+		r.device = nil
 		return fmt.Errorf("failed to start device: %w", err)
 	}
-
-	r.recording = true
-	fmt.Println("🎤 Recording started")
+	r.deviceRate = r.device.SampleRate()
+	if r.deviceRate != r.sampleRate {
+		fmt.Printf("[audio] Capturing at %d Hz, resampling to %d Hz\n", r.deviceRate, r.sampleRate)
+	}
+	r.live.Store(true)
 	return nil
 }
 
+// SetChannels sets how many channels the device is opened with (0 = its native
+// count) and which one is recorded (1-based, 0 = average all). Some audio
+// interfaces only offer a stereo or multi-channel stream.
+func (r *Recorder) SetChannels(channels, channel int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels = uint32(channels)
+	r.channel = channel
+}
+
+// SetNativeRate makes the recorder open the device at its native sample rate
+// (e.g. 48 kHz) and resample the recording itself, instead of asking the backend
+// for the target rate, which some backends and devices convert badly
+func (r *Recorder) SetNativeRate(native bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nativeRate = native
+}
+
+// SetMaxDuration limits how much audio a recording keeps. Longer recordings
+// keep their last maxDuration, so memory stays bounded (0 = unlimited).
+func (r *Recorder) SetMaxDuration(maxDuration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxDuration = maxDuration
+}
+
+// bufferLimit returns the number of samples in maxDuration at rate (0 = unlimited)
+func bufferLimit(maxDuration time.Duration, rate uint32) int {
+	return int(maxDuration.Seconds() * float64(rate))
+}
+
+// resampled converts captured samples to the target rate
+func (r *Recorder) resampled(samples []float32) []float32 {
+	return Resample(samples, int(r.deviceRate), int(r.sampleRate))
+}
+
+// SetDisconnectHandler sets a function that is called when the capture device
+// stops during a recording, e.g. because the microphone was unplugged
+func (r *Recorder) SetDisconnectHandler(handler func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onDisconnect = handler
+}
+
+// Fallback returns whether the last recording used the default device because
+// the selected one was not available
+func (r *Recorder) Fallback() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fallback
+}
+
 // Start starts capturing system audio
 func (lr *LoopbackRecorder) Start() error {
 	lr.mu.Lock()
@@ -192,13 +506,24 @@ func (lr *LoopbackRecorder) Start() error {
 		return fmt.Errorf("already recording")
 	}
 
-	lr.samples = make([]float32, 0, lr.sampleRate*10)
+	// The context is released while suspended
+	if lr.ctx == nil {
+		ctx, err := initContext()
+		if err != nil {
+			return fmt.Errorf("failed to initialize audio context: %w", err)
+		}
+		lr.ctx = ctx
+	}
 
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	deviceConfig.Capture.Format = malgo.FormatF32
 	deviceConfig.Capture.Channels = lr.channels
 	deviceConfig.SampleRate = lr.sampleRate
+	deviceConfig.Pulse.StreamNameCapture = loopbackStreamName
 	deviceConfig.Alsa.NoMMap = 1
+	if lr.nativeRate {
+		deviceConfig.SampleRate = 0 // The device's native rate
+	}
 
 	// Find speaker monitor device
 	devices, err := lr.ctx.Devices(malgo.Capture)
@@ -287,7 +612,7 @@ func (lr *LoopbackRecorder) Start() error {
 			}
 		}
 
-		lr.samples = append(lr.samples, samples...)
+		lr.samples.Write(samples)
 	}
 
 	// Try each monitor device until one works
@@ -297,7 +622,7 @@ func (lr *LoopbackRecorder) Start() error {
 		fmt.Printf("🔄 Trying loopback device [%d]: %s\n", i, monitorDevice.Name())
 
 		var initErr error
-		lr.device, initErr = malgo.InitDevice(lr.ctx.Context, deviceConfig, malgo.DeviceCallbacks{
+		lr.device, initErr = initDevice(lr.ctx, deviceConfig, malgo.DeviceCallbacks{
 			Data: onRecvFrames,
 		})
 
@@ -316,6 +641,8 @@ func (lr *LoopbackRecorder) Start() error {
 		}
 
 		fmt.Printf("✅ Successfully using loopback device: %s\n", monitorDevice.Name())
+		lr.deviceRate = lr.device.SampleRate()
+		lr.samples = newRingBuffer(bufferLimit(lr.maxDuration, lr.deviceRate))
 		lr.recording = true
 		return nil
 	}
@@ -335,15 +662,19 @@ func (r *Recorder) Stop() ([]float32, error) {
 	}
 
 	r.recording = false
-
-	if r.device != nil {
-		r.device.Stop()
-		r.device.Uninit()
-		r.device = nil
+	if r.preRoll > 0 && r.live.Load() {
+		// Keep listening for the next recording's pre-roll
+		r.preBuffer = newRingBuffer(bufferLimit(r.preRoll, r.deviceRate))
+		r.listening = true
+	} else {
+		r.closeDevice()
 	}
 
-	fmt.Printf("🛑 Recording stopped (%d samples)\n", len(r.samples))
-	return r.samples, nil
+	fmt.Printf("🛑 Recording stopped (%d samples)\n", r.samples.Len())
+	if dropped := r.samples.Dropped(); dropped > 0 {
+		fmt.Printf("⚠️  Recording was longer than %v, the first %.0fs were discarded\n", r.maxDuration, float64(dropped)/float64(r.deviceRate))
+	}
+	return r.resampled(r.samples.Samples()), nil
 }
 
 // Stop stops loopback recording
@@ -363,7 +694,71 @@ func (lr *LoopbackRecorder) Stop() ([]float32, error) {
 		lr.device = nil
 	}
 
-	return lr.samples, nil
+	return Resample(lr.samples.Samples(), int(lr.deviceRate), int(lr.sampleRate)), nil
+}
+
+// Snapshot returns a copy of the audio captured so far without stopping the
+// recording, and how many samples of the start of the recording were discarded
+// before it (see Dropped)
+func (r *Recorder) Snapshot() ([]float32, int) {
+	// Resample after unlocking, the capture callback waits for the lock
+	r.mu.Lock()
+	samples, dropped, deviceRate := r.samples.Samples(), r.samples.Dropped(), r.deviceRate
+	r.mu.Unlock()
+
+	return Resample(samples, int(deviceRate), int(r.sampleRate)), outputSamples(dropped, deviceRate, r.sampleRate)
+}
+
+// Dropped returns how many samples (at the output rate) of the start of the
+// current or last recording were overwritten because it exceeded the maximum
+// duration. The recorded audio starts that many samples into the recording.
+func (r *Recorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return outputSamples(r.samples.Dropped(), r.deviceRate, r.sampleRate)
+}
+
+// outputSamples converts a number of samples at deviceRate to sampleRate
+func outputSamples(n int, deviceRate, sampleRate uint32) int {
+	if deviceRate == 0 {
+		return n
+	}
+	return int(int64(n) * int64(sampleRate) / int64(deviceRate))
+}
+
+// Duration returns how much audio the recording holds, without copying it
+func (r *Recorder) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.deviceRate == 0 {
+		return 0
+	}
+	return time.Duration(r.samples.Len()) * time.Second / time.Duration(r.deviceRate)
+}
+
+// levelWindow is how much of the latest audio Level measures
+const levelWindow = 50 * time.Millisecond
+
+// Level returns the input level of the latest audio of the recording.
+// ok is false when not recording.
+func (r *Recorder) Level() (level Level, ok bool) {
+	level, _, ok = r.RecentLevel(levelWindow)
+	return level, ok
+}
+
+// RecentLevel returns the input level of the last window of the recording and
+// how much audio it was measured over, which is less at the start of a
+// recording. ok is false when not recording.
+func (r *Recorder) RecentLevel(window time.Duration) (level Level, measured time.Duration, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recording {
+		return Level{}, 0, false
+	}
+	samples := r.samples.Last(int(window * time.Duration(r.deviceRate) / time.Second))
+	return MeasureLevel(samples), time.Duration(len(samples)) * time.Second / time.Duration(r.deviceRate), true
 }
 
 // IsRecording returns true if currently recording
@@ -373,10 +768,25 @@ func (r *Recorder) IsRecording() bool {
 	return r.recording
 }
 
+// Suspend releases the audio context while the daemon is idle.
+// The next Start initializes it again.
+func (r *Recorder) Suspend() {
+	if r.IsRecording() {
+		return
+	}
+	r.Close()
+	r.mu.Lock()
+	r.samples = ringBuffer{}
+	r.preBuffer = ringBuffer{}
+	r.mu.Unlock()
+}
+
 // Close closes the recorder and releases resources
 func (r *Recorder) Close() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.live.Store(false)
+	r.listening = false
 
 	if r.device != nil {
 		r.device.Uninit()
@@ -390,6 +800,37 @@ func (r *Recorder) Close() {
 	}
 }
 
+// SetNativeRate makes the recorder open the monitor at its native sample rate
+// and resample the recording itself
+func (lr *LoopbackRecorder) SetNativeRate(native bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.nativeRate = native
+}
+
+// SetMaxDuration limits how much system audio a recording keeps, it should
+// match the microphone recorder so both stay aligned
+func (lr *LoopbackRecorder) SetMaxDuration(maxDuration time.Duration) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.maxDuration = maxDuration
+}
+
+// Suspend releases the audio context while the daemon is idle.
+// The next Start initializes it again.
+func (lr *LoopbackRecorder) Suspend() {
+	lr.mu.Lock()
+	recording := lr.recording
+	lr.mu.Unlock()
+	if recording {
+		return
+	}
+	lr.Close()
+	lr.mu.Lock()
+	lr.samples = ringBuffer{}
+	lr.mu.Unlock()
+}
+
 // Close closes the loopback recorder
 func (lr *LoopbackRecorder) Close() {
 	lr.mu.Lock()
@@ -0,0 +1,24 @@
+package audio
+
+// toMono converts interleaved frames to mono: channel (1-based) picks one
+// channel, 0 or a channel the stream doesn't have averages all of them
+func toMono(interleaved []float32, channels, channel int) []float32 {
+	if channels <= 1 {
+		return interleaved
+	}
+
+	mono := make([]float32, len(interleaved)/channels)
+	for i := range mono {
+		frame := interleaved[i*channels : (i+1)*channels]
+		if channel > 0 && channel <= channels {
+			mono[i] = frame[channel-1]
+			continue
+		}
+		var sum float32
+		for _, s := range frame {
+			sum += s
+		}
+		mono[i] = sum / float32(channels)
+	}
+	return mono
+}
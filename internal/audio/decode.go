@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/vorbis"
+	"github.com/gopxl/beep/wav"
+)
+
+// NativeFormats are the file extensions decoded without external tools
+var NativeFormats = []string{".wav", ".ogg"}
+
+// FFmpegFormats are additional file extensions decoded with ffmpeg (if installed)
+var FFmpegFormats = []string{".mp3", ".m4a", ".opus", ".flac", ".webm", ".aac", ".mp4", ".mkv"}
+
+// IsAudioFile returns whether a file can be decoded by DecodeFile
+func IsAudioFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range NativeFormats {
+		if ext == e {
+			return true
+		}
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return false
+	}
+	for _, e := range FFmpegFormats {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeFile decodes an audio file into mono float32 samples at the given sample rate.
+// WAV and Ogg Vorbis are decoded natively, everything else is converted with ffmpeg.
+func DecodeFile(path string, sampleRate int) ([]float32, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".wav" && ext != ".ogg" {
+		return decodeWithFFmpeg(path, sampleRate)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var streamer beep.StreamSeekCloser
+	var format beep.Format
+	if ext == ".wav" {
+		streamer, format, err = wav.Decode(f)
+	} else {
+		streamer, format, err = vorbis.Decode(f)
+	}
+	if err != nil {
+		f.Close()
+		// Vorbis decoding fails for Opus-in-Ogg, let ffmpeg try
+		if _, lookErr := exec.LookPath("ffmpeg"); lookErr == nil {
+			return decodeWithFFmpeg(path, sampleRate)
+		}
+		return nil, fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+	}
+	defer streamer.Close()
+
+	var s beep.Streamer = streamer
+	if int(format.SampleRate) != sampleRate {
+		s = beep.Resample(4, format.SampleRate, beep.SampleRate(sampleRate), streamer)
+	}
+
+	// Downmix to mono
+	samples := make([]float32, 0, streamer.Len())
+	buf := make([][2]float64, 4096)
+	for {
+		n, ok := s.Stream(buf)
+		for _, frame := range buf[:n] {
+			samples = append(samples, float32((frame[0]+frame[1])/2))
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+	}
+	return samples, nil
+}
+
+// decodeWithFFmpeg converts any format ffmpeg understands into mono float32 samples
+func decodeWithFFmpeg(path string, sampleRate int) ([]float32, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, fmt.Errorf("unsupported format %s (install ffmpeg to decode it)", filepath.Ext(path))
+	}
+
+	cmd := exec.Command("ffmpeg", "-nostdin", "-loglevel", "error", "-i", path,
+		"-f", "f32le", "-ac", "1", "-ar", strconv.Itoa(sampleRate), "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed for %s: %w: %s", filepath.Base(path), err, strings.TrimSpace(stderr.String()))
+	}
+
+	samples := make([]float32, len(output)/4)
+	for i := range samples {
+		samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(output[i*4:]))
+	}
+	return samples, nil
+}
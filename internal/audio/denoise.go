@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// denoiseFrameDuration is the shortest frame the Denoiser analyzes, the frame
+// size is rounded up to a power of two for the FFT
+const denoiseFrameDuration = 0.032
+
+// NewDenoiseProcessor creates the "denoise" stage of a backend: "spectral"
+// (built-in spectral subtraction) or "rnnoise" (librnnoise, needs a build with
+// the rnnoise tag)
+func NewDenoiseProcessor(backend string, strength float64, sampleRate int) (Processor, error) {
+	switch backend {
+	case "", "spectral":
+		return NewDenoiser(strength, sampleRate), nil
+	case "rnnoise":
+		return newRNNoiseProcessor(sampleRate)
+	default:
+		return nil, fmt.Errorf("unknown denoise backend %q", backend)
+	}
+}
+
+// Denoiser suppresses stationary background noise (fans, hum, hiss, room tone)
+// by spectral subtraction. The noise spectrum is estimated from the quietest
+// frames of the recording, so there is no training or model to load. Short
+// bursts like keyboard clatter are not stationary and pass through, the
+// rnnoise backend removes those.
+type Denoiser struct {
+	strength  float64 // How much of the noise estimate is subtracted
+	floor     float64 // Lowest gain, keeps some noise to avoid "musical" artifacts
+	frameSize int     // Samples per frame, frames overlap by 50%
+}
+
+// NewDenoiser creates a noise suppression stage for audio at sampleRate.
+// strength scales the noise estimate, 1 is a good default, higher values
+// remove more noise and more speech.
+func NewDenoiser(strength float64, sampleRate int) *Denoiser {
+	frameSize := 2
+	for float64(frameSize) < denoiseFrameDuration*float64(sampleRate) {
+		frameSize *= 2
+	}
+	return &Denoiser{strength: strength, floor: 0.1, frameSize: frameSize}
+}
+
+func (d *Denoiser) Name() string { return "denoise" }
+
+func (d *Denoiser) Process(samples, loopback []float32) ([]float32, error) {
+	// A periodic Hann window at 50% overlap sums to one, so unmodified frames
+	// add up to the input
+	frameSize := d.frameSize
+	hop := frameSize / 2
+	if len(samples) < 4*frameSize {
+		return samples, nil // Too short to tell noise from speech
+	}
+
+	window := make([]float64, frameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(frameSize))
+	}
+
+	// Pad so every sample is covered by two frames
+	padded := make([]float64, len(samples)+2*frameSize)
+	for i, s := range samples {
+		padded[i+hop] = float64(s)
+	}
+	frameCount := (len(padded)-frameSize)/hop + 1
+
+	// Spectra of all frames
+	bins := frameSize/2 + 1
+	spectra := make([][]complex128, frameCount)
+	energy := make([]float64, frameCount)
+	for f := range spectra {
+		frame := make([]complex128, frameSize)
+		for i := range frame {
+			frame[i] = complex(padded[f*hop+i]*window[i], 0)
+		}
+		fft(frame)
+		spectra[f] = frame
+		for b := 0; b < bins; b++ {
+			energy[f] += sqAbs(frame[b])
+		}
+	}
+
+	// Noise power per bin: average of the quietest 10% of the frames. Frames
+	// reaching into the zero padding would bias the estimate low.
+	var order []int
+	for f := 0; f < frameCount; f++ {
+		if start := f * hop; start >= hop && start+frameSize <= hop+len(samples) {
+			order = append(order, f)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool { return energy[order[i]] < energy[order[j]] })
+	quiet := order[:len(order)/10+1]
+	noise := make([]float64, bins)
+	for _, f := range quiet {
+		for b := 0; b < bins; b++ {
+			noise[b] += sqAbs(spectra[f][b]) / float64(len(quiet))
+		}
+	}
+
+	// Scale each bin by a Wiener-like gain, smoothed over time against musical noise
+	out := make([]float64, len(padded))
+	gains := make([]float64, bins)
+	for b := range gains {
+		gains[b] = 1
+	}
+	for f, frame := range spectra {
+		for b := 0; b < bins; b++ {
+			gain := d.floor
+			if power := sqAbs(frame[b]); power > 0 {
+				gain = math.Max(1-d.strength*noise[b]/power, d.floor)
+			}
+			gains[b] = 0.6*gain + 0.4*gains[b]
+			frame[b] *= complex(gains[b], 0)
+			if b > 0 && b < frameSize/2 {
+				frame[frameSize-b] = cmplx.Conj(frame[b]) // Keep the signal real
+			}
+		}
+		ifft(frame)
+		for i, v := range frame {
+			out[f*hop+i] += real(v)
+		}
+	}
+
+	result := make([]float32, len(samples))
+	for i := range result {
+		result[i] = float32(out[i+hop])
+	}
+	fmt.Printf("🔇 Denoise: suppressed background noise (%d frames)\n", frameCount)
+	return result, nil
+}
+
+// sqAbs returns |c|²
+func sqAbs(c complex128) float64 {
+	return real(c)*real(c) + imag(c)*imag(c)
+}
+
+// fft is an in-place radix-2 Cooley-Tukey FFT, len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+// ifft is the inverse of fft
+func ifft(x []complex128) {
+	for i := range x {
+		x[i] = cmplx.Conj(x[i])
+	}
+	fft(x)
+	scale := complex(1/float64(len(x)), 0)
+	for i := range x {
+		x[i] = cmplx.Conj(x[i]) * scale
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !rnnoise
+
+package audio
+
+import "fmt"
+
+// newRNNoiseProcessor is unavailable in builds without librnnoise
+func newRNNoiseProcessor(sampleRate int) (Processor, error) {
+	return nil, fmt.Errorf("this build has no rnnoise support (install rnnoise and rebuild)")
+}
@@ -0,0 +1,41 @@
+package audio
+
+import "math"
+
+// levelFloorDB is reported for silence, whose level in dBFS is -Inf
+const levelFloorDB = -100
+
+// Level is the loudness of a stretch of audio, both as linear amplitude
+// (0-1, full scale) and in dBFS
+type Level struct {
+	RMS    float64 `json:"rms"`
+	Peak   float64 `json:"peak"`
+	RMSdB  float64 `json:"rms_db"`
+	PeakdB float64 `json:"peak_db"`
+}
+
+// MeasureLevel returns the RMS and peak level of samples
+func MeasureLevel(samples []float32) Level {
+	var sum, peak float64
+	for _, s := range samples {
+		v := math.Abs(float64(s))
+		sum += v * v
+		if v > peak {
+			peak = v
+		}
+	}
+
+	var rms float64
+	if len(samples) > 0 {
+		rms = math.Sqrt(sum / float64(len(samples)))
+	}
+	return Level{RMS: rms, Peak: peak, RMSdB: toDBFS(rms), PeakdB: toDBFS(peak)}
+}
+
+// toDBFS converts a linear amplitude to dBFS, rounded to 0.1 dB
+func toDBFS(v float64) float64 {
+	if v <= 0 {
+		return levelFloorDB
+	}
+	return math.Max(levelFloorDB, math.Round(200*math.Log10(v))/10)
+}
@@ -4,13 +4,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/generators"
 	"github.com/gopxl/beep/speaker"
 	"github.com/gopxl/beep/vorbis"
 )
 
-var speakerInitialized = false
+// speakerRate is the rate the speaker runs at, sounds are resampled to it
+const speakerRate = beep.SampleRate(44100)
+
+// speakerMu guards speakerInitialized. Sounds hold it for reading while they
+// play, so Suspend waits for them instead of closing the speaker under them.
+var (
+	speakerMu          sync.RWMutex
+	speakerInitialized = false
+)
 
 // simpleVolume is a straightforward volume control that directly multiplies samples
 type simpleVolume struct {
@@ -38,14 +51,23 @@ type PlayerConfig struct {
 	StopSoundVolume  float64
 	StartSoundPath   *string
 	StopSoundPath    *string
+
+	WarningSoundVolume float64
+	WarningSoundPath   *string // nil = built-in tick
+
+	SoundTheme string // Theme name or directory, empty = default sounds
 }
 
 // Player handles audio playback for notification sounds
 type Player struct {
-	config         PlayerConfig
-	startSoundPath string
-	stopSoundPath  string
-	enabled        bool
+	config           PlayerConfig
+	startSoundPath   string
+	stopSoundPath    string
+	warningSoundPath string // empty = built-in tick
+	errorSoundPath   string // empty = silent
+	doneSoundPath    string // empty = silent
+	limitSoundPath   string // empty = built-in falling tones
+	enabled          bool
 }
 
 // NewPlayer creates a new audio player
@@ -68,6 +90,12 @@ func NewPlayer(config PlayerConfig) (*Player, error) {
 		player.config.StopSoundVolume = 1.0
 	}
 
+	if player.config.WarningSoundVolume < 0.0 {
+		player.config.WarningSoundVolume = 0.0
+	} else if player.config.WarningSoundVolume > 1.0 {
+		player.config.WarningSoundVolume = 1.0
+	}
+
 	// Resolve sound file paths
 	if err := player.resolveSoundPaths(); err != nil {
 		fmt.Printf("⚠️  Audio feedback disabled: %v\n", err)
@@ -78,8 +106,8 @@ func NewPlayer(config PlayerConfig) (*Player, error) {
 	return player, nil
 }
 
-// resolveSoundPaths finds the sound files
-func (p *Player) resolveSoundPaths() error {
+// assetPaths returns the directories searched for the default sounds
+func assetPaths() []string {
 	// Get home directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -94,13 +122,77 @@ func (p *Player) resolveSoundPaths() error {
 	execDir := filepath.Dir(execPath)
 
 	// Try multiple possible asset locations
-	possiblePaths := []string{
+	return []string{
 		filepath.Join(homeDir, ".local", "share", "hyprwhspr", "assets"), // XDG user data dir with assets subdir
 		filepath.Join(homeDir, ".local", "share", "hyprwhspr"),           // XDG user data dir (no subdir)
 		filepath.Join(execDir, "share", "assets"),                        // Next to binary
 		filepath.Join(execDir, "..", "share", "assets"),                  // Up one level from bin/
 		"share/assets", // Relative to working directory
 	}
+}
+
+// FindAsset returns the path of a file in the first assets directory having it
+func FindAsset(name string) (string, error) {
+	for _, dir := range assetPaths() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found in the assets directories", name)
+}
+
+// themeDirs returns the directories containing sound themes, one subdirectory per theme
+func themeDirs() []string {
+	var dirs []string
+	for _, path := range assetPaths() {
+		dirs = append(dirs, filepath.Join(path, "themes"))
+	}
+	return dirs
+}
+
+// SoundThemes returns the names of the installed sound themes
+func SoundThemes() []string {
+	seen := make(map[string]bool)
+	var themes []string
+	for _, dir := range themeDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				themes = append(themes, entry.Name())
+			}
+		}
+	}
+	sort.Strings(themes)
+	return themes
+}
+
+// FindSoundTheme returns the directory of a sound theme, given by name
+// (e.g. "soft" in ~/.local/share/hyprwhspr/themes/soft) or as an absolute path
+func FindSoundTheme(theme string) (string, error) {
+	if filepath.IsAbs(theme) {
+		if info, err := os.Stat(theme); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("sound theme directory %s does not exist", theme)
+		}
+		return theme, nil
+	}
+	for _, dir := range themeDirs() {
+		path := filepath.Join(dir, theme)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sound theme %q", theme)
+}
+
+// resolveSoundPaths finds the sound files. Explicit sound paths win over the
+// theme, sounds missing from the theme fall back to the defaults.
+func (p *Player) resolveSoundPaths() error {
+	possiblePaths := assetPaths()
 
 	var assetsDir string
 	for _, path := range possiblePaths {
@@ -116,6 +208,28 @@ func (p *Player) resolveSoundPaths() error {
 			possiblePaths[0], possiblePaths[1], possiblePaths[2], possiblePaths[3], possiblePaths[4])
 	}
 
+	// Sounds from the theme, empty if the theme doesn't have them
+	var themeDir string
+	if p.config.SoundTheme != "" {
+		dir, err := FindSoundTheme(p.config.SoundTheme)
+		if err != nil {
+			fmt.Printf("⚠️  %v, using the default sounds\n", err)
+		} else {
+			themeDir = dir
+			fmt.Printf("[audio] Sound theme: %s\n", dir)
+		}
+	}
+	themeSound := func(name string) string {
+		if themeDir == "" {
+			return ""
+		}
+		path := filepath.Join(themeDir, name+".ogg")
+		if _, err := os.Stat(path); err != nil {
+			return ""
+		}
+		return path
+	}
+
 	// Resolve start sound path
 	if p.config.StartSoundPath != nil && *p.config.StartSoundPath != "" {
 		// Try custom path
@@ -133,7 +247,10 @@ func (p *Player) resolveSoundPaths() error {
 		}
 	}
 
-	// Fallback to default start sound
+	// Fallback to the theme, then the default start sound
+	if p.startSoundPath == "" {
+		p.startSoundPath = themeSound("start")
+	}
 	if p.startSoundPath == "" {
 		p.startSoundPath = filepath.Join(assetsDir, "start.ogg")
 	}
@@ -155,11 +272,36 @@ func (p *Player) resolveSoundPaths() error {
 		}
 	}
 
-	// Fallback to default stop sound
+	// Fallback to the theme, then the default stop sound
+	if p.stopSoundPath == "" {
+		p.stopSoundPath = themeSound("stop")
+	}
 	if p.stopSoundPath == "" {
 		p.stopSoundPath = filepath.Join(assetsDir, "stop.ogg")
 	}
 
+	// Resolve optional warning sound path (falls back to a built-in tick)
+	if p.config.WarningSoundPath != nil && *p.config.WarningSoundPath != "" {
+		customPath := *p.config.WarningSoundPath
+		if !filepath.IsAbs(customPath) {
+			customPath = filepath.Join(assetsDir, customPath)
+		}
+		if _, err := os.Stat(customPath); err == nil {
+			p.warningSoundPath = customPath
+		} else {
+			fmt.Printf("⚠️  Warning sound not found, using built-in tick: %s\n", customPath)
+		}
+	}
+
+	if p.warningSoundPath == "" {
+		p.warningSoundPath = themeSound("warning")
+	}
+
+	// Result sounds only exist in themes
+	p.errorSoundPath = themeSound("error")
+	p.doneSoundPath = themeSound("done")
+	p.limitSoundPath = themeSound("limit")
+
 	// Verify files exist
 	if _, err := os.Stat(p.startSoundPath); err != nil {
 		return fmt.Errorf("start sound not found: %s", p.startSoundPath)
@@ -171,6 +313,12 @@ func (p *Player) resolveSoundPaths() error {
 	fmt.Printf("🔊 Audio feedback enabled:\n")
 	fmt.Printf("   Start: %s (volume: %.0f%%)\n", p.startSoundPath, p.config.StartSoundVolume*100)
 	fmt.Printf("   Stop: %s (volume: %.0f%%)\n", p.stopSoundPath, p.config.StopSoundVolume*100)
+	if p.doneSoundPath != "" {
+		fmt.Printf("   Done: %s\n", p.doneSoundPath)
+	}
+	if p.errorSoundPath != "" {
+		fmt.Printf("   Error: %s\n", p.errorSoundPath)
+	}
 
 	return nil
 }
@@ -191,6 +339,138 @@ func (p *Player) PlayStop() {
 	go p.playSound(p.stopSoundPath, p.config.StopSoundVolume)
 }
 
+// PlayDone plays the theme's sound for a successful transcription, if it has one
+func (p *Player) PlayDone() {
+	if !p.enabled || p.doneSoundPath == "" {
+		return
+	}
+	go p.playSound(p.doneSoundPath, p.config.StopSoundVolume)
+}
+
+// PlayError plays the theme's sound for a failed transcription, if it has one
+func (p *Player) PlayError() {
+	if !p.enabled || p.errorSoundPath == "" {
+		return
+	}
+	go p.playSound(p.errorSoundPath, p.config.StopSoundVolume)
+}
+
+// PlayFile plays an .ogg file, e.g. the confirmation sound of a voice command
+func (p *Player) PlayFile(path string) {
+	if !p.enabled || path == "" {
+		return
+	}
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[2:])
+		}
+	}
+	go p.playSound(path, p.config.StopSoundVolume)
+}
+
+// PlayWarning plays the recording duration warning. The level escalates the
+// warning: level 1 is a single subtle tick, each further level adds a tick.
+func (p *Player) PlayWarning(level int) {
+	if !p.enabled {
+		return
+	}
+	if level < 1 {
+		level = 1
+	}
+	go func() {
+		for i := 0; i < level; i++ {
+			if p.warningSoundPath != "" {
+				p.playSound(p.warningSoundPath, p.config.WarningSoundVolume)
+			} else {
+				p.playTone(880, p.config.WarningSoundVolume)
+			}
+			time.Sleep(150 * time.Millisecond)
+		}
+	}()
+}
+
+// PlayLimit plays the sound for a recording stopped at its maximum length,
+// by default two falling tones that are easy to tell apart from the warnings
+func (p *Player) PlayLimit() {
+	if !p.enabled {
+		return
+	}
+	if p.limitSoundPath != "" {
+		go p.playSound(p.limitSoundPath, p.config.WarningSoundVolume)
+		return
+	}
+	go func() {
+		p.playTone(660, p.config.WarningSoundVolume)
+		time.Sleep(60 * time.Millisecond)
+		p.playTone(440, p.config.WarningSoundVolume)
+	}()
+}
+
+// playTone plays a short built-in sine tick at the given frequency
+func (p *Player) playTone(freq int, volume float64) {
+	release, ok := p.acquireSpeaker()
+	if !ok {
+		return
+	}
+	defer release()
+
+	tone, err := generators.SineTone(speakerRate, float64(freq))
+	if err != nil {
+		fmt.Printf("⚠️  Failed to generate warning tick: %v\n", err)
+		return
+	}
+
+	// 80ms tick with a linear fade out to avoid clicks
+	length := speakerRate.N(80 * time.Millisecond)
+	played := 0
+	tick := beep.StreamerFunc(func(samples [][2]float64) (n int, ok bool) {
+		if played >= length {
+			return 0, false
+		}
+		if len(samples) > length-played {
+			samples = samples[:length-played]
+		}
+		n, _ = tone.Stream(samples)
+		for i := range samples[:n] {
+			gain := volume * (1 - float64(played+i)/float64(length))
+			samples[i][0] *= gain
+			samples[i][1] *= gain
+		}
+		played += n
+		return n, true
+	})
+
+	done := make(chan bool)
+	speaker.Play(beep.Seq(tick, beep.Callback(func() {
+		done <- true
+	})))
+
+	<-done
+}
+
+// acquireSpeaker initializes the speaker on first use and keeps it from being
+// suspended until release is called
+func (p *Player) acquireSpeaker() (release func(), ok bool) {
+	for {
+		speakerMu.RLock()
+		if speakerInitialized {
+			return speakerMu.RUnlock, true
+		}
+		speakerMu.RUnlock()
+
+		speakerMu.Lock()
+		if !speakerInitialized {
+			if err := speaker.Init(speakerRate, speakerRate.N(time.Second/10)); err != nil {
+				speakerMu.Unlock()
+				fmt.Printf("⚠️  Failed to initialize audio speaker: %v\n", err)
+				return nil, false
+			}
+			speakerInitialized = true
+		}
+		speakerMu.Unlock()
+	}
+}
+
 func (p *Player) playSound(path string, volume float64) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -207,14 +487,11 @@ func (p *Player) playSound(path string, volume float64) {
 	defer streamer.Close()
 
 	// Initialize speaker if not already done
-	if !speakerInitialized {
-		err := speaker.Init(format.SampleRate, format.SampleRate.N(format.SampleRate.D(1)/10))
-		if err != nil {
-			fmt.Printf("⚠️  Failed to initialize audio speaker: %v\n", err)
-			return
-		}
-		speakerInitialized = true
+	release, ok := p.acquireSpeaker()
+	if !ok {
+		return
 	}
+	defer release()
 
 	// Apply volume control by directly multiplying samples
 	// Simple and transparent: 0.4 means 40% amplitude
@@ -223,14 +500,29 @@ func (p *Player) playSound(path string, volume float64) {
 		volume:   volume,
 	}
 
+	// Themes don't share a sample rate, the speaker runs at a fixed one
+	resampled := beep.Resample(4, format.SampleRate, speakerRate, volumeCtrl)
+
 	done := make(chan bool)
-	speaker.Play(beep.Seq(volumeCtrl, beep.Callback(func() {
+	speaker.Play(beep.Seq(resampled, beep.Callback(func() {
 		done <- true
 	})))
 
 	<-done
 }
 
+// Suspend closes the speaker, which otherwise keeps the sound card busy playing
+// silence. The next sound initializes it again.
+func (p *Player) Suspend() {
+	speakerMu.Lock()
+	defer speakerMu.Unlock()
+	if !speakerInitialized {
+		return
+	}
+	speaker.Close()
+	speakerInitialized = false
+}
+
 // Close closes the player (currently no cleanup needed)
 func (p *Player) Close() {
 	// Future cleanup if needed
@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoVoice is returned by the VAD stage when a recording contains no speech
+var ErrNoVoice = errors.New("no voice detected")
+
+// Processor is a stage of the capture pre-processing chain. Stages run in the
+// configured order on a finished recording before it is transcribed.
+type Processor interface {
+	Name() string
+	// Process returns the processed recording. loopback is the system audio captured
+	// alongside it (nil without echo cancellation) and must not be modified.
+	Process(samples, loopback []float32) ([]float32, error)
+}
+
+// Chain runs processors in order
+type Chain []Processor
+
+// Process runs every stage on the recording, stopping at the first error
+func (c Chain) Process(samples, loopback []float32) ([]float32, error) {
+	for _, p := range c {
+		var err error
+		if samples, err = p.Process(samples, loopback); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	return samples, nil
+}
+
+// Names returns the names of the stages in order
+func (c Chain) Names() []string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// HighPass removes rumble below the cutoff frequency (desk bumps, fans, traffic)
+// with a second-order Butterworth filter
+type HighPass struct {
+	b0, b1, b2, a1, a2 float64
+}
+
+// NewHighPass creates a high-pass filter
+func NewHighPass(cutoffHz float64, sampleRate int) *HighPass {
+	w0 := 2 * math.Pi * cutoffHz / float64(sampleRate)
+	alpha := math.Sin(w0) / math.Sqrt2 // Q = 1/sqrt(2)
+	cos := math.Cos(w0)
+	a0 := 1 + alpha
+	return &HighPass{
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+func (h *HighPass) Name() string { return "highpass" }
+
+func (h *HighPass) Process(samples, loopback []float32) ([]float32, error) {
+	out := make([]float32, len(samples))
+	var x1, x2, y1, y2 float64
+	for i, s := range samples {
+		x := float64(s)
+		y := h.b0*x + h.b1*x1 + h.b2*x2 - h.a1*y1 - h.a2*y2
+		x2, x1 = x1, x
+		y2, y1 = y1, y
+		out[i] = float32(y)
+	}
+	return out, nil
+}
+
+// AGC raises quiet recordings to a consistent level, so a distant or quiet
+// microphone is transcribed as well as a close one
+type AGC struct {
+	targetPeak float64
+	maxGain    float64
+}
+
+// NewAGC creates an automatic gain control stage that amplifies the recording
+// until its peak reaches targetPeak, by at most maxGain
+func NewAGC(targetPeak, maxGain float64) *AGC {
+	return &AGC{targetPeak: targetPeak, maxGain: maxGain}
+}
+
+func (a *AGC) Name() string { return "agc" }
+
+func (a *AGC) Process(samples, loopback []float32) ([]float32, error) {
+	var peak float64
+	for _, s := range samples {
+		if v := math.Abs(float64(s)); v > peak {
+			peak = v
+		}
+	}
+	if peak == 0 {
+		return samples, nil
+	}
+
+	gain := a.targetPeak / peak
+	if gain > a.maxGain {
+		gain = a.maxGain
+	}
+	if gain <= 1 {
+		return samples, nil // Never attenuate
+	}
+
+	out := make([]float32, len(samples))
+	for i, s := range samples {
+		out[i] = float32(float64(s) * gain)
+	}
+	fmt.Printf("📈 AGC: gain %.1fx\n", gain)
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package audio
+
+import "math"
+
+// resampleZeroCrossings is the number of sinc zero crossings on each side of
+// an output sample. More is sharper but slower, 16 is plenty for speech.
+const resampleZeroCrossings = 16
+
+// kernelSteps is the resolution of the precomputed kernel, in entries per input sample
+const kernelSteps = 256
+
+// Resample converts mono audio between sample rates with windowed sinc
+// interpolation. When downsampling (e.g. 48 kHz to whisper's 16 kHz) the sinc
+// is widened to low-pass the signal below the new Nyquist frequency first, so
+// high frequencies don't alias into the speech band.
+func Resample(samples []float32, from, to int) []float32 {
+	if from == to || from <= 0 || to <= 0 || len(samples) == 0 {
+		return samples
+	}
+
+	ratio := float64(to) / float64(from)
+	cutoff := 0.95 // Fraction of the lower Nyquist frequency that is kept
+	if ratio < 1 {
+		cutoff *= ratio
+	}
+	halfWidth := float64(resampleZeroCrossings) / cutoff // In input samples
+
+	// Computing sin/cos for every tap is slow, interpolate from a table instead
+	table := make([]float64, int(halfWidth*kernelSteps)+2)
+	for k := range table {
+		x := float64(k) / kernelSteps
+		table[k] = cutoff * sinc(cutoff*x) * blackman(x/halfWidth)
+	}
+	kernel := func(x float64) float64 {
+		pos := math.Abs(x) * kernelSteps
+		k := int(pos)
+		if k+1 >= len(table) {
+			return 0
+		}
+		return table[k] + (table[k+1]-table[k])*(pos-float64(k))
+	}
+
+	out := make([]float32, int(float64(len(samples))*ratio))
+	for i := range out {
+		center := float64(i) / ratio
+		first := int(math.Ceil(center - halfWidth))
+		last := int(math.Floor(center + halfWidth))
+		if first < 0 {
+			first = 0
+		}
+		if last >= len(samples) {
+			last = len(samples) - 1
+		}
+
+		var sum, weights float64
+		for j := first; j <= last; j++ {
+			w := kernel(float64(j) - center)
+			sum += float64(samples[j]) * w
+			weights += w
+		}
+		// Normalizing keeps the gain at 1, also near the edges where taps are missing
+		if weights != 0 {
+			out[i] = float32(sum / weights)
+		}
+	}
+	return out
+}
+
+// sinc is the normalized sinc function sin(πx)/(πx)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is the Blackman window for x in [-1, 1]
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
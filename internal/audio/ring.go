@@ -0,0 +1,78 @@
+package audio
+
+// ringBuffer holds the samples of a recording. Once it reaches its limit the
+// oldest samples are overwritten, so a recording left running can't grow
+// memory without bound. Memory is only allocated as audio arrives.
+type ringBuffer struct {
+	data    []float32
+	limit   int // maximum number of samples, 0 = unlimited
+	start   int // index of the oldest sample once the buffer is full
+	dropped int // samples overwritten so far
+}
+
+// newRingBuffer creates a buffer for at most limit samples (0 = unlimited)
+func newRingBuffer(limit int) ringBuffer {
+	return ringBuffer{limit: limit}
+}
+
+// Write appends samples, overwriting the oldest ones when the buffer is full
+func (b *ringBuffer) Write(samples []float32) {
+	if b.limit <= 0 {
+		b.data = append(b.data, samples...)
+		return
+	}
+
+	// Only the newest samples of an oversized write fit
+	if len(samples) > b.limit {
+		b.dropped += len(samples) - b.limit
+		samples = samples[len(samples)-b.limit:]
+	}
+
+	// Fill up to the limit before wrapping around
+	if free := b.limit - len(b.data); free > 0 {
+		n := len(samples)
+		if n > free {
+			n = free
+		}
+		b.data = append(b.data, samples[:n]...)
+		samples = samples[n:]
+	}
+
+	for len(samples) > 0 {
+		n := copy(b.data[b.start:], samples)
+		b.dropped += n
+		b.start = (b.start + n) % b.limit
+		samples = samples[n:]
+	}
+}
+
+// Samples returns a copy of the buffered samples, oldest first
+func (b *ringBuffer) Samples() []float32 {
+	out := make([]float32, 0, len(b.data))
+	out = append(out, b.data[b.start:]...)
+	return append(out, b.data[:b.start]...)
+}
+
+// Last returns a copy of the newest n samples (fewer if the buffer holds less)
+func (b *ringBuffer) Last(n int) []float32 {
+	if n > len(b.data) {
+		n = len(b.data)
+	}
+	out := make([]float32, 0, n)
+	// The newest samples end right before start
+	if n > b.start {
+		out = append(out, b.data[len(b.data)-(n-b.start):]...)
+		n = b.start
+	}
+	return append(out, b.data[b.start-n:b.start]...)
+}
+
+// Len returns the number of buffered samples
+func (b *ringBuffer) Len() int {
+	return len(b.data)
+}
+
+// Dropped returns how many samples were overwritten
+func (b *ringBuffer) Dropped() int {
+	return b.dropped
+}
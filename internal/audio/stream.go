@@ -0,0 +1,48 @@
+package audio
+
+// #include <stdlib.h>
+import "C"
+import (
+	"unsafe"
+
+	"github.com/gen2brain/malgo"
+)
+
+// Names hyprwhspr's streams appear under in pavucontrol, helvum and PipeWire
+// session managers (PulseAudio backend, which includes pipewire-pulse)
+const (
+	applicationName    = "hyprwhspr"
+	captureStreamName  = "Dictation"
+	loopbackStreamName = "Echo cancellation reference"
+)
+
+// streamProperties are PulseAudio client properties the capture streams
+// carry, so they can be routed and volume-controlled like any other
+// application. A PULSE_PROP set by the user wins.
+const streamProperties = "application.id=hyprwhspr application.icon_name=audio-input-microphone media.role=production"
+
+// applicationNameC is applicationName in C memory, as miniaudio gets a pointer to it
+var applicationNameC = (*byte)(unsafe.Pointer(C.CString(applicationName)))
+
+// init hands streamProperties to libpulse, which reads PULSE_PROP when it
+// connects. It is set in the C environment only: Go keeps its own copy of the
+// environment, which programs started with os/exec (scripts, TTS, wl-copy)
+// get, so they don't inherit it.
+func init() {
+	name, value := C.CString("PULSE_PROP"), C.CString(streamProperties)
+	defer C.free(unsafe.Pointer(name))
+	defer C.free(unsafe.Pointer(value))
+	C.setenv(name, value, 0)
+}
+
+// initContext creates an audio context that identifies itself as hyprwhspr
+func initContext() (*malgo.AllocatedContext, error) {
+	config := malgo.ContextConfig{}
+	config.Pulse.PApplicationName = applicationNameC
+	return malgo.InitContext(nil, config, nil)
+}
+
+// initDevice creates a device whose stream carries streamProperties
+func initDevice(ctx *malgo.AllocatedContext, config malgo.DeviceConfig, callbacks malgo.DeviceCallbacks) (*malgo.Device, error) {
+	return malgo.InitDevice(ctx.Context, config, callbacks)
+}
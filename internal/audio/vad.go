@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"fmt"
 	"math"
 	"sync"
 )
@@ -25,6 +26,9 @@ func DefaultVADConfig() VADConfig {
 	}
 }
 
+// vadSampleRate is the sample rate voice segment times are based on
+const vadSampleRate = 16000.0
+
 // VADProcessor implements voice activity detection
 type VADProcessor struct {
 	config VADConfig
@@ -167,7 +171,7 @@ func (vad *VADProcessor) GetVoiceSegments(audio []float32) []VoiceSegment {
 	inVoice := false
 	segmentStart := 0
 
-	frameDurationMs := float64(vad.config.Overlap) / 16000.0 * 1000.0 // Assuming 16kHz
+	frameDurationMs := float64(vad.config.Overlap) / vadSampleRate * 1000.0
 
 	for i, isVoice := range voiceActivity {
 		if isVoice && !inVoice {
@@ -203,3 +207,90 @@ type VoiceSegment struct {
 	End      float64 // End time in milliseconds
 	Duration float64 // Duration in milliseconds
 }
+
+// VoiceDetector finds the speech in a recording
+type VoiceDetector interface {
+	GetVoiceSegments(audio []float32) []VoiceSegment
+}
+
+// VoiceDetectorFunc adapts a function to a VoiceDetector
+type VoiceDetectorFunc func(audio []float32) []VoiceSegment
+
+// GetVoiceSegments calls f
+func (f VoiceDetectorFunc) GetVoiceSegments(audio []float32) []VoiceSegment {
+	return f(audio)
+}
+
+// VoiceFilter is the "vad" stage of the pre-processing chain
+type VoiceFilter struct {
+	Detector VoiceDetector
+	Ratio    *float64        // If set, receives the share of the recording kept as speech
+	Segments *[]VoiceSegment // If set, receives the voice segments
+}
+
+func (v VoiceFilter) Name() string { return "vad" }
+
+// Process mutes everything outside the voice segments (with padding) and
+// returns ErrNoVoice if there are none. Muting instead of cutting preserves
+// timing and structure for Whisper.
+func (v VoiceFilter) Process(samples, loopback []float32) ([]float32, error) {
+	voiceSegments := v.Detector.GetVoiceSegments(samples)
+	if len(voiceSegments) == 0 {
+		fmt.Println("⚠️  VAD: No voice detected - skipping transcription (only background/output audio)")
+		if v.Ratio != nil {
+			*v.Ratio = 0
+		}
+		return nil, ErrNoVoice
+	}
+	fmt.Printf("✅ VAD: Detected %d voice segment(s)\n", len(voiceSegments))
+	if v.Segments != nil {
+		*v.Segments = voiceSegments
+	}
+
+	paddingMs := 200.0 // Add 200ms padding before/after each segment
+	paddingSamples := int(paddingMs * vadSampleRate / 1000.0)
+
+	// Create a mask: true = keep audio, false = mute
+	keepMask := make([]bool, len(samples))
+
+	// Mark voice segments (with padding) to keep
+	for i, seg := range voiceSegments {
+		startSample := int(seg.Start*vadSampleRate/1000.0) - paddingSamples
+		endSample := int(seg.End*vadSampleRate/1000.0) + paddingSamples
+
+		// Bounds check
+		if startSample < 0 {
+			startSample = 0
+		}
+		if endSample > len(samples) {
+			endSample = len(samples)
+		}
+
+		for j := startSample; j < endSample; j++ {
+			keepMask[j] = true
+		}
+
+		fmt.Printf("   Segment %d: %.1fms-%.1fms (%.1fms duration, keeping with %.0fms padding)\n",
+			i+1, seg.Start, seg.End, seg.Duration, paddingMs*2)
+	}
+
+	// Mute (zero out) all non-voice parts
+	mutedSamples := make([]float32, len(samples))
+	mutedCount := 0
+	for i, s := range samples {
+		if keepMask[i] {
+			mutedSamples[i] = s
+		} else {
+			mutedCount++
+		}
+	}
+
+	keptSamples := len(mutedSamples) - mutedCount
+	fmt.Printf("📊 VAD: Keeping %d samples, muted %d samples (%.1f%% voice)\n",
+		keptSamples, mutedCount, float64(keptSamples)/float64(len(mutedSamples))*100)
+	if v.Ratio != nil {
+		*v.Ratio = float64(keptSamples) / float64(len(mutedSamples))
+	}
+
+	return mutedSamples, nil
+}
@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// WriteWAV writes mono float32 samples as a 16-bit PCM WAV file
+func WriteWAV(path string, samples []float32, sampleRate int) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create wav file: %w", err)
+	}
+	defer f.Close()
+
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	dataSize := uint32(len(samples) * bitsPerSample / 8)
+	blockAlign := uint16(channels * bitsPerSample / 8)
+
+	w := bufio.NewWriter(f)
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16), // fmt chunk size
+		uint16(1),  // PCM
+		uint16(channels),
+		uint32(sampleRate),
+		uint32(sampleRate) * uint32(blockAlign), // byte rate
+		blockAlign,
+		uint16(bitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("failed to write wav header: %w", err)
+		}
+	}
+
+	buf := make([]byte, 2)
+	for _, s := range samples {
+		// Clamp to [-1, 1] before converting to 16-bit
+		v := math.Max(-1, math.Min(1, float64(s)))
+		binary.LittleEndian.PutUint16(buf, uint16(int16(v*math.MaxInt16)))
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write wav data: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write wav data: %w", err)
+	}
+	return nil
+}
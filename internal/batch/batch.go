@@ -0,0 +1,265 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pa/hyprwhspr/internal/audio"
+	"github.com/pa/hyprwhspr/internal/whisper"
+)
+
+// SummaryFile is the name of the report written to the output directory
+const SummaryFile = "hyprwhspr-summary.txt"
+
+// Options configures a batch run
+type Options struct {
+	Dir        string   // Directory with audio files
+	OutputDir  string   // Where .txt/.srt files are written (empty = next to the audio files)
+	Parallel   int      // Number of files transcribed at once (each loads its own model)
+	Formats    []string // Output formats: "txt", "srt"
+	Overwrite  bool     // Transcribe files again even if all outputs exist
+	SampleRate int
+
+	// NewTranscriber creates a transcriber for a worker
+	NewTranscriber func() (*whisper.Transcriber, error)
+	// PostProcess cleans up transcribed text (may be nil)
+	PostProcess func(text, language string) string
+}
+
+// FileResult is the outcome for a single file
+type FileResult struct {
+	Path     string
+	Audio    time.Duration
+	Wall     time.Duration
+	Words    int
+	Language string
+	Skipped  bool // Outputs already existed
+	Err      error
+}
+
+// Run transcribes every audio file in the directory
+func Run(opts Options) ([]FileResult, error) {
+	files, err := audioFiles(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no audio files found in %s", opts.Dir)
+	}
+	if opts.OutputDir != "" {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if opts.Parallel < 1 {
+		opts.Parallel = 1
+	}
+	if opts.Parallel > len(files) {
+		opts.Parallel = len(files)
+	}
+
+	results := make([]FileResult, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < opts.Parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var transcriber *whisper.Transcriber
+			var initErr error
+			defer func() {
+				if transcriber != nil {
+					transcriber.Close()
+				}
+			}()
+
+			for i := range jobs {
+				results[i].Path = files[i]
+				if !opts.Overwrite && outputsExist(files[i], opts) {
+					results[i].Skipped = true
+					fmt.Printf("⏭️  [%d/%d] %s (already transcribed)\n", i+1, len(files), filepath.Base(files[i]))
+					continue
+				}
+
+				// Load the model lazily so workers that only skip files stay cheap
+				if transcriber == nil && initErr == nil {
+					transcriber, initErr = opts.NewTranscriber()
+				}
+				if initErr != nil {
+					results[i].Err = initErr
+					continue
+				}
+
+				results[i] = transcribeFile(transcriber, files[i], opts)
+				if results[i].Err != nil {
+					fmt.Printf("❌ [%d/%d] %s: %v\n", i+1, len(files), filepath.Base(files[i]), results[i].Err)
+				} else {
+					fmt.Printf("✅ [%d/%d] %s (%.1fs audio in %v)\n", i+1, len(files), filepath.Base(files[i]),
+						results[i].Audio.Seconds(), results[i].Wall.Round(time.Millisecond))
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// audioFiles lists the decodable audio files of a directory in name order
+func audioFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if audio.IsAudioFile(path) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// outputPath returns the output file of an audio file for a format
+func outputPath(file, format string, opts Options) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) + "." + format
+	if opts.OutputDir != "" {
+		return filepath.Join(opts.OutputDir, base)
+	}
+	return filepath.Join(filepath.Dir(file), base)
+}
+
+// outputsExist returns whether every output of a file has already been written
+func outputsExist(file string, opts Options) bool {
+	for _, format := range opts.Formats {
+		if _, err := os.Stat(outputPath(file, format, opts)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// transcribeFile decodes, transcribes and writes the outputs of a single file
+func transcribeFile(transcriber *whisper.Transcriber, file string, opts Options) FileResult {
+	result := FileResult{Path: file}
+
+	samples, err := audio.DecodeFile(file, opts.SampleRate)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(samples) == 0 {
+		result.Err = fmt.Errorf("file contains no audio")
+		return result
+	}
+	result.Audio = time.Duration(len(samples)) * time.Second / time.Duration(opts.SampleRate)
+
+	start := time.Now()
+	transcription, err := transcriber.Transcribe(samples, whisper.Options{})
+	result.Wall = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Language = transcription.Language
+
+	process := func(text string) string {
+		text = strings.TrimSpace(text)
+		if opts.PostProcess != nil {
+			text = opts.PostProcess(text, transcription.Language)
+		}
+		return text
+	}
+
+	text := process(transcription.Text)
+	result.Words = len(strings.Fields(text))
+
+	for _, format := range opts.Formats {
+		var content string
+		switch format {
+		case "txt":
+			content = text + "\n"
+		case "srt":
+			content = FormatSRT(transcription.Segments, process)
+		default:
+			result.Err = fmt.Errorf("unknown output format %q", format)
+			return result
+		}
+		if err := os.WriteFile(outputPath(file, format, opts), []byte(content), 0644); err != nil {
+			result.Err = fmt.Errorf("failed to write %s: %w", format, err)
+			return result
+		}
+	}
+	return result
+}
+
+// FormatSRT renders segments as SubRip subtitles
+func FormatSRT(segments []whisper.Segment, process func(string) string) string {
+	var b strings.Builder
+	n := 0
+	for _, seg := range segments {
+		text := process(seg.Text)
+		if text == "" {
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", n, srtTime(seg.Start), srtTime(seg.End), text)
+	}
+	return b.String()
+}
+
+// srtTime formats a duration as HH:MM:SS,mmm
+func srtTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// Summary renders a report of a batch run
+func Summary(results []FileResult) string {
+	var b strings.Builder
+	var done, skipped, failed int
+	var totalAudio, totalWall time.Duration
+	var words int
+
+	fmt.Fprintf(&b, "hyprwhspr batch transcription - %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	for _, r := range results {
+		name := filepath.Base(r.Path)
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(&b, "FAILED   %s: %v\n", name, r.Err)
+		case r.Skipped:
+			skipped++
+			fmt.Fprintf(&b, "SKIPPED  %s\n", name)
+		default:
+			done++
+			totalAudio += r.Audio
+			totalWall += r.Wall
+			words += r.Words
+			fmt.Fprintf(&b, "OK       %s (%s, %.1fs audio, %.1fs, %d words)\n", name, r.Language, r.Audio.Seconds(), r.Wall.Seconds(), r.Words)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%d transcribed, %d skipped, %d failed\n", done, skipped, failed)
+	if totalAudio > 0 {
+		fmt.Fprintf(&b, "%.1f minutes of audio in %.1f minutes (%.2fx real time), %d words\n",
+			totalAudio.Minutes(), totalWall.Minutes(), totalWall.Seconds()/totalAudio.Seconds(), words)
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/pa/hyprwhspr/internal/hyprland"
+)
+
+// Keyboard presses keys and inserts text for the keys and type actions
+type Keyboard interface {
+	PressKeys(chord string) error
+	InsertText(text string) error
+}
+
+// action returns the name and value of a built-in action, empty for commands
+// running a script or command line
+func (c Command) action() (string, string) {
+	switch {
+	case c.Open != "":
+		return "open", c.Open
+	case c.Launch != "":
+		return "launch", c.Launch
+	case c.Keys != "":
+		return "keys", c.Keys
+	case c.Type != "":
+		return "type", c.Type
+	case c.Dispatch != "":
+		return "dispatch", c.Dispatch
+	}
+	return "", ""
+}
+
+// runAction runs a built-in action with the text after the command word
+// filling in the placeholders of its value
+func (e *Executor) runAction(name, value, text string) error {
+	values := placeholders(text)
+	switch name {
+	case "open":
+		// The text becomes part of a URL, e.g. a search query
+		escaped := make(map[string]string, len(values))
+		for k, v := range values {
+			escaped[k] = url.QueryEscape(v)
+		}
+		if !strings.Contains(value, "://") {
+			escaped = values // A file, not a URL
+		}
+		return start([]string{"xdg-open", expandPlaceholders(value, escaped)})
+
+	case "launch":
+		words, err := splitCommandLine(value)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return fmt.Errorf("nothing to launch")
+		}
+		for i, word := range words {
+			words[i] = expandPlaceholders(word, values)
+		}
+		return start(words)
+
+	case "keys":
+		if e.keys == nil {
+			return fmt.Errorf("no keyboard to press %s", value)
+		}
+		return e.keys.PressKeys(value)
+
+	case "type":
+		if e.keys == nil {
+			return fmt.Errorf("no keyboard to type with")
+		}
+		return e.keys.InsertText(expandPlaceholders(value, values))
+
+	case "dispatch":
+		return hyprland.Dispatch(expandPlaceholders(value, values))
+	}
+	return fmt.Errorf("unknown action %q", name)
+}
+
+// start starts a program in the background without waiting for it, in its own
+// session so it keeps running when hyprwhspr stops
+func start(args []string) error {
+	if strings.HasPrefix(args[0], "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			args[0] = home + args[0][1:]
+		}
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditEntry records a single executed voice command
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	Trigger     string    `json:"trigger"`
+	Script      string    `json:"script"`
+	Arguments   string    `json:"arguments"`
+	WindowClass string    `json:"window_class,omitempty"`
+	ExitCode    int       `json:"exit_code"` // -1 if the script could not be started
+	DurationMs  int64     `json:"duration_ms"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AuditLog is an append-only JSONL log of executed commands
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAuditLog creates an audit log writing to path
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Path returns the log file path
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Append writes an entry to the end of the log. The file is only ever opened
+// in append mode so existing entries are never rewritten.
+func (l *AuditLog) Append(entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAuditLog returns the last limit entries of the log (all if limit <= 0)
+func ReadAuditLog(path string, limit int) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip corrupt lines (e.g. a partial write)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
@@ -4,59 +4,292 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/pa/hyprwhspr/internal/hyprland"
 )
 
 // Executor handles command mode execution
 type Executor struct {
-	enabled  bool
-	commands map[string]string
+	enabled     bool
+	commands    map[string]Command
+	appCommands map[string]map[string]Command // window class -> command_word -> command
+	audit       *AuditLog                     // nil = no audit log
+	redact      bool                          // keep arguments and script output out of logs
+	fuzzy       float64                       // similarity a misheard command word needs (0 = exact matches only)
+	keys        Keyboard                      // presses keys for the keys and type actions, nil = unavailable
+	recognized  func(*Execution)              // called before a recognized command runs, nil = nothing
+}
+
+// Execution describes a command that was run
+type Execution struct {
+	Trigger   string // Command word as configured
+	Arguments string // Rest of the transcription
+	ExitCode  int    // -1 if it could not be started or didn't run yet
 }
 
 // NewExecutor creates a new command executor
-func NewExecutor(enabled bool, commands map[string]string) *Executor {
+// appCommands holds commands that only apply while a window of the given class is focused
+func NewExecutor(enabled bool, commands map[string]Command, appCommands map[string]map[string]Command) *Executor {
 	return &Executor{
-		enabled:  enabled,
-		commands: commands,
+		enabled:     enabled,
+		commands:    commands,
+		appCommands: appCommands,
 	}
 }
 
+// SetAuditLog records every executed command in the given log (nil disables auditing)
+func (e *Executor) SetAuditLog(audit *AuditLog) {
+	e.audit = audit
+}
+
+// SetRedact hides command arguments and script output in the log and audit log
+func (e *Executor) SetRedact(redact bool) {
+	e.redact = redact
+}
+
+// SetFuzzyThreshold lets first words that are similar to a command word run the
+// command, e.g. "noted" for "note" at 0.8 (0 = exact matches only)
+func (e *Executor) SetFuzzyThreshold(threshold float64) {
+	e.fuzzy = threshold
+}
+
+// SetKeyboard sets the keyboard of the keys and type actions
+func (e *Executor) SetKeyboard(keys Keyboard) {
+	e.keys = keys
+}
+
+// SetRecognizedHandler sets a function called when a command was recognized,
+// right before it runs, e.g. to confirm it with a sound
+func (e *Executor) SetRecognizedHandler(handler func(*Execution)) {
+	e.recognized = handler
+}
+
+// redacted returns text for logging, or only its size if redaction is on
+func (e *Executor) redacted(text string) string {
+	if e.redact {
+		return fmt.Sprintf("[redacted, %d words]", len(strings.Fields(text)))
+	}
+	return text
+}
+
+// lookup resolves a command word, preferring commands scoped to the focused window's class
+func (e *Executor) lookup(word string, window *hyprland.Window) (Command, bool) {
+	for _, commands := range e.windowCommands(window) {
+		if cmd, exists := commands[word]; exists {
+			return cmd, true
+		}
+	}
+
+	cmd, exists := e.commands[word]
+	return cmd, exists
+}
+
+// phrases returns the trigger phrases available while the window is focused
+// (may be nil), the command words and their aliases, with the command word
+// each runs. App commands win over global ones.
+func (e *Executor) phrases(window *hyprland.Window) map[string]string {
+	phrases := make(map[string]string)
+	add := func(commands map[string]Command) {
+		for word, cmd := range commands {
+			phrases[normalizePhrase(word)] = word
+			for _, alias := range cmd.Aliases {
+				phrases[normalizePhrase(alias)] = word
+			}
+		}
+	}
+	add(e.commands)
+	apps := e.windowCommands(window)
+	for i := len(apps) - 1; i >= 0; i-- {
+		add(apps[i])
+	}
+	return phrases
+}
+
+// windowCommands returns the app commands of the entries matching the window,
+// most specific first: the window's class before its initial class, an exact
+// match before one differing in case. Ties are ordered by the entry's key, so
+// a word defined by several entries always runs the same command.
+func (e *Executor) windowCommands(window *hyprland.Window) []map[string]Command {
+	if window == nil {
+		return nil
+	}
+	rank := func(class string) int {
+		switch {
+		case class == window.Class:
+			return 0
+		case strings.EqualFold(class, window.Class):
+			return 1
+		case class == window.InitialClass:
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	var classes []string
+	for class := range e.appCommands {
+		if window.MatchesClass(class) {
+			classes = append(classes, class)
+		}
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if ri, rj := rank(classes[i]), rank(classes[j]); ri != rj {
+			return ri < rj
+		}
+		return classes[i] < classes[j]
+	})
+
+	commands := make([]map[string]Command, len(classes))
+	for i, class := range classes {
+		commands[i] = e.appCommands[class]
+	}
+	return commands
+}
+
+// normalizePhrase lowercases a phrase and strips the punctuation whisper puts
+// after words, like "Note," or "Take a note."
+func normalizePhrase(phrase string) string {
+	words := strings.Fields(strings.ToLower(phrase))
+	for i, word := range words {
+		words[i] = strings.TrimRight(word, ".,!?;:")
+	}
+	return strings.Join(words, " ")
+}
+
+// match returns the command word the beginning of the transcribed words stands
+// for and how many words the trigger phrase took. The longest trigger phrase
+// wins; with fuzzy matching a first word that matches no phrase runs the most
+// similar single-word trigger above the threshold. Two equally similar words
+// are ambiguous and match neither.
+func (e *Executor) match(words []string, window *hyprland.Window) (string, int, bool) {
+	heard := strings.Fields(normalizePhrase(strings.Join(words, " ")))
+	if len(heard) == 0 {
+		return "", 0, false
+	}
+
+	phrases := e.phrases(window)
+	trigger, length := "", 0
+	for phrase, word := range phrases {
+		parts := strings.Fields(phrase)
+		if len(parts) <= length || len(parts) > len(heard) {
+			continue
+		}
+		if strings.Join(heard[:len(parts)], " ") == phrase {
+			trigger, length = word, len(parts)
+		}
+	}
+	if length > 0 {
+		return trigger, length, true
+	}
+	if e.fuzzy <= 0 {
+		return "", 0, false
+	}
+
+	best, bestScore, ambiguous := "", 0.0, false
+	for phrase, word := range phrases {
+		if strings.Contains(phrase, " ") {
+			continue
+		}
+		score := similarity(heard[0], phrase)
+		switch {
+		case score > bestScore:
+			best, bestScore, ambiguous = word, score, false
+		case score == bestScore && word != best:
+			ambiguous = true
+		}
+	}
+	if bestScore < e.fuzzy {
+		return "", 0, false
+	}
+	if ambiguous {
+		fmt.Printf("⚠️  '%s' is as close to '%s' as to another command, not running either\n", heard[0], best)
+		return "", 0, false
+	}
+	return best, 1, true
+}
+
 // Execute processes the transcribed text and either executes a command or returns false
-// Returns (wasCommand, error)
-func (e *Executor) Execute(text string) (bool, error) {
+// window is the focused window at the end of recording (may be nil) and is passed to scripts as env vars
+// Returns the executed command, or nil if the text isn't a command
+func (e *Executor) Execute(text string, window *hyprland.Window) (*Execution, error) {
 	if !e.enabled || text == "" {
-		return false, nil
+		return nil, nil
 	}
 
 	// Split text into words
 	words := strings.Fields(text)
 	if len(words) == 0 {
-		return false, nil
+		return nil, nil
 	}
 
-	// Check if first word is a command
-	// Strip trailing punctuation from the first word to handle cases like "Note," or "Note."
-	firstWord := strings.ToLower(strings.TrimRight(words[0], ".,!?;:"))
-	scriptPath, exists := e.commands[firstWord]
-	if !exists {
-		return false, nil
+	// Check if the text starts with a command word or alias
+	trigger, length, ok := e.match(words, window)
+	if !ok {
+		return nil, nil
 	}
+	cmd, _ := e.lookup(trigger, window)
 
 	// It's a command! Extract remaining text
-	remainingText := ""
-	if len(words) > 1 {
-		remainingText = strings.Join(words[1:], " ")
+	remainingText := strings.Join(words[length:], " ")
+
+	if heard := normalizePhrase(strings.Join(words[:length], " ")); heard != normalizePhrase(trigger) {
+		fmt.Printf("🎯 Command mode: '%s' (heard '%s') -> %s\n", trigger, heard, cmd)
+	} else {
+		fmt.Printf("🎯 Command mode: '%s' -> %s\n", trigger, cmd)
 	}
+	fmt.Printf("   Arguments: '%s'\n", e.redacted(remainingText))
 
-	fmt.Printf("🎯 Command mode: '%s' -> %s\n", firstWord, scriptPath)
-	fmt.Printf("   Arguments: '%s'\n", remainingText)
+	execution := &Execution{Trigger: trigger, Arguments: remainingText, ExitCode: -1}
+	if e.recognized != nil {
+		e.recognized(execution)
+	}
 
 	// Execute the script
-	return true, e.executeScript(scriptPath, remainingText)
+	start := time.Now()
+	exitCode, err := e.run(cmd, remainingText, window)
+	execution.ExitCode = exitCode
+	if e.audit != nil {
+		entry := AuditEntry{
+			Time:       start,
+			Trigger:    trigger,
+			Script:     cmd.String(),
+			Arguments:  e.redacted(remainingText),
+			ExitCode:   exitCode,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if window != nil {
+			entry.WindowClass = window.Class
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		if auditErr := e.audit.Append(entry); auditErr != nil {
+			fmt.Printf("⚠️  Failed to write command audit log: %v\n", auditErr)
+		}
+	}
+	return execution, err
 }
 
-// executeScript runs the script with the provided text as arguments
-func (e *Executor) executeScript(scriptPath, text string) error {
+// run runs a command with the text after the command word. The text is also
+// in $HYPRWHSPR_ARGS, and on stdin if the command asks for it.
+// Returns the script's exit code (-1 if it could not be started)
+func (e *Executor) run(command Command, text string, window *hyprland.Window) (int, error) {
+	if name, value := command.action(); name != "" {
+		if err := e.runAction(name, value, text); err != nil {
+			return -1, err
+		}
+		return 0, nil
+	}
+
+	args, err := command.argv(text)
+	if err != nil {
+		return -1, err
+	}
+	scriptPath := args[0]
+
 	// Expand home directory if needed
 	if strings.HasPrefix(scriptPath, "~/") {
 		homeDir, err := os.UserHomeDir()
@@ -65,36 +298,45 @@ func (e *Executor) executeScript(scriptPath, text string) error {
 		}
 	}
 
-	// Check if script exists
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return fmt.Errorf("script not found: %s", scriptPath)
-	}
+	// Programs of command lines may come from $PATH
+	if command.Plain || strings.ContainsRune(scriptPath, '/') {
+		// Check if script exists
+		if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
+			return -1, fmt.Errorf("script not found: %s", scriptPath)
+		}
 
-	// Check if script is executable
-	info, err := os.Stat(scriptPath)
-	if err != nil {
-		return fmt.Errorf("cannot stat script: %w", err)
-	}
+		// Check if script is executable
+		info, err := os.Stat(scriptPath)
+		if err != nil {
+			return -1, fmt.Errorf("cannot stat script: %w", err)
+		}
 
-	if info.Mode()&0111 == 0 {
-		return fmt.Errorf("script is not executable: %s", scriptPath)
+		if info.Mode()&0111 == 0 {
+			return -1, fmt.Errorf("script is not executable: %s", scriptPath)
+		}
 	}
 
-	// Execute the script with text as argument
-	cmd := exec.Command(scriptPath, text)
-	cmd.Env = os.Environ()
+	cmd := exec.Command(scriptPath, args[1:]...)
+	cmd.Env = append(os.Environ(), "HYPRWHSPR_ARGS="+text)
+	cmd.Env = append(cmd.Env, window.Env()...)
+	if command.Stdin {
+		cmd.Stdin = strings.NewReader(text)
+	}
 
 	// Capture output
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("script execution failed: %w\nOutput: %s", err, string(output))
+		if cmd.ProcessState == nil {
+			return -1, fmt.Errorf("failed to start %s: %w", scriptPath, err)
+		}
+		return cmd.ProcessState.ExitCode(), fmt.Errorf("script execution failed: %w\nOutput: %s", err, e.redacted(string(output)))
 	}
 
 	if len(output) > 0 {
-		fmt.Printf("📋 Script output: %s\n", string(output))
+		fmt.Printf("📋 Script output: %s\n", e.redacted(string(output)))
 	}
 
-	return nil
+	return 0, nil
 }
 
 // IsEnabled returns whether command mode is enabled
@@ -103,23 +345,38 @@ func (e *Executor) IsEnabled() bool {
 }
 
 // GetCommands returns the command map
-func (e *Executor) GetCommands() map[string]string {
+func (e *Executor) GetCommands() map[string]Command {
 	return e.commands
 }
 
+// Triggers returns the trigger phrases (command words and aliases) available
+// while the window is focused (may be nil)
+func (e *Executor) Triggers(window *hyprland.Window) []string {
+	var phrases []string
+	for phrase := range e.phrases(window) {
+		phrases = append(phrases, phrase)
+	}
+	return phrases
+}
+
 // GetStatus returns a status string for debugging
 func (e *Executor) GetStatus() string {
 	if !e.enabled {
 		return "Command mode: disabled"
 	}
 
-	if len(e.commands) == 0 {
+	if len(e.commands) == 0 && len(e.appCommands) == 0 {
 		return "Command mode: enabled (no commands configured)"
 	}
 
 	status := fmt.Sprintf("Command mode: enabled (%d commands)\n", len(e.commands))
-	for cmd, script := range e.commands {
-		status += fmt.Sprintf("  '%s' -> %s\n", cmd, script)
+	for word, cmd := range e.commands {
+		status += fmt.Sprintf("  '%s' -> %s\n", word, cmd)
+	}
+	for class, commands := range e.appCommands {
+		for word, cmd := range commands {
+			status += fmt.Sprintf("  '%s' -> %s (only in %s)\n", word, cmd, class)
+		}
 	}
 
 	return status
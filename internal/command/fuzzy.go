@@ -0,0 +1,96 @@
+package command
+
+import "strings"
+
+// similarity rates how alike a transcribed word and a command word are, from 0
+// (nothing in common) to 1 (equal). It is the edit distance relative to the
+// longer word, moved halfway towards 1 if both sound alike, so mishearings like
+// "node" or "noted" for "note" score high while "need" stays low.
+func similarity(word, trigger string) float64 {
+	if word == trigger {
+		return 1
+	}
+	a, b := []rune(word), []rune(trigger)
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	score := 1 - float64(levenshtein(a, b))/float64(longest)
+	if soundex(word) != "" && soundex(word) == soundex(trigger) {
+		score = (score + 1) / 2
+	}
+	return score
+}
+
+// levenshtein returns the number of single character edits between a and b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// soundexCodes are the Soundex digits of consonants, vowels and h, w, y have none
+var soundexCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// soundex returns the American Soundex code of a word ("note" -> "N300"),
+// empty if it doesn't start with a letter a-z
+func soundex(word string) string {
+	word = strings.ToLower(word)
+	if word == "" || word[0] < 'a' || word[0] > 'z' {
+		return ""
+	}
+
+	code := []byte{word[0] - 'a' + 'A'}
+	last := soundexCodes[rune(word[0])]
+	for _, r := range word[1:] {
+		digit, ok := soundexCodes[r]
+		switch {
+		case ok && digit != last:
+			code = append(code, digit)
+			last = digit
+		case r == 'h' || r == 'w':
+			// Letters with the same code around h and w count once
+		default:
+			last = digit
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
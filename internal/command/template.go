@@ -0,0 +1,161 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Command is what a command word runs: a script, a command line or one of the
+// built-in actions
+type Command struct {
+	Exec  string // Script path, or a command line with placeholders if Plain is false
+	Stdin bool   // Also pass the arguments on stdin
+	Plain bool   // Exec is a script getting the arguments as its only argument
+
+	Aliases []string // More words or phrases triggering the command, e.g. "take a note"
+
+	// Built-in actions, their values can contain placeholders too
+	Open     string // URL or file opened with xdg-open
+	Launch   string // Command line started in the background
+	Keys     string // Key chord pressed, e.g. "ctrl+s"
+	Type     string // Text inserted into the focused window
+	Dispatch string // Hyprland dispatcher with arguments, e.g. "workspace {1}"
+}
+
+// String returns the command as configured
+func (c Command) String() string {
+	if name, value := c.action(); name != "" {
+		return name + " " + value
+	}
+	if c.Stdin {
+		return c.Exec + " (stdin)"
+	}
+	return c.Exec
+}
+
+// argv returns the program and arguments running the command for the text
+// after the command word. Placeholders are filled in after the command line is
+// split into words, so the text never turns into extra arguments or options.
+func (c Command) argv(text string) ([]string, error) {
+	if c.Plain {
+		return []string{c.Exec, text}, nil
+	}
+	words, err := splitCommandLine(c.Exec)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command line")
+	}
+	values := placeholders(text)
+	for i, word := range words {
+		words[i] = expandPlaceholders(word, values)
+	}
+	return words, nil
+}
+
+// placeholders returns the values of the placeholders of a command line:
+// {args} (the whole text), {first_word}, {first_sentence} (without its final
+// punctuation), {rest} (the text after the first sentence) and {1}, {2}, ...
+// (single words, trailing punctuation removed)
+func placeholders(text string) map[string]string {
+	values := map[string]string{"args": text, "first_word": "", "first_sentence": text, "rest": ""}
+	for i, word := range strings.Fields(text) {
+		word = strings.TrimRight(word, ".,!?;:")
+		values[strconv.Itoa(i+1)] = word
+		if i == 0 {
+			values["first_word"] = word
+		}
+	}
+
+	// The first sentence ends at ., ! or ? followed by a space or the end
+	for i, r := range text {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		after := text[i+1:]
+		if after == "" || unicode.IsSpace(rune(after[0])) {
+			values["first_sentence"] = strings.TrimSpace(text[:i])
+			values["rest"] = strings.TrimSpace(after)
+			break
+		}
+	}
+	return values
+}
+
+// expandPlaceholders replaces the known {placeholders} of a word, unknown ones
+// stay as they are. Numbered placeholders past the last word are empty.
+func expandPlaceholders(word string, values map[string]string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(word, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(word[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := word[start+1 : start+end]
+		value, known := values[name]
+		if _, err := strconv.Atoi(name); err == nil {
+			known = true
+		}
+		b.WriteString(word[:start])
+		if known {
+			b.WriteString(value)
+		} else {
+			b.WriteString(word[start : start+end+1])
+		}
+		word = word[start+end+1:]
+	}
+	b.WriteString(word)
+	return b.String()
+}
+
+// splitCommandLine splits a command line into words like a shell: words are
+// separated by spaces, '...' and "..." quote and \ escapes the next character
+func splitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,159 @@
+package compose
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Action is what a dictation did to the buffer
+type Action int
+
+const (
+	Appended Action = iota // The dictation was added to the buffer
+	Removed                // "scratch that" removed the last dictation
+	Replaced               // "replace X with Y" changed the buffer
+	Cleared                // "start over" emptied the buffer
+	Sent                   // "send it" released the buffer for injection
+	NoMatch                // An edit whose target was not found, the buffer is unchanged
+)
+
+// Edit phrases, matched case-insensitively against the whole dictation
+var (
+	scratchPhrases = []string{"scratch that", "delete that", "undo that", "strike that"}
+	clearPhrases   = []string{"start over", "clear all", "clear everything"}
+	sendPhrases    = []string{"send it", "send that", "send"}
+
+	replacePattern = regexp.MustCompile(`(?i)^(?:replace|change)\s+(.+?)\s+(?:with|to|by)\s+(.+)$`)
+)
+
+// Buffer accumulates dictations until they are sent
+type Buffer struct {
+	mu    sync.Mutex
+	parts []string
+}
+
+// New creates an empty compose buffer
+func New() *Buffer {
+	return &Buffer{}
+}
+
+// normalize lowercases a dictation and strips surrounding punctuation,
+// so "Scratch that." matches "scratch that"
+func normalize(text string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(text), ".,!?;: "))
+}
+
+// matches returns whether text is one of the phrases
+func matches(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if text == phrase {
+			return true
+		}
+	}
+	return false
+}
+
+// trailingSend returns the dictation without a trailing send phrase ("... send it")
+func trailingSend(text string) (string, bool) {
+	trimmed := strings.TrimRight(strings.TrimSpace(text), ".,!?;: ")
+	lower := strings.ToLower(trimmed)
+	for _, phrase := range sendPhrases[:2] { // a bare trailing "send" is too likely to be dictated text
+		if strings.HasSuffix(lower, " "+phrase) {
+			return strings.TrimRight(trimmed[:len(trimmed)-len(phrase)], ",;: "), true
+		}
+	}
+	return text, false
+}
+
+// Handle applies a dictation to the buffer. For Sent, the composed text is
+// returned and the buffer is emptied.
+func (b *Buffer) Handle(text string) (Action, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	command := normalize(text)
+	switch {
+	case matches(command, scratchPhrases):
+		if len(b.parts) == 0 {
+			return NoMatch, ""
+		}
+		b.parts = b.parts[:len(b.parts)-1]
+		return Removed, ""
+
+	case matches(command, clearPhrases):
+		b.parts = nil
+		return Cleared, ""
+
+	case matches(command, sendPhrases):
+		return Sent, b.take()
+	}
+
+	if m := replacePattern.FindStringSubmatch(strings.Trim(strings.TrimSpace(text), ".!?")); m != nil {
+		if b.replace(m[1], m[2]) {
+			return Replaced, ""
+		}
+		return NoMatch, ""
+	}
+
+	if dictated, send := trailingSend(text); send {
+		b.append(dictated)
+		return Sent, b.take()
+	}
+
+	b.append(text)
+	return Appended, ""
+}
+
+// append adds a dictation to the buffer
+func (b *Buffer) append(text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		b.parts = append(b.parts, text)
+	}
+}
+
+// replace changes the most recent occurrence of old (case-insensitive) to new
+func (b *Buffer) replace(old, new string) bool {
+	old = strings.Trim(old, "\"'“”„ ")
+	new = strings.Trim(new, "\"'“”„ ")
+	// Matched on the original text, lowercasing can change the length of a rune
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(old))
+	for i := len(b.parts) - 1; i >= 0; i-- {
+		matches := pattern.FindAllStringIndex(b.parts[i], -1)
+		if len(matches) == 0 {
+			continue
+		}
+		last := matches[len(matches)-1]
+		b.parts[i] = b.parts[i][:last[0]] + new + b.parts[i][last[1]:]
+		return true
+	}
+	return false
+}
+
+// take returns the composed text and empties the buffer
+func (b *Buffer) take() string {
+	text := strings.Join(b.parts, " ")
+	b.parts = nil
+	return text
+}
+
+// Text returns the composed text so far
+func (b *Buffer) Text() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Join(b.parts, " ")
+}
+
+// Send returns the composed text and empties the buffer
+func (b *Buffer) Send() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.take()
+}
+
+// Clear empties the buffer
+func (b *Buffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.parts = nil
+}
@@ -2,35 +2,354 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+// Profile holds settings that override the global configuration.
+// Unset (nil) fields keep the global value.
+type Profile struct {
+	WhisperPrompt       *Prompt  `json:"whisper_prompt,omitempty"`        // Initial prompt for whisper transcription
+	PromptPreset        *string  `json:"prompt_preset,omitempty"`         // Built-in or custom prompt preset by name
+	PasteShortcut       *string  `json:"paste_shortcut,omitempty"`        // Key chord used to paste, e.g. "ctrl+shift+v"
+	InjectionMode       *string  `json:"injection_mode,omitempty"`        // "auto", "clipboard", "type", "file" or "none"
+	OutputFile          *string  `json:"output_file,omitempty"`           // File appended to with injection_mode "file"
+	Preview             *string  `json:"preview,omitempty"`               // "off", "notify" or "menu": confirm before injecting
+	StripTrailingPeriod *bool    `json:"strip_trailing_period,omitempty"` // Drop a trailing "." from the transcription
+	TextPrefix          *string  `json:"text_prefix,omitempty"`           // Template prepended to injected text
+	InjectSuffix        *string  `json:"inject_suffix,omitempty"`         // "space", "newline" or "enter" after the text, empty = nothing
+	NormalizeNumbers    *bool    `json:"normalize_numbers,omitempty"`     // Convert spoken numbers to digits
+	Locale              *string  `json:"locale,omitempty"`                // Number and quote style (e.g. "de", "en-US")
+	SmartQuotes         *bool    `json:"smart_quotes,omitempty"`          // Replace straight quotes with typographic ones
+	LLMEnabled          *bool    `json:"llm_enabled,omitempty"`           // Rewrite the transcript with the LLM
+	LLMSystemPrompt     *string  `json:"llm_system_prompt,omitempty"`     // Instructions for the LLM rewrite
+	AudioPipeline       []string `json:"audio_pipeline,omitempty"`        // Pre-processing stages in order
+	Vocabulary          []string `json:"vocabulary,omitempty"`            // Names and jargon, replaces the global list
+
+	// Only used by named profiles, the model and VAD are shared by all windows
+	Model                  *string  `json:"model,omitempty"`                    // Whisper model
+	Language               *string  `json:"language,omitempty"`                 // Transcription language (e.g. "de")
+	VoiceActivityDetection *bool    `json:"voice_activity_detection,omitempty"` // Mute non-speech before transcribing
+	VADVoiceThreshold      *float64 `json:"vad_voice_threshold,omitempty"`      // Voice probability threshold
+}
+
+// Decoding overrides the whisper decoding settings for a model
+type Decoding struct {
+	BeamSize       *int     `json:"beam_size,omitempty"`
+	BestOf         *int     `json:"best_of,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	TemperatureInc *float64 `json:"temperature_inc,omitempty"`
+	EntropyThold   *float64 `json:"entropy_thold,omitempty"`
+	LogprobThold   *float64 `json:"logprob_thold,omitempty"`
+}
+
+// Command is what a command word runs. Written as a plain string it is a
+// script getting the rest of the transcription as its only argument, as an
+// object a command line or a built-in action whose {placeholders} are filled
+// in with the text.
+type Command struct {
+	Exec  string `json:"exec,omitempty"`  // Command line, e.g. "compose.sh --subject {first_sentence}"
+	Stdin bool   `json:"stdin,omitempty"` // Also pass the rest of the transcription on stdin
+	Plain bool   `json:"-"`               // Exec is a script path written as a plain string
+
+	Aliases []string `json:"aliases,omitempty"` // More words or phrases triggering the command, e.g. "take a note"
+
+	// Built-in actions, a command has exec or one of them
+	Open     string `json:"open,omitempty"`     // URL or file opened with xdg-open
+	Launch   string `json:"launch,omitempty"`   // Command line started in the background, e.g. "firefox"
+	Keys     string `json:"keys,omitempty"`     // Key chord pressed, e.g. "ctrl+s"
+	Type     string `json:"type,omitempty"`     // Text inserted into the focused window
+	Dispatch string `json:"dispatch,omitempty"` // Hyprland dispatcher with arguments, e.g. "workspace {1}"
+}
+
+// Actions returns the names of the actions set, exec included
+func (c Command) Actions() []string {
+	var actions []string
+	for _, a := range []struct{ name, value string }{
+		{"exec", c.Exec}, {"open", c.Open}, {"launch", c.Launch}, {"keys", c.Keys}, {"type", c.Type}, {"dispatch", c.Dispatch},
+	} {
+		if a.value != "" {
+			actions = append(actions, a.name)
+		}
+	}
+	return actions
+}
+
+// UnmarshalJSON accepts a script path or an object
+func (c *Command) UnmarshalJSON(data []byte) error {
+	var script string
+	if err := json.Unmarshal(data, &script); err == nil {
+		*c = Command{Exec: script, Plain: true}
+		return nil
+	}
+	type object Command
+	var o object
+	if err := json.Unmarshal(data, &o); err != nil {
+		return fmt.Errorf("a command is a script path or an object with \"exec\" or an action: %w", err)
+	}
+	*c = Command(o)
+	return nil
+}
+
+// MarshalJSON writes a plain script path back as a string
+func (c Command) MarshalJSON() ([]byte, error) {
+	if c.Plain {
+		return json.Marshal(c.Exec)
+	}
+	type object Command
+	return json.Marshal(object(c))
+}
+
+// CommandFeedback confirms a voice command with a sound and/or speech, for
+// hands-free use without looking at the screen
+type CommandFeedback struct {
+	Sound    string `json:"sound,omitempty"`     // .ogg file played when the command succeeded
+	Say      string `json:"say,omitempty"`       // Spoken when the command succeeded, "{args}" is replaced with its arguments
+	SayError string `json:"say_error,omitempty"` // Spoken when the command failed (empty = error sound only)
+
+	RecognizedSound string `json:"recognized_sound,omitempty"` // .ogg file played as soon as the command is recognized, before it runs
+	Notify          bool   `json:"notify,omitempty"`           // Desktop notification when the command starts and when it finished (with the exit code)
+}
+
+// Trigger is a source of recording start and stop requests besides the
+// hyprwhspr start/stop/toggle commands
+type Trigger struct {
+	Type   string `json:"type"`             // "evdev" (a key of an input device), "dbus" (session bus methods) or "portal" (global shortcut)
+	Device string `json:"device,omitempty"` // evdev: input device, e.g. /dev/input/by-id/usb-...-event-kbd
+	Key    string `json:"key,omitempty"`    // evdev: key name like "f13" or "code:183"; portal: suggested shortcut like "SUPER+D"
+	Mode   string `json:"mode,omitempty"`   // "hold" (record while held, evdev default) or "toggle" (portal default)
+}
+
 // Config represents the application configuration
 type Config struct {
-	Model            string            `json:"model"`
-	Threads          int               `json:"threads"`
-	Language         *string           `json:"language"`          // nil = auto-detect
-	AllowedLanguages []string          `json:"allowed_languages"` // Restrict auto-detect to these languages (e.g. ["de", "en"])
-	AudioDevice      *string           `json:"audio_device"`
-	SampleRate       int               `json:"sample_rate"`
-	SocketPath       string            `json:"socket_path"`
-	WhisperModelDir  string            `json:"whisper_model_dir"`
-	AudioFeedback    bool              `json:"audio_feedback"`
-	StartSoundVolume float64           `json:"start_sound_volume"`
-	StopSoundVolume  float64           `json:"stop_sound_volume"`
-	StartSoundPath   *string           `json:"start_sound_path"` // nil = default
-	StopSoundPath    *string           `json:"stop_sound_path"`  // nil = default
-	CommandMode      bool              `json:"command_mode"`     // Enable command mode
-	Commands         map[string]string `json:"commands"`         // command_word -> script_path
-	WhisperPrompt    string            `json:"whisper_prompt"`   // Initial prompt for whisper transcription
+	Model            string   `json:"model"`
+	Threads          int      `json:"threads"`
+	GPUDevice        int      `json:"gpu_device"`        // CUDA device whisper runs on (0 = first GPU)
+	Language         *string  `json:"language"`          // nil = auto-detect
+	AllowedLanguages []string `json:"allowed_languages"` // Restrict auto-detect to these languages (e.g. ["de", "en"])
+	// Reuse the language detected for allowed_languages while dictations follow each other within this many seconds (0 = detect every time)
+	LanguageCacheSeconds int                `json:"language_cache_seconds"`
+	AudioDevice          *string            `json:"audio_device"`
+	SampleRate           int                `json:"sample_rate"`
+	SocketPath           string             `json:"socket_path"`
+	WhisperModelDir      string             `json:"whisper_model_dir"`
+	AudioFeedback        bool               `json:"audio_feedback"`
+	StartSoundVolume     float64            `json:"start_sound_volume"`
+	StopSoundVolume      float64            `json:"stop_sound_volume"`
+	StartSoundPath       *string            `json:"start_sound_path"` // nil = default
+	StopSoundPath        *string            `json:"stop_sound_path"`  // nil = default
+	CommandMode          bool               `json:"command_mode"`     // Enable command mode
+	Commands             map[string]Command `json:"commands"`         // command_word -> script path or {"exec": ..., "stdin": ...}
+	WhisperPrompt        Prompt             `json:"whisper_prompt"`   // Initial prompt for whisper transcription, or an object with one per language
+
+	// English-only model variant (e.g. base.en) when only English is transcribed (language "en" or allowed_languages ["en"])
+	EnglishModel string `json:"english_model"` // "suggest" (hint at startup), "use" (if downloaded), "download" (on demand) or "off"
+
+	// Code-switching: detect the language of every speech segment the VAD finds instead
+	// of once per recording, so dictations mixing allowed_languages keep each one
+	SegmentLanguageDetection bool `json:"segment_language_detection"`
+
+	// Whisper decoding, beam search is slower but more accurate (most noticeable with small models)
+	BeamSize int `json:"beam_size"` // Beams searched per segment, 0 or 1 = greedy decoding
+	BestOf   int `json:"best_of"`   // Candidates sampled when falling back to a higher temperature (greedy only)
+
+	// Temperature fallback: a segment failing a threshold is decoded again at a higher temperature
+	Temperature    float64 `json:"temperature"`     // Initial sampling temperature, 0 = always the most likely token
+	TemperatureInc float64 `json:"temperature_inc"` // Added for every fallback, 0 = no fallback
+	EntropyThold   float64 `json:"entropy_thold"`   // Fall back when the token entropy is below this (repetitions)
+	LogprobThold   float64 `json:"logprob_thold"`   // Fall back when the average token log probability is below this
+
+	// Segments whisper rates as more likely silence or noise than this are dropped (0-1, 0 = keep all)
+	NoSpeechThreshold float64 `json:"no_speech_threshold"`
+
+	// Additional models kept loaded next to model, used for a recording by mode_models or "hyprwhspr use <model>"
+	ResidentModels []string          `json:"resident_models"`
+	ModeModels     map[string]string `json:"mode_models"` // Constrained mode -> model, e.g. {"command": "tiny"}
+
+	// Decoding overrides per model (model name -> settings), e.g. beam search for "tiny" only
+	ModelDecoding map[string]Decoding `json:"model_decoding"`
+
+	// Named profiles switched at runtime with "hyprwhspr profile <name>" (name -> overrides)
+	Profiles map[string]Profile `json:"profiles"`
+	Profile  string             `json:"profile"` // Profile active on startup (empty = none)
+
+	// Whisper prompt presets ("dictation", "code", ...) selected instead of whisper_prompt
+	PromptPreset  string            `json:"prompt_preset"`  // Empty = use whisper_prompt
+	PromptPresets map[string]string `json:"prompt_presets"` // Custom presets (name -> prompt), may override built-ins
+
+	// Grammar-constrained decoding, selected like a constrained mode ("hyprwhspr mode <name>")
+	Grammars       map[string]string `json:"grammars"`        // Mode name -> GBNF grammar file, in addition to the built-in yesno, digits and command
+	GrammarPenalty float64           `json:"grammar_penalty"` // How strongly whisper is kept from tokens the grammar rejects
+
+	// Commands that only apply while a window of the given class is focused (window class -> command_word -> script_path)
+	AppCommands map[string]map[string]Command `json:"app_commands"`
+
+	// Similarity (0-1) a misheard first word needs to still run the closest command, e.g. "noted" for "note" (0 = exact matches only)
+	CommandFuzzyThreshold float64 `json:"command_fuzzy_threshold"`
+
+	// Confirmation of executed commands (command_word -> sound and/or speech)
+	CommandFeedback map[string]CommandFeedback `json:"command_feedback"`
+	TTSCommand      string                     `json:"tts_command"` // Text-to-speech program, gets the text on stdin
+
+	// Read transcriptions aloud with tts_command, to check them without looking
+	Readback       string `json:"readback"`        // "before" or "after" injection, empty = only on request
+	ReadbackPhrase string `json:"readback_phrase"` // Dictating only this phrase reads the last transcription aloud (empty = disabled)
+
+	// Transcription history
+	History     bool   `json:"history"`      // Save every transcription
+	HistoryPath string `json:"history_path"` // JSONL file the history is appended to
+
+	// Recording archive (WAV files for debugging and re-transcription)
+	ArchiveRecordings bool   `json:"archive_recordings"`
+	RecordingsDir     string `json:"recordings_dir"`
+	ArchiveMaxCount   int    `json:"archive_max_count"`   // Keep at most this many recordings (0 = unlimited)
+	ArchiveMaxSizeMB  int    `json:"archive_max_size_mb"` // Delete the oldest recordings above this size (0 = unlimited)
+
+	// Append-only log of every executed voice command
+	CommandAuditLog  bool   `json:"command_audit_log"`
+	CommandAuditPath string `json:"command_audit_path"`
+
+	// Injection formatting
+	PasteShortcut       string `json:"paste_shortcut"`        // Key chord used to paste, e.g. "shift+Insert" or "ctrl+shift+v"
+	InjectionMode       string `json:"injection_mode"`        // "auto" (paste), "clipboard" (copy only), "type" (keyboard), "file" (output_file) or "none"
+	InjectionBackend    string `json:"injection_backend"`     // Tool pressing the keys: "auto" (the first available of wtype, ydotool, portal, uinput) or one of them
+	StripTrailingPeriod bool   `json:"strip_trailing_period"` // Drop a trailing "." from the transcription
+	TextPrefix          string `json:"text_prefix"`           // Template prepended to injected text, e.g. "[{time}] "
+	InjectSuffix        string `json:"inject_suffix"`         // After a dictation: "space", "newline" (typed) or "enter" (pressed, e.g. to send a chat message), empty = nothing
+
+	// Transcripts appended with a timestamp instead of injected (injection_mode "file")
+	OutputFile string `json:"output_file"`
+
+	// Safeguard against flooding a window with a huge transcription (e.g. a forgotten recording)
+	MaxInjectChars int    `json:"max_inject_chars"` // Longer transcriptions are handled by oversize_action (0 = unlimited)
+	OversizeAction string `json:"oversize_action"`  // "confirm" (ask with a notification), "truncate" or "clipboard"
+
+	// Show the transcription and inject it only once confirmed
+	Preview               string `json:"preview"`                 // "off", "notify" (Insert, Edit and Discard buttons) or "menu" (editable in a launcher)
+	PreviewMenu           string `json:"preview_menu"`            // Launcher editing the text: "auto", "rofi", "wofi" or "fuzzel"
+	PreviewTimeoutSeconds int    `json:"preview_timeout_seconds"` // Without an answer the transcription is discarded
+
+	// Chunked injection, some apps drop or reorder characters of a single huge paste
+	InjectChunkChars   int `json:"inject_chunk_chars"`    // Longer transcriptions are injected in chunks of this many characters (0 = at once)
+	InjectChunkDelayMs int `json:"inject_chunk_delay_ms"` // Pause between chunks
+
+	// Injection verification
+	VerifyInjection          bool `json:"verify_injection"`            // Confirm the paste landed and retry with other methods
+	InjectionVerifyTimeoutMs int  `json:"injection_verify_timeout_ms"` // How long to wait for the paste to be confirmed
+
+	// Per-application overrides keyed by window class (e.g. "kitty", "firefox")
+	AppProfiles map[string]Profile `json:"app_profiles"`
+
+	// Post-processing
+	RemoveFillers bool     `json:"remove_fillers"` // Strip "um", "uh", "you know" and repeated words
+	FillerWords   []string `json:"filler_words"`   // Additional filler words/phrases to strip
+
+	// Names, jargon and product terms ("Kubernetes", "ChatGPT"), added to the whisper
+	// prompt and used to correct near-misses in the transcript
+	Vocabulary []string `json:"vocabulary"`
+
+	// Known whisper hallucinations ("Thanks for watching!") are dropped when they are all a short or quiet recording came out as
+	HallucinationPhrases    []string `json:"hallucination_phrases"`     // Additional phrases, a trailing "*" matches any ending
+	HallucinationMaxSeconds float64  `json:"hallucination_max_seconds"` // Recordings up to this long count as short
+	HallucinationQuietDB    float64  `json:"hallucination_quiet_db"`    // Recordings with an RMS level (dBFS) below this count as quiet
+
+	NormalizeNumbers          bool     `json:"normalize_numbers"`           // Convert spoken numbers, percentages, currency and dates to digits
+	NormalizeNumbersLanguages []string `json:"normalize_numbers_languages"` // Only normalize for these languages (empty = all supported: en, de)
+
+	// Typography
+	Locale      string `json:"locale"`       // Decimal separator and quote style, e.g. "de", "en-US", "de-CH" (empty = transcribed language)
+	SmartQuotes bool   `json:"smart_quotes"` // Replace straight quotes and apostrophes with the locale's typographic ones
+
+	// LLM post-processing (rewrites the transcript before injection)
+	LLMEnabled        bool   `json:"llm_enabled"`
+	LLMProvider       string `json:"llm_provider"`        // "ollama" or "openai" (any OpenAI-compatible endpoint)
+	LLMEndpoint       string `json:"llm_endpoint"`        // Base URL, empty = provider default
+	LLMModel          string `json:"llm_model"`           // e.g. "llama3.2" or "gpt-4o-mini"
+	LLMAPIKey         string `json:"llm_api_key"`         // Empty = $OPENAI_API_KEY for the openai provider
+	LLMSystemPrompt   string `json:"llm_system_prompt"`   // Instructions sent with every transcript
+	LLMTimeoutSeconds int    `json:"llm_timeout_seconds"` // Give up and inject the raw transcript after this long
+
+	// Experimental: inject finalized segments while still recording
+	StreamingInjection  bool `json:"streaming_injection"`
+	StreamingIntervalMs int  `json:"streaming_interval_ms"` // How often the recording so far is transcribed
+
+	// Processing watchdog: warn when processing takes longer than
+	// max(watchdog_min_seconds, watchdog_factor * recording duration)
+	WatchdogFactor     float64 `json:"watchdog_factor"`
+	WatchdogMinSeconds int     `json:"watchdog_min_seconds"`
+
+	// Priority of transcriptions, so whisper on the CPU doesn't make the desktop stutter
+	ProcessingNice     int    `json:"processing_nice"`      // Niceness 1-19 (0 = unchanged)
+	ProcessingIOClass  string `json:"processing_io_class"`  // I/O class "idle" or "best-effort" ("" = unchanged)
+	ProcessingMaxProcs int    `json:"processing_max_procs"` // GOMAXPROCS while transcribing (0 = unchanged)
+
+	// Pressing toggle while a transcription is processed cancels it instead of starting a new recording
+	ToggleCancelsProcessing bool `json:"toggle_cancels_processing"`
+
+	// Hold-to-record with the "press" and "release" commands (bind/bindr pairs)
+	PressDebounceMs int `json:"press_debounce_ms"` // A press this soon after the release continues the recording
+	HoldMinMs       int `json:"hold_min_ms"`       // Shorter holds are taps, their recording is discarded
+
+	// Push-to-talk keys, D-Bus methods and global shortcuts starting and stopping recordings
+	Triggers []Trigger `json:"triggers"`
+
+	// Recording duration warnings
+	RecordingWarningSeconds []int   `json:"recording_warning_seconds"` // Warn when a recording passes these durations (empty = disabled)
+	WarningSoundVolume      float64 `json:"warning_sound_volume"`      // Volume of the warning tick
+	WarningSoundPath        *string `json:"warning_sound_path"`        // nil = built-in tick
+	MaxRecordingSeconds     int     `json:"max_recording_seconds"`     // Stop and transcribe a recording after this long (0 = no limit)
+
+	// Warn when the microphone delivers silence (muted, wrong device) for this many seconds (0 = disabled)
+	DeadMicSeconds     int     `json:"dead_mic_seconds"`
+	DeadMicThresholdDB float64 `json:"dead_mic_threshold_db"` // Peak level (dBFS) below which the microphone counts as silent
+
+	// Send the input level as a "level" event this often while recording, for VU meters (0 = disabled)
+	LevelEventsMs int `json:"level_events_ms"`
+
+	// Sound theme: a directory with start/stop/warning/done/error .ogg files,
+	// by name from the themes directory or as an absolute path (empty = default sounds)
+	SoundTheme string `json:"sound_theme"`
+
+	// Compose mode: collect dictations with voice edits ("scratch that", "replace X with Y")
+	// and inject them at once on "send it"
+	ComposeMode bool `json:"compose_mode"`
+
+	// Privacy: keep transcribed text out of logs, notifications and the command audit log
+	// (word counts and durations are logged instead). The history is controlled by "history".
+	RedactTranscripts bool `json:"redact_transcripts"`
+
+	// How long the "success" and "error" states are shown after processing (0 = go straight to idle)
+	ResultStateSeconds int `json:"result_state_seconds"`
+
+	// On-screen indicator (Wayland layer-shell) while recording and processing
+	Overlay         bool   `json:"overlay"`
+	OverlayPosition string `json:"overlay_position"` // "top", "bottom", "top-left", "top-right", "bottom-left" or "bottom-right"
+
+	// Low-power idle mode: release audio devices after this many idle minutes (0 = never)
+	IdleTimeoutMinutes int  `json:"idle_timeout_minutes"`
+	IdleUnloadModel    bool `json:"idle_unload_model"` // Also free the whisper model (reloaded on the next recording)
+
+	// Whisper model memory: load the model on the first recording instead of at startup, free it after this many idle minutes (0 = keep it)
+	LazyLoadModel      bool `json:"lazy_load_model"`
+	ModelUnloadMinutes int  `json:"model_unload_minutes"`
+
+	// Highlight words whose token probability is below this threshold in detailed output (0 = disabled)
+	LowConfidenceThreshold float64 `json:"low_confidence_threshold"`
+
+	// Recording markers ("hyprwhspr marker" or the spoken phrase) bookmark moments in long recordings
+	MarkerPhrase string `json:"marker_phrase"` // Spoken phrase that sets a marker, removed from the text (empty = hotkey only)
+	MarkersDir   string `json:"markers_dir"`   // Recordings with markers are exported here as .srt files
 
 	// Echo Cancellation settings
 	EchoCancellation   bool    `json:"echo_cancellation"`    // Enable acoustic echo cancellation
+	AECBackend         string  `json:"aec_backend"`          // "nlms" (built-in) or "speex" (libspeexdsp, better with speakers)
 	AECFilterLength    int     `json:"aec_filter_length"`    // AEC filter length (512-2048)
 	AECStepSize        float64 `json:"aec_step_size"`        // AEC adaptation step size (0.01-0.1)
 	AECEchoSuppression float64 `json:"aec_echo_suppression"` // Echo suppression gain (0.0-1.0)
@@ -39,34 +358,194 @@ type Config struct {
 	VoiceActivityDetection bool    `json:"voice_activity_detection"` // Enable VAD
 	VADEnergyThreshold     float64 `json:"vad_energy_threshold"`     // Energy threshold for VAD
 	VADVoiceThreshold      float64 `json:"vad_voice_threshold"`      // Voice probability threshold
+	VADEngine              string  `json:"vad_engine"`               // "energy" or "silero"
+	VADModel               string  `json:"vad_model"`                // Silero model in whisper_model_dir (ggml-<name>.bin)
+
+	// Open the microphone at its native rate (e.g. 48000) and resample to sample_rate
+	// in hyprwhspr, for backends and devices that convert badly
+	NativeSampleRate bool `json:"native_sample_rate"`
+
+	// Multi-channel microphones and audio interfaces
+	CaptureChannels int `json:"capture_channels"` // Channels to open the device with (0 = device default)
+	CaptureChannel  int `json:"capture_channel"`  // Channel to record (1-based), 0 = mix all channels to mono
+
+	// Audio kept of a recording, a longer one keeps its last minutes so a forgotten
+	// microphone can't use up memory (0 = unlimited)
+	RecordingBufferMinutes int `json:"recording_buffer_minutes"`
+
+	// Keep the microphone open and prepend this much audio from before "start" to
+	// every recording, so the first word isn't clipped (0 = disabled)
+	PreRollMs int `json:"pre_roll_ms"`
+
+	// Audio pre-processing chain, stages run in order before transcription:
+	// "aec", "highpass", "denoise", "agc", "vad"
+	AudioPipeline    []string `json:"audio_pipeline"`
+	HighPassCutoffHz float64  `json:"highpass_cutoff_hz"` // Frequencies below are removed by "highpass"
+	AGCMaxGain       float64  `json:"agc_max_gain"`       // Strongest amplification "agc" applies
+	DenoiseStrength  float64  `json:"denoise_strength"`   // How aggressively "denoise" removes background noise
+	DenoiseBackend   string   `json:"denoise_backend"`    // "spectral" (built-in) or "rnnoise" (librnnoise, also removes keyboard clatter)
+
+	// Options overridden by HYPRWHSPR_* environment variables (not saved)
+	envOverrides map[string]envOverride
 }
 
 // Default returns default configuration
 func Default() *Config {
 	homeDir, _ := os.UserHomeDir()
 	socketPath := filepath.Join(homeDir, ".config", "hyprwhspr", "hyprwhspr.sock")
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		// Per-user tmpfs, removed on logout so no socket outlives the session
+		socketPath = filepath.Join(runtimeDir, "hyprwhspr.sock")
+	}
 	modelDir := filepath.Join(homeDir, ".local", "share", "hyprwhspr")
 
 	return &Config{
-		Model:            "base",
-		Threads:          4,
-		Language:         nil,        // auto-detect
-		AllowedLanguages: []string{}, // empty = all languages allowed
-		AudioDevice:      nil,        // default device
-		SampleRate:       16000,
-		SocketPath:       socketPath,
-		WhisperModelDir:  modelDir,
-		AudioFeedback:    true,                    // Enable audio feedback by default
-		StartSoundVolume: 0.4,                     // 40% volume for start sound
-		StopSoundVolume:  0.4,                     // 40% volume for stop sound
-		StartSoundPath:   nil,                     // Use default
-		StopSoundPath:    nil,                     // Use default
-		CommandMode:      false,                   // Disabled by default
-		Commands:         make(map[string]string), // Empty by default
-		WhisperPrompt:    "Transcribe with proper capitalization, including sentence beginnings, proper nouns, titles, and standard English capitalization rules.",
+		Model:                "base",
+		Threads:              4,
+		Language:             nil,        // auto-detect
+		AllowedLanguages:     []string{}, // empty = all languages allowed
+		LanguageCacheSeconds: 0,
+		EnglishModel:         "suggest",
+		BeamSize:             0,
+		BestOf:               5,
+		Temperature:          0,
+		TemperatureInc:       0.2,
+		EntropyThold:         2.4,
+		LogprobThold:         -1,
+		NoSpeechThreshold:    0.8,
+		AudioDevice:          nil, // default device
+		SampleRate:           16000,
+		SocketPath:           socketPath,
+		WhisperModelDir:      modelDir,
+		AudioFeedback:        true,                     // Enable audio feedback by default
+		StartSoundVolume:     0.4,                      // 40% volume for start sound
+		StopSoundVolume:      0.4,                      // 40% volume for stop sound
+		StartSoundPath:       nil,                      // Use default
+		StopSoundPath:        nil,                      // Use default
+		CommandMode:          false,                    // Disabled by default
+		Commands:             make(map[string]Command), // Empty by default
+		AppCommands:          make(map[string]map[string]Command),
+		CommandFeedback:      make(map[string]CommandFeedback),
+		TTSCommand:           "espeak-ng --stdin",
+		Readback:             "",
+		ReadbackPhrase:       "",
+
+		SegmentLanguageDetection: false,
+
+		CommandFuzzyThreshold: 0, // Exact matches only
+
+		History:     true,
+		HistoryPath: filepath.Join(modelDir, "history.jsonl"),
+
+		ArchiveRecordings: false,
+		RecordingsDir:     filepath.Join(modelDir, "recordings"),
+		ArchiveMaxCount:   100,
+		ArchiveMaxSizeMB:  500,
+
+		PromptPresets: make(map[string]string),
+
+		Grammars:       make(map[string]string),
+		GrammarPenalty: 100,
+
+		Profiles: make(map[string]Profile),
+
+		CommandAuditLog:  true,
+		CommandAuditPath: filepath.Join(modelDir, "command-audit.jsonl"),
+		WhisperPrompt:    Prompt{Default: "Transcribe with proper capitalization, including sentence beginnings, proper nouns, titles, and standard English capitalization rules."},
+
+		LowConfidenceThreshold: 0.4, // Mark words below 40% probability
+
+		MarkerPhrase: "",
+		MarkersDir:   filepath.Join(modelDir, "markers"),
+
+		PasteShortcut:       "shift+Insert", // Works in terminals and most GUI apps
+		InjectionMode:       "auto",
+		InjectionBackend:    "auto",
+		StripTrailingPeriod: false,
+		TextPrefix:          "",
+		InjectSuffix:        "",
+		AppProfiles:         make(map[string]Profile),
+
+		OutputFile: filepath.Join(modelDir, "transcripts.txt"),
+
+		MaxInjectChars: 5000,
+		OversizeAction: "confirm",
+
+		Preview:               "off",
+		PreviewMenu:           "auto",
+		PreviewTimeoutSeconds: 60,
+
+		InjectChunkChars:   1000,
+		InjectChunkDelayMs: 100,
+
+		VerifyInjection:          false,
+		InjectionVerifyTimeoutMs: 1000,
+
+		RemoveFillers: false,
+		FillerWords:   []string{},
+
+		Vocabulary: []string{},
+
+		HallucinationPhrases:    []string{},
+		HallucinationMaxSeconds: 3,
+		HallucinationQuietDB:    -45,
+
+		NormalizeNumbers:          false,
+		NormalizeNumbersLanguages: []string{},
+
+		Locale:      "",
+		SmartQuotes: false,
+
+		LLMEnabled:        false,
+		LLMProvider:       "ollama",
+		LLMEndpoint:       "",
+		LLMModel:          "llama3.2",
+		LLMSystemPrompt:   "You clean up dictated text. Fix grammar, punctuation and obvious transcription errors, and remove rambling and repetitions. Keep the meaning, language and tone. Reply with the corrected text only.",
+		LLMTimeoutSeconds: 15,
+
+		StreamingInjection:  false,
+		StreamingIntervalMs: 3000,
+
+		WatchdogFactor:     3.0,
+		WatchdogMinSeconds: 20,
+
+		ProcessingNice:     0,
+		ProcessingIOClass:  "",
+		ProcessingMaxProcs: 0,
+
+		ToggleCancelsProcessing: false,
+
+		PressDebounceMs: 150,
+		HoldMinMs:       250,
+
+		Triggers: []Trigger{},
+
+		RecordingWarningSeconds: []int{120, 300}, // Warn at 2 and 5 minutes
+		WarningSoundVolume:      0.3,
+		WarningSoundPath:        nil,
+
+		DeadMicSeconds:     3,
+		DeadMicThresholdDB: -70,
+
+		LevelEventsMs: 100,
+
+		SoundTheme: "",
+
+		ComposeMode: false,
+
+		RedactTranscripts: false,
+
+		ResultStateSeconds: 2,
+
+		Overlay:         false,
+		OverlayPosition: "bottom",
+
+		IdleTimeoutMinutes: 10,
+		IdleUnloadModel:    false,
 
 		// Echo Cancellation defaults
 		EchoCancellation:   true, // Enable AEC by default
+		AECBackend:         "nlms",
 		AECFilterLength:    1024, // Default filter length
 		AECStepSize:        0.05, // Default step size
 		AECEchoSuppression: 0.7,  // Default echo suppression
@@ -75,7 +554,191 @@ func Default() *Config {
 		VoiceActivityDetection: true, // Enable VAD by default
 		VADEnergyThreshold:     0.01, // Default energy threshold
 		VADVoiceThreshold:      0.5,  // Default voice probability threshold
+		VADEngine:              "energy",
+		VADModel:               "silero-v5.1.2",
+
+		NativeSampleRate: false,
+
+		CaptureChannels: 1,
+		CaptureChannel:  0,
+
+		RecordingBufferMinutes: 10,
+		PreRollMs:              0,
+
+		// Audio pre-processing defaults
+		AudioPipeline:    []string{"aec", "vad"},
+		HighPassCutoffHz: 80,
+		AGCMaxGain:       10,
+		DenoiseStrength:  1,
+		DenoiseBackend:   "spectral",
+	}
+}
+
+// EnglishOnly returns whether only English is transcribed: language is "en"
+// or allowed_languages is exactly ["en"]
+func (c *Config) EnglishOnly() bool {
+	if c.Language != nil {
+		return *c.Language == "en"
 	}
+	return len(c.AllowedLanguages) == 1 && strings.EqualFold(c.AllowedLanguages[0], "en")
+}
+
+// Apply returns a copy of the config with the profile's overrides applied
+func (c *Config) Apply(p Profile) *Config {
+	cfg := *c
+	if p.PromptPreset != nil {
+		cfg.PromptPreset = *p.PromptPreset
+	}
+	if p.WhisperPrompt != nil {
+		// An explicit prompt wins over a preset
+		cfg.WhisperPrompt = *p.WhisperPrompt
+		if p.PromptPreset == nil {
+			cfg.PromptPreset = ""
+		}
+	}
+	if p.PasteShortcut != nil {
+		cfg.PasteShortcut = *p.PasteShortcut
+	}
+	if p.InjectionMode != nil {
+		cfg.InjectionMode = *p.InjectionMode
+	}
+	if p.OutputFile != nil {
+		cfg.OutputFile = *p.OutputFile
+	}
+	if p.Preview != nil {
+		cfg.Preview = *p.Preview
+	}
+	if p.StripTrailingPeriod != nil {
+		cfg.StripTrailingPeriod = *p.StripTrailingPeriod
+	}
+	if p.TextPrefix != nil {
+		cfg.TextPrefix = *p.TextPrefix
+	}
+	if p.InjectSuffix != nil {
+		cfg.InjectSuffix = *p.InjectSuffix
+	}
+	if p.NormalizeNumbers != nil {
+		cfg.NormalizeNumbers = *p.NormalizeNumbers
+	}
+	if p.Locale != nil {
+		cfg.Locale = *p.Locale
+	}
+	if p.SmartQuotes != nil {
+		cfg.SmartQuotes = *p.SmartQuotes
+	}
+	if p.LLMEnabled != nil {
+		cfg.LLMEnabled = *p.LLMEnabled
+	}
+	if p.LLMSystemPrompt != nil {
+		cfg.LLMSystemPrompt = *p.LLMSystemPrompt
+	}
+	if p.Model != nil {
+		cfg.Model = *p.Model
+	}
+	if p.Language != nil {
+		cfg.Language = p.Language
+	}
+	if p.VoiceActivityDetection != nil {
+		cfg.VoiceActivityDetection = *p.VoiceActivityDetection
+	}
+	if p.VADVoiceThreshold != nil {
+		cfg.VADVoiceThreshold = *p.VADVoiceThreshold
+	}
+	if p.AudioPipeline != nil {
+		cfg.AudioPipeline = p.AudioPipeline
+	}
+	if p.Vocabulary != nil {
+		cfg.Vocabulary = p.Vocabulary
+	}
+	return &cfg
+}
+
+// WithProfile returns a copy of the config with a named profile applied
+// (the config itself for an empty name)
+func (c *Config) WithProfile(name string) (*Config, error) {
+	if name == "" {
+		return c, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	return c.Apply(p), nil
+}
+
+// ForModel returns a copy of the config with the model_decoding overrides of
+// the model applied. An English-only variant like "base.en" falls back to the
+// overrides of "base".
+func (c *Config) ForModel(model string) *Config {
+	d, ok := c.ModelDecoding[model]
+	if !ok {
+		if d, ok = c.ModelDecoding[strings.TrimSuffix(model, ".en")]; !ok {
+			return c
+		}
+	}
+	cfg := *c
+	if d.BeamSize != nil {
+		cfg.BeamSize = *d.BeamSize
+	}
+	if d.BestOf != nil {
+		cfg.BestOf = *d.BestOf
+	}
+	if d.Temperature != nil {
+		cfg.Temperature = *d.Temperature
+	}
+	if d.TemperatureInc != nil {
+		cfg.TemperatureInc = *d.TemperatureInc
+	}
+	if d.EntropyThold != nil {
+		cfg.EntropyThold = *d.EntropyThold
+	}
+	if d.LogprobThold != nil {
+		cfg.LogprobThold = *d.LogprobThold
+	}
+	return &cfg
+}
+
+// ProfileNames returns the names of the named profiles in order
+func (c *Config) ProfileNames() []string {
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForWindowClass returns the effective config for a window, applying the first
+// app profile whose key matches one of the given classes (case-insensitive).
+// Keys can be patterns with * wildcards (e.g. "*term*", "chrome-*"), a key
+// without wildcards wins over patterns, patterns are tried in sorted order.
+func (c *Config) ForWindowClass(classes ...string) *Config {
+	for key, profile := range c.AppProfiles {
+		for _, class := range classes {
+			if class != "" && strings.EqualFold(key, class) {
+				return c.Apply(profile)
+			}
+		}
+	}
+
+	var patterns []string
+	for key := range c.AppProfiles {
+		if strings.Contains(key, "*") {
+			patterns = append(patterns, key)
+		}
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		for _, class := range classes {
+			if class == "" {
+				continue
+			}
+			if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(class)); ok {
+				return c.Apply(c.AppProfiles[pattern])
+			}
+		}
+	}
+	return c
 }
 
 // Load loads configuration from file
@@ -85,16 +748,19 @@ func Load(configPath string) (*Config, error) {
 
 	// Try to read config file
 	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Config doesn't exist, return defaults
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
-	// Parse JSON
-	if err := json.Unmarshal(data, cfg); err != nil {
+	// Parse JSON (a missing config file leaves the defaults)
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, describeJSONError(data, err))
+		}
+	}
+
+	// Environment variables take precedence over the file
+	if err := cfg.applyEnv(); err != nil {
 		return nil, err
 	}
 
@@ -110,17 +776,48 @@ func (c *Config) Save(configPath string) error {
 	}
 
 	// Marshal to JSON
-	data, err := json.MarshalIndent(c, "", "  ")
+	var v interface{} = c
+	if len(c.envOverrides) > 0 {
+		m, err := c.withoutEnv()
+		if err != nil {
+			return err
+		}
+		v = m
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it, so readers never see a half-written config
+	tmp, err := os.CreateTemp(dir, ".config-*.json")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name())
 
-	// Write to file
-	return os.WriteFile(configPath, data, 0644)
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), configPath)
 }
 
-// GetConfigPath returns the default config path
+// ConfigPathEnv selects another config file, e.g. for a second daemon instance
+const ConfigPathEnv = "HYPRWHSPR_CONFIG"
+
+// GetConfigPath returns the config path, $HYPRWHSPR_CONFIG or the default
 func GetConfigPath() string {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path
+	}
 	homeDir, _ := os.UserHomeDir()
 	return filepath.Join(homeDir, ".config", "hyprwhspr", "config.json")
 }
@@ -228,7 +925,7 @@ func (w *Watcher) reloadConfig() {
 
 	cfg, err := Load(w.configPath)
 	if err != nil {
-		// In a real application, you might want to log this
+		fmt.Printf("❌ Config reload failed, keeping the current config: %v\n", err)
 		return
 	}
 
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// toMap converts the config into generic JSON values
+func (c *Config) toMap() (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Get returns the JSON value of a dotted key like "vad_voice_threshold" or
+// "app_profiles.kitty.paste_shortcut"
+func (c *Config) Get(key string) (string, error) {
+	m, err := c.toMap()
+	if err != nil {
+		return "", err
+	}
+
+	var value interface{} = m
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s: not an object", key)
+		}
+		if value, ok = obj[part]; !ok {
+			return "", fmt.Errorf("unknown key: %s", key)
+		}
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Set changes the value of a dotted key. The value is parsed as JSON
+// (numbers, true/false, null, lists, objects); anything else is taken as a string.
+// Returns the updated config, c is left unchanged.
+func (c *Config) Set(key, value string) (*Config, error) {
+	m, err := c.toMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		parsed = value
+	}
+
+	parts := strings.Split(key, ".")
+	obj := m
+	for i, part := range parts[:len(parts)-1] {
+		next, exists := obj[part]
+		if !exists || next == nil {
+			// Only maps (commands, app_profiles, ...) may gain new entries
+			if i == 0 {
+				return nil, fmt.Errorf("unknown key: %s", strings.Join(parts[:i+1], "."))
+			}
+			next = map[string]interface{}{}
+			obj[part] = next
+		}
+		nextObj, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: not an object", strings.Join(parts[:i+1], "."))
+		}
+		obj = nextObj
+	}
+
+	last := parts[len(parts)-1]
+	if _, exists := m[last]; len(parts) == 1 && !exists {
+		return nil, fmt.Errorf("unknown key: %s", key)
+	}
+	obj[last] = parsed
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	updated := &Config{}
+	if err := json.Unmarshal(data, updated); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%s must be %s, not %s", key, jsonType(typeErr.Type), typeErr.Value)
+		}
+		return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	updated.envOverrides = c.envOverrides
+	return updated, nil
+}
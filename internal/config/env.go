@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is prepended to the upper-cased option name to form its environment
+// variable, e.g. HYPRWHSPR_MODEL or HYPRWHSPR_SOCKET_PATH
+const EnvPrefix = "HYPRWHSPR_"
+
+// envOverride is an option whose file value was replaced by an environment variable
+type envOverride struct {
+	env   string      // Variable name
+	file  interface{} // JSON value from the file (or the default)
+	value interface{} // JSON value from the environment
+}
+
+// EnvName returns the environment variable that overrides a config key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// applyEnv overrides top-level options with HYPRWHSPR_* environment variables.
+// Lists are comma-separated, objects (commands, app_profiles, ...) can't be overridden.
+func (c *Config) applyEnv() error {
+	before, err := c.toMap()
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		env := EnvName(key)
+		raw, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), raw); err != nil {
+			return fmt.Errorf("%s: %w", env, err)
+		}
+
+		after, err := c.toMap()
+		if err != nil {
+			return err
+		}
+		if c.envOverrides == nil {
+			c.envOverrides = make(map[string]envOverride)
+		}
+		c.envOverrides[key] = envOverride{env: env, file: before[key], value: after[key]}
+	}
+	return nil
+}
+
+// setFromEnv parses an environment variable into a config field
+func setFromEnv(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, not %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("must be a whole number, not %q", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, not %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Ptr:
+		// Empty resets optional values like audio_device to their default
+		if raw == "" {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		value := reflect.New(field.Type().Elem())
+		if err := setFromEnv(value.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(value)
+	case reflect.Slice:
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			value := reflect.New(field.Type().Elem()).Elem()
+			if err := setFromEnv(value, item); err != nil {
+				return err
+			}
+			list = reflect.Append(list, value)
+		}
+		field.Set(list)
+	default:
+		return fmt.Errorf("can't be set from the environment, use the config file")
+	}
+	return nil
+}
+
+// EnvOverrides returns the active overrides as "option (VARIABLE)" in option order
+func (c *Config) EnvOverrides() []string {
+	var overrides []string
+	for key, o := range c.envOverrides {
+		overrides = append(overrides, fmt.Sprintf("%s (%s)", key, o.env))
+	}
+	sort.Strings(overrides)
+	return overrides
+}
+
+// withoutEnv returns the JSON values to save: options that still have their
+// environment value get their file value back, so overrides never end up in the file
+func (c *Config) withoutEnv() (map[string]interface{}, error) {
+	m, err := c.toMap()
+	if err != nil {
+		return nil, err
+	}
+	for key, o := range c.envOverrides {
+		if reflect.DeepEqual(m[key], o.value) {
+			m[key] = o.file
+		}
+	}
+	return m, nil
+}
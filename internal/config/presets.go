@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Prompt is a whisper_prompt. Written as a string it is used for every language,
+// written as an object it maps language codes to prompts, with "default" for the
+// languages without their own, e.g. {"en": "Hello, world.", "de": "Hallo, Welt."}.
+type Prompt struct {
+	Default   string
+	Languages map[string]string // Language code -> prompt
+}
+
+// For returns the prompt for a language ("" = not known yet)
+func (p Prompt) For(language string) string {
+	if prompt, ok := p.Languages[language]; ok {
+		return prompt
+	}
+	return p.Default
+}
+
+// MarshalJSON writes a string unless there are per-language prompts
+func (p Prompt) MarshalJSON() ([]byte, error) {
+	if len(p.Languages) == 0 {
+		return json.Marshal(p.Default)
+	}
+	m := make(map[string]string, len(p.Languages)+1)
+	for language, prompt := range p.Languages {
+		m[language] = prompt
+	}
+	if p.Default != "" {
+		m["default"] = p.Default
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON reads a string or an object of per-language prompts
+func (p *Prompt) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = Prompt{Default: text}
+		return nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("whisper_prompt must be a string or an object of language codes and prompts")
+	}
+	*p = Prompt{Default: m["default"]}
+	delete(m, "default")
+	if len(m) > 0 {
+		p.Languages = m
+	}
+	return nil
+}
+
+// PromptPresets are the built-in whisper prompts that can be selected by name
+// with "prompt_preset". Whisper continues the style of the prompt, so each preset
+// is written the way the transcript should look.
+var PromptPresets = map[string]string{
+	"dictation": "Transcribe with proper capitalization, including sentence beginnings, proper nouns, titles, and standard English capitalization rules.",
+	"punctuation": "Hello, how are you? I'm fine, thanks. Well, let's see: first, we check the results; then, if everything works, we ship it! " +
+		"Commas, periods, question marks, colons and semicolons are all written out.",
+	"code": "Use snake_case, camelCase and kebab-case identifiers as spoken. Common terms: git, npm, JSON, YAML, API, HTTP, SQL, regex, " +
+		"stdout, stderr, localhost, sudo, config, env, CLI, TypeScript, Go, Rust, Python, Kubernetes, Docker, async, await, struct, enum.",
+	"medical": "The patient presented with dyspnea and tachycardia. History of hypertension, type 2 diabetes mellitus and COPD. " +
+		"Medications: metformin 500 mg, lisinopril 10 mg, atorvastatin. ECG showed sinus rhythm; troponin negative. Plan: CBC, CMP, chest X-ray.",
+	"technical": "Technical documentation with acronyms and units: CPU, GPU, RAM, SSD, TCP/IP, DNS, TLS, SSH, kHz, MHz, GHz, ms, kB, MB, GB. " +
+		"Version numbers like 1.2.3, file names like config.json, and product names are written exactly.",
+	"email": "Hi Anna,\n\nthanks for your message. I've attached the report; let me know if you have any questions.\n\nBest regards,",
+	"chat":  "hey, sounds good! see you at 8 then :) btw did you get my message?",
+}
+
+// promptPresets returns the built-in presets merged with the user-defined ones
+func (c *Config) promptPresets() map[string]string {
+	presets := make(map[string]string, len(PromptPresets)+len(c.PromptPresets))
+	for name, prompt := range PromptPresets {
+		presets[name] = prompt
+	}
+	for name, prompt := range c.PromptPresets {
+		presets[name] = prompt
+	}
+	return presets
+}
+
+// PresetNames returns the names of all available presets in order
+func (c *Config) PresetNames() []string {
+	var names []string
+	for name := range c.promptPresets() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetPrompt returns the prompt of a preset
+func (c *Config) PresetPrompt(name string) (string, bool) {
+	prompt, ok := c.promptPresets()[name]
+	return prompt, ok
+}
+
+// Prompt returns the effective whisper prompt: the selected preset, or whisper_prompt
+// (its "default" if it has one per language, see LanguagePrompts)
+func (c *Config) Prompt() string {
+	if c.PromptPreset == "" {
+		return c.withVocabulary(c.WhisperPrompt.Default)
+	}
+	if prompt, ok := c.PresetPrompt(c.PromptPreset); ok {
+		return c.withVocabulary(prompt)
+	}
+	fmt.Printf("⚠️  Unknown prompt preset '%s', using whisper_prompt\n", c.PromptPreset)
+	return c.withVocabulary(c.WhisperPrompt.Default)
+}
+
+// LanguagePrompts returns the per-language prompts of whisper_prompt, which
+// replace Prompt once the language is known. nil when a preset is selected.
+func (c *Config) LanguagePrompts() map[string]string {
+	if c.PromptPreset != "" {
+		if _, ok := c.PresetPrompt(c.PromptPreset); ok {
+			return nil
+		}
+	}
+	if len(c.WhisperPrompt.Languages) == 0 || len(c.Vocabulary) == 0 {
+		return c.WhisperPrompt.Languages
+	}
+	prompts := make(map[string]string, len(c.WhisperPrompt.Languages))
+	for language, prompt := range c.WhisperPrompt.Languages {
+		prompts[language] = c.withVocabulary(prompt)
+	}
+	return prompts
+}
+
+// withVocabulary appends the vocabulary to prompt. Whisper takes the prompt as
+// preceding text, so spellings that appear in it are more likely to be used.
+func (c *Config) withVocabulary(prompt string) string {
+	var terms []string
+	for _, term := range c.Vocabulary {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return prompt
+	}
+	vocabulary := strings.Join(terms, ", ") + "."
+	if prompt = strings.TrimSpace(prompt); prompt == "" {
+		return vocabulary
+	}
+	return prompt + " " + vocabulary
+}
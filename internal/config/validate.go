@@ -0,0 +1,720 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// AudioStages are the stages audio_pipeline can contain
+var AudioStages = []string{"aec", "highpass", "denoise", "agc", "vad"}
+
+// InjectionModes are the values of injection_mode
+var InjectionModes = []string{"auto", "clipboard", "type", "file", "none"}
+
+// TriggerTypes are the values of a trigger's type
+var TriggerTypes = []string{"evdev", "dbus", "portal"}
+
+// EnglishModelModes are the values of english_model
+var EnglishModelModes = []string{"suggest", "use", "download", "off"}
+
+// InjectSuffixes are the values of inject_suffix
+var InjectSuffixes = []string{"", "space", "newline", "enter"}
+
+// PreviewModes are the values of preview
+var PreviewModes = []string{"off", "notify", "menu"}
+
+// PreviewMenus are the values of preview_menu
+var PreviewMenus = []string{"auto", "rofi", "wofi", "fuzzel"}
+
+// OverlayPositions are the values of overlay_position
+var OverlayPositions = []string{"top", "bottom", "top-left", "top-right", "bottom-left", "bottom-right"}
+
+// InjectionBackends are the values of injection_backend
+var InjectionBackends = []string{"auto", "wtype", "ydotool", "portal", "uinput"}
+
+// contains returns whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Issue is a problem found while validating the config
+type Issue struct {
+	Field   string
+	Message string
+	Warning bool // The daemon still works, but probably not as intended
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// describeJSONError turns a JSON decoding error into a message with line and column
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("invalid JSON at line %d, column %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		line, col := position(data, typeErr.Offset)
+		field := typeErr.Field
+		if field == "" {
+			field = "value"
+		}
+		return fmt.Errorf("line %d, column %d: %q must be %s, not %s", line, col, field, jsonType(typeErr.Type), typeErr.Value)
+	}
+	return err
+}
+
+// position converts a byte offset into a 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line, col := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// jsonType describes a Go type in JSON terms
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "a whole number"
+	case reflect.Float64, reflect.Float32:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Ptr:
+		return jsonType(t.Elem()) + " or null"
+	}
+	return t.String()
+}
+
+// jsonKeys returns the JSON keys of a struct type
+func jsonKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// UnknownKeys returns config keys that hyprwhspr does not know (usually typos)
+func UnknownKeys(data []byte) []string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+
+	var unknown []string
+	known := jsonKeys(reflect.TypeOf(Config{}))
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	// Profile keys
+	profileKeys := jsonKeys(reflect.TypeOf(Profile{}))
+	for _, field := range []string{"app_profiles", "profiles"} {
+		var profiles map[string]map[string]json.RawMessage
+		if json.Unmarshal(raw[field], &profiles) != nil {
+			continue
+		}
+		for name, profile := range profiles {
+			for key := range profile {
+				if !profileKeys[key] {
+					unknown = append(unknown, fmt.Sprintf("%s.%s.%s", field, name, key))
+				}
+			}
+		}
+	}
+
+	sort.Strings(unknown)
+	return unknown
+}
+
+// expandHome replaces a leading "~/" with the home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// Validate checks value ranges and referenced files
+func (c *Config) Validate() []Issue {
+	var issues []Issue
+	fail := func(field, format string, args ...interface{}) {
+		issues = append(issues, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(field, format string, args ...interface{}) {
+		issues = append(issues, Issue{Field: field, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+	inRange := func(field string, value, min, max float64) {
+		if value < min || value > max {
+			fail(field, "%g is out of range (%g-%g)", value, min, max)
+		}
+	}
+
+	// Model
+	if c.Model == "" {
+		fail("model", "no model configured")
+	} else {
+		modelPath := filepath.Join(c.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", c.Model))
+		if _, err := os.Stat(modelPath); err != nil {
+			warn("model", "%s is not downloaded (run: hyprwhspr download %s)", modelPath, c.Model)
+		}
+	}
+	for _, model := range c.ResidentModels {
+		modelPath := filepath.Join(c.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", model))
+		if _, err := os.Stat(modelPath); err != nil {
+			warn("resident_models", "%s is not downloaded (run: hyprwhspr download %s)", modelPath, model)
+		}
+	}
+	for mode, model := range c.ModeModels {
+		if model != c.Model && !contains(c.ResidentModels, model) {
+			fail("mode_models."+mode, "model %q is neither model nor one of resident_models", model)
+		}
+	}
+	if info, err := os.Stat(c.WhisperModelDir); err != nil || !info.IsDir() {
+		warn("whisper_model_dir", "directory %s does not exist", c.WhisperModelDir)
+	}
+	if c.Threads < 1 {
+		fail("threads", "must be at least 1")
+	}
+	if c.CaptureChannels < 0 {
+		fail("capture_channels", "must not be negative")
+	}
+	if c.CaptureChannel < 0 {
+		fail("capture_channel", "must not be negative")
+	}
+	if c.RecordingBufferMinutes < 0 {
+		fail("recording_buffer_minutes", "must not be negative")
+	}
+	if c.PreRollMs < 0 || c.PreRollMs > 5000 {
+		fail("pre_roll_ms", "%d is out of range (0-5000)", c.PreRollMs)
+	}
+	if c.CaptureChannel > 0 && c.CaptureChannels == 1 {
+		warn("capture_channel", "has no effect with capture_channels 1, set capture_channels to the device's channel count (or 0)")
+	}
+	if c.SampleRate != 16000 {
+		warn("sample_rate", "whisper expects 16000 Hz audio, %d will produce poor transcriptions", c.SampleRate)
+	}
+
+	// Named profiles
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			fail("profile", "unknown profile %q (available: %s)", c.Profile, strings.Join(c.ProfileNames(), ", "))
+		}
+	}
+	for name, profile := range c.Profiles {
+		if profile.Model != nil {
+			modelPath := filepath.Join(c.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", *profile.Model))
+			if _, err := os.Stat(modelPath); err != nil {
+				warn("profiles."+name+".model", "%s is not downloaded (run: hyprwhspr download %s)", modelPath, *profile.Model)
+			}
+		}
+		if profile.VADVoiceThreshold != nil {
+			inRange("profiles."+name+".vad_voice_threshold", *profile.VADVoiceThreshold, 0, 1)
+		}
+		if profile.PromptPreset != nil && *profile.PromptPreset != "" {
+			if _, ok := c.PresetPrompt(*profile.PromptPreset); !ok {
+				fail("profiles."+name+".prompt_preset", "unknown preset %q", *profile.PromptPreset)
+			}
+		}
+	}
+	for class, profile := range c.AppProfiles {
+		if profile.Model != nil || profile.VoiceActivityDetection != nil && *profile.VoiceActivityDetection {
+			warn("app_profiles."+class, "model and enabling voice_activity_detection only work in named profiles")
+		}
+	}
+
+	// Prompt presets
+	if c.PromptPreset != "" {
+		if _, ok := c.PresetPrompt(c.PromptPreset); !ok {
+			fail("prompt_preset", "unknown preset %q (available: %s)", c.PromptPreset, strings.Join(c.PresetNames(), ", "))
+		}
+	}
+	if len(c.AllowedLanguages) > 0 && c.Language == nil {
+		for language := range c.WhisperPrompt.Languages {
+			if !contains(c.AllowedLanguages, language) {
+				warn("whisper_prompt."+language, "%q is not in allowed_languages, the prompt is never used", language)
+			}
+		}
+	}
+	for class, profile := range c.AppProfiles {
+		if profile.PromptPreset != nil && *profile.PromptPreset != "" {
+			if _, ok := c.PresetPrompt(*profile.PromptPreset); !ok {
+				fail("app_profiles."+class+".prompt_preset", "unknown preset %q (available: %s)", *profile.PromptPreset, strings.Join(c.PresetNames(), ", "))
+			}
+		}
+	}
+
+	// Vocabulary
+	for i, term := range c.Vocabulary {
+		if strings.TrimSpace(term) == "" {
+			warn(fmt.Sprintf("vocabulary[%d]", i), "empty term is ignored")
+		}
+	}
+	// Whisper keeps only the last ~224 tokens of the prompt, about 4 characters each
+	if _, ok := c.PresetPrompt(c.PromptPreset); len(c.Vocabulary) > 0 && (c.PromptPreset == "" || ok) {
+		if prompt := c.Prompt(); len(prompt) > 900 {
+			warn("vocabulary", "prompt is %d characters long with the vocabulary, whisper drops the beginning of long prompts", len(prompt))
+		}
+	}
+
+	// Triggers
+	for i, trigger := range c.Triggers {
+		field := fmt.Sprintf("triggers[%d]", i)
+		if !contains(TriggerTypes, trigger.Type) {
+			fail(field+".type", "unknown trigger type %q (available: %s)", trigger.Type, strings.Join(TriggerTypes, ", "))
+		}
+		if trigger.Mode != "" && trigger.Mode != "hold" && trigger.Mode != "toggle" {
+			fail(field+".mode", "must be \"hold\" or \"toggle\"")
+		}
+		if trigger.Type == "evdev" {
+			if trigger.Key == "" {
+				fail(field+".key", "an evdev trigger needs a key")
+			}
+			if _, err := os.Stat(trigger.Device); err != nil {
+				fail(field+".device", "input device %q does not exist", trigger.Device)
+			}
+		}
+	}
+
+	// Grammars
+	for name, path := range c.Grammars {
+		if name == "" || name == "none" || strings.ContainsAny(name, " \t") {
+			fail("grammars."+name, "invalid mode name %q", name)
+		}
+		if _, err := os.Stat(expandHome(path)); err != nil {
+			fail("grammars."+name, "grammar file %s does not exist", path)
+		}
+	}
+	if c.GrammarPenalty <= 0 {
+		fail("grammar_penalty", "must be greater than 0")
+	}
+
+	// Sounds
+	inRange("start_sound_volume", c.StartSoundVolume, 0, 1)
+	inRange("stop_sound_volume", c.StopSoundVolume, 0, 1)
+	inRange("warning_sound_volume", c.WarningSoundVolume, 0, 1)
+	for field, path := range map[string]*string{
+		"start_sound_path":   c.StartSoundPath,
+		"stop_sound_path":    c.StopSoundPath,
+		"warning_sound_path": c.WarningSoundPath,
+	} {
+		if path != nil && filepath.IsAbs(*path) {
+			if _, err := os.Stat(*path); err != nil {
+				warn(field, "%s does not exist, the default sound is used", *path)
+			}
+		}
+	}
+
+	if c.SoundTheme != "" && !filepath.IsAbs(c.SoundTheme) && strings.ContainsRune(c.SoundTheme, '/') {
+		fail("sound_theme", "must be a theme name or an absolute path")
+	}
+
+	// Commands
+	checkScript := func(field string, cmd Command) {
+		if actions := cmd.Actions(); len(actions) != 1 {
+			fail(field, "needs exactly one of exec, open, launch, keys, type or dispatch (has %d)", len(actions))
+			return
+		}
+		if cmd.Exec == "" {
+			return
+		}
+		path := cmd.Exec
+		if !cmd.Plain {
+			// The program of a command line, found in $PATH if it has no slash
+			fields := strings.Fields(path)
+			if len(fields) == 0 {
+				fail(field+".exec", "no command line")
+				return
+			}
+			path = fields[0]
+			if !strings.ContainsRune(path, '/') {
+				if _, err := exec.LookPath(path); err != nil {
+					fail(field+".exec", "%s is not in $PATH", path)
+				}
+				return
+			}
+		}
+		info, err := os.Stat(expandHome(path))
+		switch {
+		case err != nil:
+			fail(field, "script %s does not exist", path)
+		case info.Mode()&0111 == 0:
+			fail(field, "script %s is not executable (chmod +x %s)", path, path)
+		}
+	}
+	for word, cmd := range c.Commands {
+		checkScript("commands."+word, cmd)
+	}
+	for class, commands := range c.AppCommands {
+		for word, cmd := range commands {
+			checkScript(fmt.Sprintf("app_commands.%s.%s", class, word), cmd)
+		}
+	}
+	checkAliases := func(field string, commands map[string]Command) {
+		owner := make(map[string]string) // phrase -> command word
+		for word := range commands {
+			owner[strings.ToLower(word)] = word
+		}
+		for word, cmd := range commands {
+			for _, alias := range cmd.Aliases {
+				phrase := strings.ToLower(strings.Join(strings.Fields(alias), " "))
+				if phrase == "" {
+					fail(field+"."+word+".aliases", "empty alias")
+					continue
+				}
+				if other, ok := owner[phrase]; ok && other != word {
+					warn(field+"."+word+".aliases", "%q also triggers %q", alias, other)
+					continue
+				}
+				owner[phrase] = word
+			}
+		}
+	}
+	checkAliases("commands", c.Commands)
+	for class, commands := range c.AppCommands {
+		checkAliases("app_commands."+class, commands)
+	}
+	for word, feedback := range c.CommandFeedback {
+		field := "command_feedback." + word
+		_, exists := c.Commands[word]
+		for _, commands := range c.AppCommands {
+			if _, ok := commands[word]; ok {
+				exists = true
+			}
+		}
+		if !exists {
+			warn(field, "%q is not a configured command", word)
+		}
+		for key, sound := range map[string]string{"sound": feedback.Sound, "recognized_sound": feedback.RecognizedSound} {
+			if sound == "" {
+				continue
+			}
+			if _, err := os.Stat(expandHome(sound)); err != nil {
+				fail(field+"."+key, "sound %s does not exist", sound)
+			} else if !strings.HasSuffix(strings.ToLower(sound), ".ogg") {
+				fail(field+"."+key, "must be an .ogg file")
+			}
+		}
+		if (feedback.Say != "" || feedback.SayError != "") && strings.TrimSpace(c.TTSCommand) == "" {
+			fail("tts_command", "must not be empty, %s speaks a confirmation", field)
+		}
+	}
+
+	switch c.Readback {
+	case "", "before", "after":
+	default:
+		fail("readback", "must be \"before\", \"after\" or empty, got %q", c.Readback)
+	}
+	if (c.Readback != "" || c.ReadbackPhrase != "") && strings.TrimSpace(c.TTSCommand) == "" {
+		fail("tts_command", "must not be empty, readback speaks transcriptions")
+	}
+
+	// Injection
+	if c.PasteShortcut == "" {
+		fail("paste_shortcut", "must not be empty (e.g. \"shift+Insert\")")
+	}
+	if c.VerifyInjection && c.InjectionVerifyTimeoutMs <= 0 {
+		fail("injection_verify_timeout_ms", "must be positive")
+	}
+	for class, profile := range c.AppProfiles {
+		if _, err := filepath.Match(class, ""); err != nil {
+			fail("app_profiles."+class, "invalid window class pattern")
+		}
+		if profile.PasteShortcut != nil && *profile.PasteShortcut == "" {
+			fail("app_profiles."+class+".paste_shortcut", "must not be empty")
+		}
+	}
+	if c.MaxInjectChars < 0 {
+		fail("max_inject_chars", "must not be negative")
+	}
+	if c.InjectChunkChars < 0 {
+		fail("inject_chunk_chars", "must not be negative")
+	}
+	if c.InjectChunkDelayMs < 0 {
+		fail("inject_chunk_delay_ms", "must not be negative")
+	}
+	switch c.OversizeAction {
+	case "confirm", "truncate", "clipboard":
+	default:
+		fail("oversize_action", "must be \"confirm\", \"truncate\" or \"clipboard\", got %q", c.OversizeAction)
+	}
+	if !contains(InjectionBackends, c.InjectionBackend) {
+		fail("injection_backend", "unknown backend %q (available: %s)", c.InjectionBackend, strings.Join(InjectionBackends, ", "))
+	}
+	checkInjectionMode := func(field, mode string) {
+		if !contains(InjectionModes, mode) {
+			fail(field, "unknown mode %q (available: %s)", mode, strings.Join(InjectionModes, ", "))
+		}
+	}
+	checkInjectionMode("injection_mode", c.InjectionMode)
+	if c.InjectionMode == "file" && c.OutputFile == "" {
+		fail("output_file", "injection_mode \"file\" needs a file")
+	}
+	for name, profile := range c.Profiles {
+		if profile.InjectionMode != nil {
+			checkInjectionMode("profiles."+name+".injection_mode", *profile.InjectionMode)
+		}
+	}
+	for class, profile := range c.AppProfiles {
+		if profile.InjectionMode != nil {
+			checkInjectionMode("app_profiles."+class+".injection_mode", *profile.InjectionMode)
+		}
+	}
+	checkPreview := func(field, mode string) {
+		if !contains(PreviewModes, mode) {
+			fail(field, "unknown mode %q (available: %s)", mode, strings.Join(PreviewModes, ", "))
+		}
+	}
+	checkPreview("preview", c.Preview)
+	for name, profile := range c.Profiles {
+		if profile.Preview != nil {
+			checkPreview("profiles."+name+".preview", *profile.Preview)
+		}
+	}
+	for class, profile := range c.AppProfiles {
+		if profile.Preview != nil {
+			checkPreview("app_profiles."+class+".preview", *profile.Preview)
+		}
+	}
+	if !contains(PreviewMenus, c.PreviewMenu) {
+		fail("preview_menu", "unknown launcher %q (available: %s)", c.PreviewMenu, strings.Join(PreviewMenus, ", "))
+	}
+	if c.PreviewTimeoutSeconds < 1 {
+		fail("preview_timeout_seconds", "must be at least 1")
+	}
+	checkSuffix := func(field, suffix string) {
+		if !contains(InjectSuffixes, suffix) {
+			fail(field, "must be \"space\", \"newline\", \"enter\" or empty, got %q", suffix)
+		}
+	}
+	checkSuffix("inject_suffix", c.InjectSuffix)
+	for name, profile := range c.Profiles {
+		if profile.InjectSuffix != nil {
+			checkSuffix("profiles."+name+".inject_suffix", *profile.InjectSuffix)
+		}
+	}
+	for class, profile := range c.AppProfiles {
+		if profile.InjectSuffix != nil {
+			checkSuffix("app_profiles."+class+".inject_suffix", *profile.InjectSuffix)
+		}
+	}
+
+	// Transcription quality
+	inRange("low_confidence_threshold", c.LowConfidenceThreshold, 0, 1)
+	inRange("command_fuzzy_threshold", c.CommandFuzzyThreshold, 0, 1)
+
+	// LLM
+	if c.LLMEnabled {
+		if c.LLMProvider != "ollama" && c.LLMProvider != "openai" {
+			fail("llm_provider", "must be \"ollama\" or \"openai\", not %q", c.LLMProvider)
+		}
+		if c.LLMModel == "" {
+			fail("llm_model", "no model configured")
+		}
+		if c.LLMProvider == "openai" && c.LLMAPIKey == "" && os.Getenv("OPENAI_API_KEY") == "" && c.LLMEndpoint == "" {
+			warn("llm_api_key", "no API key configured and $OPENAI_API_KEY is not set")
+		}
+	}
+
+	// Recording
+	if c.StreamingInjection && c.StreamingIntervalMs < 500 {
+		fail("streaming_interval_ms", "must be at least 500")
+	}
+	for _, seconds := range c.RecordingWarningSeconds {
+		if seconds <= 0 {
+			fail("recording_warning_seconds", "%d is not a positive number of seconds", seconds)
+		}
+	}
+	if c.DeadMicSeconds < 0 {
+		fail("dead_mic_seconds", "must not be negative")
+	}
+	if c.DeadMicSeconds > 0 && (c.DeadMicThresholdDB >= 0 || c.DeadMicThresholdDB < -100) {
+		fail("dead_mic_threshold_db", "must be between -100 and 0 dBFS, got %g", c.DeadMicThresholdDB)
+	}
+	if c.LevelEventsMs < 0 {
+		fail("level_events_ms", "must not be negative")
+	} else if c.LevelEventsMs > 0 && c.LevelEventsMs < 20 {
+		fail("level_events_ms", "must be at least 20 (or 0 to disable)")
+	}
+	if c.MaxRecordingSeconds < 0 {
+		fail("max_recording_seconds", "must not be negative")
+	} else if c.MaxRecordingSeconds > 0 && c.RecordingBufferMinutes > 0 && c.MaxRecordingSeconds > c.RecordingBufferMinutes*60 {
+		warn("max_recording_seconds", "is longer than recording_buffer_minutes, the start of long recordings is discarded before the limit is reached")
+	}
+	if c.WatchdogFactor < 0 {
+		fail("watchdog_factor", "must not be negative")
+	}
+	if c.ProcessingNice < 0 || c.ProcessingNice > 19 {
+		fail("processing_nice", "%d is out of range (0-19)", c.ProcessingNice)
+	}
+	if c.ProcessingIOClass != "" && c.ProcessingIOClass != "idle" && c.ProcessingIOClass != "best-effort" {
+		fail("processing_io_class", "must be \"idle\", \"best-effort\" or empty, not %q", c.ProcessingIOClass)
+	}
+	if c.ProcessingMaxProcs < 0 {
+		fail("processing_max_procs", "must not be negative")
+	}
+	if c.PressDebounceMs < 0 || c.PressDebounceMs > 2000 {
+		fail("press_debounce_ms", "must be between 0 and 2000")
+	}
+	if c.HoldMinMs < 0 || c.HoldMinMs > 5000 {
+		fail("hold_min_ms", "must be between 0 and 5000")
+	}
+	if c.ResultStateSeconds < 0 {
+		fail("result_state_seconds", "must not be negative")
+	}
+	if !contains(OverlayPositions, c.OverlayPosition) {
+		fail("overlay_position", "unknown position %q (available: %s)", c.OverlayPosition, strings.Join(OverlayPositions, ", "))
+	}
+	if c.IdleTimeoutMinutes < 0 {
+		fail("idle_timeout_minutes", "must not be negative")
+	}
+	if c.ModelUnloadMinutes < 0 {
+		fail("model_unload_minutes", "must not be negative")
+	}
+	if c.LanguageCacheSeconds < 0 {
+		fail("language_cache_seconds", "must not be negative")
+	}
+	if c.SegmentLanguageDetection {
+		if len(c.AllowedLanguages) < 2 {
+			warn("segment_language_detection", "needs at least two allowed_languages to choose from")
+		}
+		if !c.VoiceActivityDetection || !contains(c.AudioPipeline, "vad") {
+			warn("segment_language_detection", "needs voice_activity_detection and the \"vad\" stage in audio_pipeline to find the segments")
+		}
+	}
+	checkDecoding := func(prefix string, cfg *Config) {
+		if cfg.BeamSize < 0 || cfg.BeamSize > 16 {
+			fail(prefix+"beam_size", "must be between 0 and 16")
+		}
+		if cfg.BestOf < 0 || cfg.BestOf > 16 {
+			fail(prefix+"best_of", "must be between 0 and 16")
+		}
+		inRange(prefix+"temperature", cfg.Temperature, 0, 1)
+		inRange(prefix+"temperature_inc", cfg.TemperatureInc, 0, 1)
+		if cfg.EntropyThold < 0 {
+			fail(prefix+"entropy_thold", "must not be negative")
+		}
+		if cfg.LogprobThold > 0 {
+			fail(prefix+"logprob_thold", "must not be positive, log probabilities are at most 0")
+		}
+	}
+	checkDecoding("", c)
+	if c.GPUDevice < 0 {
+		fail("gpu_device", "must not be negative")
+	}
+	inRange("no_speech_threshold", c.NoSpeechThreshold, 0, 1)
+	if c.HallucinationMaxSeconds < 0 {
+		fail("hallucination_max_seconds", "must not be negative")
+	}
+	inRange("hallucination_quiet_db", c.HallucinationQuietDB, -100, 0)
+	for model := range c.ModelDecoding {
+		checkDecoding("model_decoding."+model+".", c.ForModel(model))
+	}
+	if !contains(EnglishModelModes, c.EnglishModel) {
+		fail("english_model", "unknown mode %q (available: %s)", c.EnglishModel, strings.Join(EnglishModelModes, ", "))
+	}
+	if c.ArchiveMaxCount < 0 {
+		fail("archive_max_count", "must not be negative")
+	}
+	if c.ArchiveMaxSizeMB < 0 {
+		fail("archive_max_size_mb", "must not be negative")
+	}
+
+	// Echo cancellation
+	if c.EchoCancellation {
+		if c.AECFilterLength < 512 || c.AECFilterLength > 2048 {
+			fail("aec_filter_length", "%d is out of range (512-2048)", c.AECFilterLength)
+		}
+		inRange("aec_step_size", c.AECStepSize, 0.01, 0.1)
+		inRange("aec_echo_suppression", c.AECEchoSuppression, 0, 1)
+		if c.AECBackend != "" && c.AECBackend != "nlms" && c.AECBackend != "speex" {
+			fail("aec_backend", "unknown backend %q (nlms, speex)", c.AECBackend)
+		}
+	}
+
+	// Audio pre-processing
+	checkPipeline := func(field string, stages []string) {
+		for _, stage := range stages {
+			if !contains(AudioStages, stage) {
+				fail(field, "unknown stage %q (available: %s)", stage, strings.Join(AudioStages, ", "))
+			}
+		}
+	}
+	checkPipeline("audio_pipeline", c.AudioPipeline)
+	for name, profile := range c.Profiles {
+		checkPipeline("profiles."+name+".audio_pipeline", profile.AudioPipeline)
+	}
+	for class, profile := range c.AppProfiles {
+		checkPipeline("app_profiles."+class+".audio_pipeline", profile.AudioPipeline)
+	}
+	if contains(c.AudioPipeline, "highpass") {
+		inRange("highpass_cutoff_hz", c.HighPassCutoffHz, 20, 500)
+	}
+	if contains(c.AudioPipeline, "denoise") {
+		inRange("denoise_strength", c.DenoiseStrength, 0, 3)
+		if c.DenoiseBackend != "" && c.DenoiseBackend != "spectral" && c.DenoiseBackend != "rnnoise" {
+			fail("denoise_backend", "unknown backend %q (spectral, rnnoise)", c.DenoiseBackend)
+		}
+	}
+	if contains(c.AudioPipeline, "agc") && c.AGCMaxGain < 1 {
+		fail("agc_max_gain", "must be at least 1")
+	}
+
+	// Voice activity detection
+	if c.VoiceActivityDetection {
+		inRange("vad_energy_threshold", c.VADEnergyThreshold, 0, 1)
+		inRange("vad_voice_threshold", c.VADVoiceThreshold, 0, 1)
+	}
+	switch c.VADEngine {
+	case "energy":
+	case "silero":
+		modelPath := filepath.Join(c.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", c.VADModel))
+		if _, err := os.Stat(modelPath); err != nil {
+			warn("vad_model", "%s is not downloaded, the energy detector is used (run: hyprwhspr download %s)", modelPath, c.VADModel)
+		}
+	default:
+		fail("vad_engine", "must be \"energy\" or \"silero\", not %q", c.VADEngine)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Field < issues[j].Field })
+	return issues
+}
@@ -0,0 +1,267 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pa/hyprwhspr/internal/audio"
+	"github.com/pa/hyprwhspr/internal/config"
+	"github.com/pa/hyprwhspr/internal/models"
+	"github.com/pa/hyprwhspr/internal/whisper"
+)
+
+// Result of a check
+const (
+	Pass = "pass"
+	Warn = "warn" // Works, but not as well as it could
+	Fail = "fail"
+)
+
+// micTestDuration is how long the microphone is recorded to see it delivers audio
+const micTestDuration = 500 * time.Millisecond
+
+// Check is the outcome of one diagnostic
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // Pass, Warn or Fail
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"` // How to fix it
+}
+
+// Run checks everything dictation depends on: the injection tools, the
+// microphone, the model files, the daemon socket, CUDA and whisper.cpp
+func Run(cfg *config.Config) []Check {
+	var checks []Check
+	checks = append(checks, checkInjection(cfg)...)
+	checks = append(checks, checkMicrophone(cfg))
+	checks = append(checks, checkModels(cfg)...)
+	checks = append(checks, checkSocket(cfg.SocketPath))
+	checks = append(checks, checkCUDA())
+	checks = append(checks, Check{Name: "whisper.cpp", Status: Pass, Detail: whisper.Version() + " (" + whisper.SystemInfo() + ")"})
+	return checks
+}
+
+// Failed reports whether any check failed
+func Failed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInjection looks for wl-clipboard and the tool pressing the keys. wl-clipboard
+// is only required when text goes through the clipboard: not when it is typed,
+// written to a file, or injected with uinput, which types unless the mode is "clipboard".
+func checkInjection(cfg *config.Config) []Check {
+	var checks []Check
+	clipboardNeeded := cfg.InjectionMode != "type" && cfg.InjectionMode != "file" && cfg.InjectionMode != "none" &&
+		(cfg.InjectionBackend != "uinput" || cfg.InjectionMode == "clipboard")
+	for _, tool := range []string{"wl-copy", "wl-paste"} {
+		path, err := exec.LookPath(tool)
+		switch {
+		case err == nil:
+			checks = append(checks, Check{Name: tool, Status: Pass, Detail: path})
+		case clipboardNeeded:
+			checks = append(checks, Check{Name: tool, Status: Fail, Detail: "not found",
+				Hint: "install wl-clipboard (e.g. 'sudo pacman -S wl-clipboard')"})
+		default:
+			checks = append(checks, Check{Name: tool, Status: Warn, Detail: "not found, not needed to type text",
+				Hint: "install wl-clipboard for the clipboard injection mode (e.g. 'sudo pacman -S wl-clipboard')"})
+		}
+	}
+
+	switch cfg.InjectionBackend {
+	case "portal", "uinput":
+		checks = append(checks, Check{Name: "keys", Status: Pass, Detail: "pressed with " + cfg.InjectionBackend})
+	case "ydotool":
+		checks = append(checks, checkTool("ydotool", "install ydotool and start ydotoold"))
+	case "wtype":
+		checks = append(checks, checkTool("wtype", "install wtype (e.g. 'sudo pacman -S wtype')"))
+	default:
+		c := checkTool("wtype", "install wtype (e.g. 'sudo pacman -S wtype')")
+		if c.Status == Fail {
+			if _, err := exec.LookPath("ydotool"); err == nil {
+				c = Check{Name: "wtype", Status: Warn, Detail: "not found, keys are pressed with ydotool",
+					Hint: "install wtype, it needs no daemon"}
+			}
+		}
+		checks = append(checks, c)
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		checks = append(checks, Check{Name: "wayland", Status: Warn, Detail: "WAYLAND_DISPLAY is not set",
+			Hint: "run hyprwhspr inside your Hyprland session, wl-copy and wtype need it"})
+	}
+	return checks
+}
+
+// checkTool looks for a command in PATH
+func checkTool(name, hint string) Check {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return Check{Name: name, Status: Fail, Detail: "not found", Hint: hint}
+	}
+	return Check{Name: name, Status: Pass, Detail: path}
+}
+
+// checkMicrophone records a moment from the configured capture device
+func checkMicrophone(cfg *config.Config) Check {
+	c := Check{Name: "microphone"}
+	devices, err := audio.ListCaptureDevices()
+	if err != nil || len(devices) == 0 {
+		c.Status, c.Detail = Fail, "no capture devices found"
+		if err != nil {
+			c.Detail = err.Error()
+		}
+		c.Hint = "check that PipeWire or PulseAudio is running and a microphone is connected"
+		return c
+	}
+
+	name := "system default"
+	if cfg.AudioDevice != nil && *cfg.AudioDevice != "" {
+		dev, err := audio.MatchDevice(devices, *cfg.AudioDevice)
+		if err != nil {
+			c.Status, c.Detail = Fail, err.Error()
+			c.Hint = "pick one of 'hyprwhspr devices' with 'hyprwhspr device <index>'"
+			return c
+		}
+		name = dev.Name
+	}
+
+	recorder, err := audio.NewRecorder(cfg.SampleRate, cfg.AudioDevice)
+	if err != nil {
+		c.Status, c.Detail = Fail, err.Error()
+		return c
+	}
+	defer recorder.Close()
+	recorder.SetChannels(cfg.CaptureChannels, cfg.CaptureChannel)
+	if err := recorder.Start(); err != nil {
+		c.Status, c.Detail = Fail, fmt.Sprintf("%s: %v", name, err)
+		c.Hint = "close programs holding the device exclusively, or pick another with 'hyprwhspr device'"
+		return c
+	}
+	time.Sleep(micTestDuration)
+	samples, err := recorder.Stop()
+	switch {
+	case err != nil:
+		c.Status, c.Detail = Fail, fmt.Sprintf("%s: %v", name, err)
+	case len(samples) == 0:
+		c.Status, c.Detail = Fail, name+" delivers no audio"
+		c.Hint = "check the device in pavucontrol, it may be suspended or in use"
+	default:
+		level := audio.MeasureLevel(samples)
+		c.Status, c.Detail = Pass, fmt.Sprintf("%s (peak %.0f dBFS)", name, level.PeakdB)
+		if level.PeakdB <= -90 {
+			c.Status = Warn
+			c.Hint = "the microphone is silent, check that it isn't muted"
+		}
+	}
+	return c
+}
+
+// checkModels verifies the files of the configured, resident and VAD models
+func checkModels(cfg *config.Config) []Check {
+	manager := models.NewManager(cfg.WhisperModelDir)
+	names := append([]string{cfg.Model}, cfg.ResidentModels...)
+	if cfg.VoiceActivityDetection && cfg.VADEngine == "silero" {
+		names = append(names, cfg.VADModel)
+	}
+
+	var checks []Check
+	seen := map[string]bool{}
+	for _, model := range names {
+		if seen[model] {
+			continue
+		}
+		seen[model] = true
+		c := Check{Name: "model " + model, Status: Pass, Detail: manager.GetModelPath(model)}
+		if !manager.IsModelDownloaded(model) {
+			c.Status, c.Detail = Fail, "not downloaded"
+			c.Hint = "hyprwhspr download " + model
+		} else if err := manager.Verify(model); err != nil {
+			c.Status, c.Detail = Fail, err.Error()
+			c.Hint = fmt.Sprintf("hyprwhspr delete %s && hyprwhspr download %s", model, model)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkSocket connects to the daemon socket
+func checkSocket(path string) Check {
+	c := Check{Name: "socket", Detail: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		c.Status = Warn
+		c.Detail += ": daemon not running"
+		c.Hint = "start it with 'hyprwhspr' (or the systemd service)"
+		if err := dirWritable(filepath.Dir(path)); err != nil {
+			c.Status = Fail
+			c.Detail += ", " + err.Error()
+			c.Hint = "set socket_path to a writable location"
+		}
+		return c
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	switch {
+	case err == nil:
+		conn.Close()
+		c.Status = Pass
+		c.Detail += ": daemon responding"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		c.Status = Warn
+		c.Detail += ": stale socket, no daemon is listening"
+		c.Hint = "left over from a crash, start the daemon and it removes the socket"
+	case errors.Is(err, syscall.EACCES):
+		c.Status = Fail
+		c.Detail += ": permission denied"
+		c.Hint = "the daemon runs as another user, or socket_path points to a shared location"
+	default:
+		c.Status, c.Detail = Fail, err.Error()
+	}
+	return c
+}
+
+// dirWritable reports why files can't be created in dir, nil if they can
+func dirWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".hyprwhspr-doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return nil
+}
+
+// checkCUDA compares how whisper.cpp was built with the GPU of the machine
+func checkCUDA() Check {
+	c := Check{Name: "cuda"}
+	_, nvidiaErr := os.Stat("/dev/nvidiactl")
+	gpu := nvidiaErr == nil
+
+	switch {
+	case whisper.IsCudaEnabled() && !gpu:
+		c.Status, c.Detail = Fail, "built with CUDA, but no NVIDIA GPU or driver found"
+		c.Hint = "load the nvidia driver, or rebuild without CUDA"
+	case whisper.IsCudaEnabled() && !strings.Contains(whisper.SystemInfo(), "CUDA"):
+		c.Status, c.Detail = Warn, "built with CUDA, but whisper.cpp reports no CUDA backend"
+		c.Hint = "rebuild whisper.cpp with 'make clean-all && make'"
+	case whisper.IsCudaEnabled():
+		c.Status, c.Detail = Pass, "enabled"
+	case gpu:
+		c.Status, c.Detail = Warn, "NVIDIA GPU found, but built for the CPU only"
+		c.Hint = "install the CUDA toolkit and rebuild with 'make clean-all && make' for much faster transcription"
+	default:
+		c.Status, c.Detail = Pass, "not used (CPU only)"
+	}
+	return c
+}
@@ -0,0 +1,104 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a single persisted transcription
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Text        string    `json:"text"`
+	DurationMs  int64     `json:"duration_ms"` // Length of the recorded audio
+	Model       string    `json:"model"`
+	Language    string    `json:"language,omitempty"`
+	Profile     string    `json:"profile,omitempty"`    // Named profile active while dictating
+	Confidence  float32   `json:"confidence,omitempty"` // Mean word probability (0-1)
+	WindowClass string    `json:"window_class,omitempty"`
+	WindowTitle string    `json:"window_title,omitempty"`
+	Command     bool      `json:"command,omitempty"` // Executed as a voice command instead of injected
+}
+
+// Store persists transcriptions as JSON lines
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a history store writing to path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the history file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Add appends an entry to the history
+func (s *Store) Add(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the most recent entries matching search (case-insensitive, empty = all),
+// oldest first. limit <= 0 returns all matches.
+func Read(path string, limit int, search string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	search = strings.ToLower(search)
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip corrupt lines (e.g. a partial write)
+		}
+		if search != "" && !strings.Contains(strings.ToLower(entry.Text), search) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
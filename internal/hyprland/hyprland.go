@@ -0,0 +1,142 @@
+package hyprland
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window describes a Hyprland client window
+type Window struct {
+	Address      string `json:"address"`
+	Class        string `json:"class"`
+	Title        string `json:"title"`
+	InitialClass string `json:"initialClass"`
+	InitialTitle string `json:"initialTitle"`
+	PID          int    `json:"pid"`
+	Workspace    struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"workspace"`
+}
+
+// Env returns the window context as environment variables for child processes
+func (w *Window) Env() []string {
+	if w == nil {
+		return nil
+	}
+	return []string{
+		"HYPRWHSPR_WINDOW_CLASS=" + w.Class,
+		"HYPRWHSPR_WINDOW_TITLE=" + w.Title,
+		"HYPRWHSPR_WINDOW_PID=" + strconv.Itoa(w.PID),
+		"HYPRWHSPR_WINDOW_ADDRESS=" + w.Address,
+		"HYPRWHSPR_WORKSPACE=" + w.Workspace.Name,
+	}
+}
+
+// String returns a short human readable description of the window
+func (w *Window) String() string {
+	if w == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s (%s)", w.Class, w.Title)
+}
+
+// MatchesClass returns whether the window's class or initial class equals pattern (case-insensitive)
+func (w *Window) MatchesClass(pattern string) bool {
+	if w == nil || pattern == "" {
+		return false
+	}
+	return strings.EqualFold(w.Class, pattern) || strings.EqualFold(w.InitialClass, pattern)
+}
+
+// IsAvailable returns whether we are running inside a Hyprland session
+func IsAvailable() bool {
+	return os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != ""
+}
+
+// socketPath returns the path of Hyprland's request socket
+func socketPath() (string, error) {
+	signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")
+	if signature == "" {
+		return "", fmt.Errorf("HYPRLAND_INSTANCE_SIGNATURE not set (not running under Hyprland?)")
+	}
+
+	// Hyprland >= 0.40 uses $XDG_RUNTIME_DIR/hypr, older versions /tmp/hypr
+	candidates := []string{}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "hypr", signature, ".socket.sock"))
+	}
+	candidates = append(candidates, filepath.Join("/tmp", "hypr", signature, ".socket.sock"))
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("hyprland socket not found")
+}
+
+// Request sends a raw request (e.g. "j/activewindow") to Hyprland's socket
+func Request(request string) ([]byte, error) {
+	path, err := socketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", path, 500*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to hyprland: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	return io.ReadAll(conn)
+}
+
+// ActiveWindow returns the currently focused window
+func ActiveWindow() (*Window, error) {
+	data, err := Request("j/activewindow")
+	if err != nil {
+		// Fall back to hyprctl if the socket is not reachable
+		data, err = exec.Command("hyprctl", "-j", "activewindow").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query active window: %w", err)
+		}
+	}
+
+	var window Window
+	if err := json.Unmarshal(data, &window); err != nil {
+		return nil, fmt.Errorf("failed to parse active window: %w", err)
+	}
+
+	// Hyprland returns "{}" when no window is focused
+	if window.Address == "" {
+		return nil, nil
+	}
+
+	return &window, nil
+}
+
+// Dispatch runs a dispatcher with its arguments, e.g. "workspace 3"
+func Dispatch(dispatcher string) error {
+	data, err := Request("dispatch " + dispatcher)
+	if err != nil {
+		return err
+	}
+	if reply := strings.TrimSpace(string(data)); reply != "ok" {
+		return fmt.Errorf("dispatch %s: %s", dispatcher, reply)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package inject
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+)
+
+// injectChunked injects a long text in pieces of at most opts.ChunkSize
+// characters with opts.ChunkDelay in between. Some applications drop or
+// reorder characters of a single huge paste; every chunk is copied to the
+// clipboard anew, the user's clipboard is restored after the last one.
+func (inj *Injector) injectChunked(text string, opts Options) error {
+	chunks := splitChunks(text, opts.ChunkSize)
+	// Without keys the text only lands in the clipboard, chunks would replace each other
+	if len(chunks) == 1 || inj.keys == nil {
+		return inj.injectText(text, opts)
+	}
+
+	fmt.Printf("✂️  Injecting %d chars in %d chunks\n", len([]rune(text)), len(chunks))
+	for i, chunk := range chunks {
+		if i > 0 {
+			time.Sleep(opts.ChunkDelay)
+		}
+		if err := inj.injectText(chunk, opts); err != nil {
+			return fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+	}
+	return nil
+}
+
+// splitChunks splits text into chunks of at most size characters, breaking
+// after whitespace where possible so words stay whole. Joined, the chunks are
+// the text again.
+func splitChunks(text string, size int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) <= size {
+		return []string{text}
+	}
+
+	var chunks []string
+	for len(runes) > size {
+		end := size
+		// Break after the last whitespace in the second half of the chunk
+		for i := size; i > size/2; i-- {
+			if unicode.IsSpace(runes[i-1]) {
+				end = i
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:end]))
+		runes = runes[end:]
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
@@ -0,0 +1,208 @@
+package inject
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rajveermalviya/go-wayland/wayland/client"
+	"golang.org/x/sys/unix"
+)
+
+// Just enough of wlr-data-control-unstable-v1 to put a saved clipboard back
+// in every MIME type it was offered in, wl-copy can only offer one. go-wayland
+// ships no binding for it, Hyprland, Sway, KDE and most wlroots-style
+// compositors implement it.
+
+const dataControlInterface = "zwlr_data_control_manager_v1"
+
+// serveClipboard makes content the clipboard selection, offered in all its
+// types. Paste requests are answered in the background until another client
+// takes over the selection, like wl-copy does in its own process.
+func serveClipboard(content *clipboardContent) error {
+	display, err := client.Connect("")
+	if err != nil {
+		return fmt.Errorf("failed to connect to Wayland: %w", err)
+	}
+	ctx := display.Context()
+
+	var seat *client.Seat
+	var manager *dataControlManager
+	registry, err := display.GetRegistry()
+	if err != nil {
+		ctx.Close()
+		return err
+	}
+	registry.SetGlobalHandler(func(e client.RegistryGlobalEvent) {
+		switch {
+		case e.Interface == "wl_seat" && seat == nil:
+			seat = client.NewSeat(ctx)
+			registry.Bind(e.Name, e.Interface, 1, seat)
+		case e.Interface == dataControlInterface:
+			manager = newDataControlManager(ctx)
+			registry.Bind(e.Name, e.Interface, 1, manager)
+		}
+	})
+	if err := waitSync(display); err != nil {
+		ctx.Close()
+		return err
+	}
+	if seat == nil || manager == nil {
+		ctx.Close()
+		return fmt.Errorf("the compositor doesn't support %s", dataControlInterface)
+	}
+
+	source, err := manager.createDataSource()
+	if err != nil {
+		ctx.Close()
+		return err
+	}
+	source.data = content.data
+	for _, mimeType := range content.types {
+		if err := source.offer(mimeType); err != nil {
+			ctx.Close()
+			return err
+		}
+	}
+	device, err := manager.getDataDevice(seat)
+	if err != nil {
+		ctx.Close()
+		return err
+	}
+	if err := device.setSelection(source); err != nil {
+		ctx.Close()
+		return err
+	}
+	if err := waitSync(display); err != nil {
+		ctx.Close()
+		return err
+	}
+	if source.cancelled {
+		ctx.Close()
+		return fmt.Errorf("the compositor refused the clipboard selection")
+	}
+
+	go func() {
+		defer ctx.Close()
+		for !source.cancelled {
+			if err := dispatch(ctx); err != nil {
+				return
+			}
+		}
+		source.request(1) // Destroy
+		device.request(1)
+		manager.request(2)
+	}()
+	return nil
+}
+
+// dispatch reads one event and hands it to its object. Events of objects the
+// compositor created and nothing tracks (the data offers of the current
+// selection) are dropped, client.Context.Dispatch fails on them.
+func dispatch(ctx *client.Context) error {
+	senderID, opcode, fd, data, err := ctx.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if sender, ok := ctx.GetProxy(senderID).(client.Dispatcher); ok {
+		sender.Dispatch(opcode, fd, data)
+	} else if fd != -1 {
+		unix.Close(fd)
+	}
+	return nil
+}
+
+// dataControlManager is the zwlr_data_control_manager_v1 global
+type dataControlManager struct {
+	client.BaseProxy
+}
+
+func newDataControlManager(ctx *client.Context) *dataControlManager {
+	m := &dataControlManager{}
+	ctx.Register(m)
+	return m
+}
+
+// Dispatch implements client.Dispatcher, the global has no events
+func (m *dataControlManager) Dispatch(opcode uint32, fd int, data []byte) {}
+
+func (m *dataControlManager) request(opcode uint32, args ...uint32) error {
+	return request(&m.BaseProxy, opcode, args...)
+}
+
+func (m *dataControlManager) createDataSource() (*dataControlSource, error) {
+	s := &dataControlSource{}
+	m.Context().Register(s)
+	return s, m.request(0, s.ID())
+}
+
+func (m *dataControlManager) getDataDevice(seat *client.Seat) (*dataControlDevice, error) {
+	d := &dataControlDevice{}
+	m.Context().Register(d)
+	return d, m.request(1, d.ID(), seat.ID())
+}
+
+// dataControlSource is a zwlr_data_control_source_v1 offering saved data
+type dataControlSource struct {
+	client.BaseProxy
+	data      map[string][]byte
+	cancelled bool // another client set the selection
+}
+
+func (s *dataControlSource) request(opcode uint32, args ...uint32) error {
+	return request(&s.BaseProxy, opcode, args...)
+}
+
+func (s *dataControlSource) offer(mimeType string) error {
+	length := len(mimeType) + 1
+	msg := make([]byte, 8+4+client.PaddedLen(length))
+	client.PutUint32(msg[0:4], s.ID())
+	client.PutUint32(msg[4:8], uint32(len(msg)<<16)) // Opcode 0
+	client.PutString(msg[8:], mimeType, length)
+	return s.Context().WriteMsg(msg, nil)
+}
+
+// Dispatch implements client.Dispatcher
+func (s *dataControlSource) Dispatch(opcode uint32, fd int, data []byte) {
+	switch opcode {
+	case 0: // send: write the data in the requested type to fd
+		if fd == -1 {
+			return
+		}
+		file := os.NewFile(uintptr(fd), "clipboard")
+		content := s.data[client.String(data[4:])]
+		// A slow reader must not hold up the other events
+		go func() {
+			file.Write(content)
+			file.Close()
+		}()
+	case 1:
+		s.cancelled = true
+	}
+}
+
+// dataControlDevice is a zwlr_data_control_device_v1, the seat's selection
+type dataControlDevice struct {
+	client.BaseProxy
+}
+
+func (d *dataControlDevice) request(opcode uint32, args ...uint32) error {
+	return request(&d.BaseProxy, opcode, args...)
+}
+
+func (d *dataControlDevice) setSelection(source *dataControlSource) error {
+	return d.request(0, source.ID())
+}
+
+// Dispatch implements client.Dispatcher, the offers of other clients are ignored
+func (d *dataControlDevice) Dispatch(opcode uint32, fd int, data []byte) {}
+
+// request sends a request whose arguments are all 32 bit values
+func request(p *client.BaseProxy, opcode uint32, args ...uint32) error {
+	msg := make([]byte, 8+4*len(args))
+	client.PutUint32(msg[0:4], p.ID())
+	client.PutUint32(msg[4:8], uint32(len(msg)<<16)|opcode&0xffff)
+	for i, arg := range args {
+		client.PutUint32(msg[8+4*i:], arg)
+	}
+	return p.Context().WriteMsg(msg, nil)
+}
@@ -3,19 +3,37 @@ package inject
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os/exec"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Injector handles text injection into focused applications
 type Injector struct {
-	wlClipboardAvailable bool // wl-copy/wl-paste availability
+	wlClipboardAvailable bool       // wl-copy/wl-paste availability
+	keys                 keyboard   // nil = no tool to press keys
+	fallbackKeys         []keyboard // other tools to retry a paste with that wasn't confirmed
+
+	mu sync.Mutex // one injection at a time, pastes must not interleave
+
+	restoreMu sync.Mutex
+	restore   *pendingRestore // clipboard restore scheduled after the last paste
 }
 
-// New creates a new text injector
-func New() *Injector {
+// pendingRestore is the user's clipboard waiting to be put back after a paste
+type pendingRestore struct {
+	content *clipboardContent // nil = the clipboard was empty
+}
+
+// New creates a new text injector pressing keys with backend ("auto", "wtype", "ydotool", "portal" or "uinput")
+func New(backend string) *Injector {
+	keys := newKeyboard(backend)
 	return &Injector{
-		wlClipboardAvailable: checkCommand("wl-copy") && checkCommand("wl-paste") && checkCommand("wtype"),
+		wlClipboardAvailable: checkCommand("wl-copy") && checkCommand("wl-paste"),
+		keys:                 keys,
+		fallbackKeys:         fallbackKeyboards(keys),
 	}
 }
 
@@ -25,26 +43,115 @@ func checkCommand(name string) bool {
 	return err == nil
 }
 
+// Options holds per-injection settings
+type Options struct {
+	Mode          string        // "auto" (paste, empty = auto), "clipboard" (copy only), "type" (keyboard), "file" or "none"
+	File          string        // Appended to in mode "file"
+	PasteShortcut string        // Key chord used to paste (e.g. "ctrl+shift+v"), empty = Shift+Insert
+	Verify        bool          // Confirm the paste happened and retry with other backends if not
+	VerifyTimeout time.Duration // How long to wait for the paste to be confirmed
+	ChunkSize     int           // Longer texts are injected in chunks of this many characters (0 = at once)
+	ChunkDelay    time.Duration // Pause between chunks
+	Enter         bool          // Press Enter after the text, e.g. to send a chat message
+}
+
 // Inject injects text into the focused application
-func (inj *Injector) Inject(text string) error {
-	// Smart clipboard with wtype (reliable with all layouts, keeps clipboard clean)
-	if inj.wlClipboardAvailable {
-		return inj.injectViaSmartClipboardWtype(text)
+func (inj *Injector) Inject(text string, opts Options) error {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	switch opts.Mode {
+	case "none":
+		fmt.Println("📝 Injection disabled, text not inserted")
+		return nil
+	case "clipboard":
+		// For apps that react badly to the synthetic paste, the user pastes
+		return inj.copyToClipboard(text)
+	case "file":
+		return appendToFile(opts.File, text)
+	}
+	if err := inj.injectChunked(text, opts); err != nil {
+		return err
+	}
+	if opts.Enter && inj.keys != nil {
+		// Let the app take the paste before sending it off
+		time.Sleep(100 * time.Millisecond)
+		if err := inj.keys.Chord("Return"); err != nil {
+			return fmt.Errorf("failed to press Enter: %w", err)
+		}
+	}
+	return nil
+}
+
+// injectText types or pastes text, whichever the mode and the available tools allow
+func (inj *Injector) injectText(text string, opts Options) error {
+	if opts.Mode == "type" {
+		return inj.typeText(text)
+	}
+
+	// The uinput keyboard needs no external tools, pasting would bring back wl-copy
+	if _, ok := inj.keys.(*uinputKeyboard); ok {
+		return inj.typeText(text)
+	}
+
+	// Smart clipboard with a paste shortcut (reliable with all layouts, keeps clipboard clean)
+	if inj.wlClipboardAvailable && inj.keys != nil {
+		if opts.Verify {
+			return inj.injectVerified(text, opts)
+		}
+		return inj.injectViaSmartClipboard(text, opts.PasteShortcut)
+	}
+
+	// Without wl-clipboard the text can still be typed
+	if inj.keys != nil {
+		return inj.typeText(text)
 	}
 
 	// Fallback: clipboard only (manual paste needed)
 	return inj.copyToClipboard(text)
 }
 
-// injectViaSmartClipboardWtype injects text using smart clipboard with wtype for paste
-func (inj *Injector) injectViaSmartClipboardWtype(text string) error {
-	fmt.Printf("📋 Injecting text via smart clipboard (wtype): %d chars\n", len(text))
+// PressKeys presses a key chord like "ctrl+s" in the focused window
+func (inj *Injector) PressKeys(chord string) error {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if inj.keys == nil {
+		return fmt.Errorf("can't press %s: no keyboard backend available", chord)
+	}
+	return inj.keys.Chord(chord)
+}
+
+// Close releases the keyboard backend (the uinput device, the portal session)
+func (inj *Injector) Close() {
+	if closer, ok := inj.keys.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// typeText types the text as if it was typed on the keyboard. It doesn't
+// touch the clipboard, but is slower than pasting and depends on the keyboard
+// layout for special characters.
+func (inj *Injector) typeText(text string) error {
+	if inj.keys == nil {
+		return fmt.Errorf("can't type: no keyboard backend available (wtype, ydotool, the remote desktop portal or uinput)")
+	}
+	fmt.Printf("⌨️  Typing text with %s: %d chars\n", inj.keys.Name(), len(text))
+	if err := inj.keys.Type(text); err != nil {
+		return err
+	}
+	fmt.Println("✅ Text typed successfully")
+	return nil
+}
+
+// injectViaSmartClipboard injects text using smart clipboard, pasting with the keyboard backend
+func (inj *Injector) injectViaSmartClipboard(text string, pasteShortcut string) error {
+	fmt.Printf("📋 Injecting text via smart clipboard (%s): %d chars\n", inj.keys.Name(), len(text))
 
 	// Save current clipboard content
-	oldClipboard, err := inj.getCurrentClipboard()
+	oldClipboard, err := inj.saveClipboard()
 	if err != nil {
 		fmt.Printf("[WARN] Failed to save current clipboard: %v\n", err)
-		oldClipboard = ""
+		oldClipboard = nil
 	}
 
 	// Copy new text to clipboard
@@ -55,21 +162,56 @@ func (inj *Injector) injectViaSmartClipboardWtype(text string) error {
 	// Wait for clipboard to settle
 	time.Sleep(120 * time.Millisecond)
 
-	// Paste with wtype using Shift+Insert (safer, doesn't conflict with system bindings)
-	pasteCmd := exec.Command("wtype", "-M", "shift", "-k", "Insert", "-m", "shift")
-	if err := pasteCmd.Run(); err != nil {
-		return fmt.Errorf("wtype paste failed: %w", err)
+	// Paste using Shift+Insert by default (safer, doesn't conflict with system bindings)
+	if pasteShortcut == "" {
+		pasteShortcut = "shift+Insert"
+	}
+	if err := inj.keys.Chord(pasteShortcut); err != nil {
+		return fmt.Errorf("paste failed: %w", err)
 	}
 
 	// Schedule clipboard restoration in background
+	inj.restoreClipboardLater(oldClipboard)
+
+	fmt.Println("✅ Text injected successfully (smart clipboard)")
+	return nil
+}
+
+// saveClipboard returns the user's clipboard content. If the restore after the
+// previous paste is still pending it is taken over, the clipboard holds the
+// previously injected text then.
+func (inj *Injector) saveClipboard() (*clipboardContent, error) {
+	inj.restoreMu.Lock()
+	defer inj.restoreMu.Unlock()
+	if pending := inj.restore; pending != nil {
+		inj.restore = nil
+		return pending.content, nil
+	}
+	return inj.getCurrentClipboard()
+}
+
+// restoreClipboardLater restores the previous clipboard content once the paste had time to complete
+func (inj *Injector) restoreClipboardLater(oldClipboard *clipboardContent) {
+	pending := &pendingRestore{content: oldClipboard}
+	inj.restoreMu.Lock()
+	inj.restore = pending
+	inj.restoreMu.Unlock()
+
 	go func() {
 		time.Sleep(500 * time.Millisecond) // Wait 0.5 seconds for paste to complete
 
-		if oldClipboard != "" {
-			if err := inj.copyToClipboard(oldClipboard); err != nil {
+		inj.restoreMu.Lock()
+		defer inj.restoreMu.Unlock()
+		if inj.restore != pending {
+			return // A newer injection took over the restore
+		}
+		inj.restore = nil
+
+		if oldClipboard != nil {
+			if restored, err := inj.restoreClipboard(oldClipboard); err != nil {
 				fmt.Printf("[WARN] Failed to restore clipboard: %v\n", err)
 			} else {
-				fmt.Println("📋 Clipboard restored")
+				fmt.Printf("📋 Clipboard restored (%s)\n", restored)
 			}
 		} else {
 			// Clear clipboard if it was empty before
@@ -81,23 +223,88 @@ func (inj *Injector) injectViaSmartClipboardWtype(text string) error {
 			}
 		}
 	}()
+}
 
-	fmt.Println("✅ Text injected successfully (smart clipboard)")
-	return nil
+// clipboardContent is a saved clipboard selection in every MIME type it was offered in
+type clipboardContent struct {
+	types []string // in the order the source offered them
+	data  map[string][]byte
 }
 
-// getCurrentClipboard retrieves current clipboard content
-func (inj *Injector) getCurrentClipboard() (string, error) {
-	cmd := exec.Command("wl-paste")
-	output, err := cmd.Output()
+// getCurrentClipboard retrieves current clipboard content in all its MIME types, so
+// images and rich text (a copied screenshot, HTML with its plain text) survive the
+// injection. Returns nil if the clipboard is empty.
+func (inj *Injector) getCurrentClipboard() (*clipboardContent, error) {
+	output, err := exec.Command("wl-paste", "--list-types").Output()
 	if err != nil {
 		// wl-paste returns exit status 1 when clipboard is empty, which is normal
 		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
-			return "", nil
+			return nil, nil
 		}
-		return "", err
+		return nil, err
+	}
+
+	content := &clipboardContent{data: map[string][]byte{}}
+	for _, mimeType := range strings.Fields(string(output)) {
+		// Skip X11 selection targets that carry no content
+		if mimeType == "TARGETS" || mimeType == "TIMESTAMP" || mimeType == "MULTIPLE" || mimeType == "SAVE_TARGETS" {
+			continue
+		}
+		if _, seen := content.data[mimeType]; seen {
+			continue
+		}
+		data, err := exec.Command("wl-paste", "--no-newline", "--type", mimeType).Output()
+		if err != nil {
+			continue // The source can't provide every type it offers
+		}
+		content.types = append(content.types, mimeType)
+		content.data[mimeType] = data
+	}
+	if len(content.types) == 0 {
+		return nil, nil
 	}
-	return string(output), nil
+	return content, nil
+}
+
+// preferredMimeType picks the type to restore with wl-copy, which can only offer
+// a single type. Images win over text (a copied screenshot usually also offers a
+// text representation), then plain text, then whatever the source offered first.
+func preferredMimeType(types []string) string {
+	for _, t := range types {
+		if strings.HasPrefix(t, "image/") {
+			return t
+		}
+	}
+	for _, t := range []string{"text/plain;charset=utf-8", "text/plain", "UTF8_STRING", "STRING", "TEXT"} {
+		for _, offered := range types {
+			if offered == t {
+				return t
+			}
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// restoreClipboard puts saved content back into the clipboard in all its MIME types.
+// Without the data control protocol only the preferred type is restored with wl-copy.
+// Returns a description of what was restored.
+func (inj *Injector) restoreClipboard(content *clipboardContent) (string, error) {
+	err := serveClipboard(content)
+	if err == nil {
+		return strings.Join(content.types, ", "), nil
+	}
+	fmt.Printf("[WARN] Can't restore every clipboard type (%v), restoring one with wl-copy\n", err)
+
+	mimeType := preferredMimeType(content.types)
+	cmd := exec.Command("wl-copy", "--type", mimeType)
+	cmd.Stdin = bytes.NewReader(content.data[mimeType])
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to restore clipboard (%s): %w", mimeType, err)
+	}
+	return mimeType, nil
 }
 
 // copyToClipboard copies text to clipboard
@@ -113,10 +320,25 @@ func (inj *Injector) copyToClipboard(text string) error {
 	return nil
 }
 
+// Backend names how text is injected: "clipboard+<keys>", the key backend
+// alone when typing, or "clipboard"
+func (inj *Injector) Backend() string {
+	switch {
+	case inj.wlClipboardAvailable && inj.keys != nil:
+		return "clipboard+" + inj.keys.Name()
+	case inj.keys != nil:
+		return inj.keys.Name()
+	default:
+		return "clipboard"
+	}
+}
+
 // GetStatus returns the current injection method
 func (inj *Injector) GetStatus() string {
-	if inj.wlClipboardAvailable {
-		return "✅ Text injection: Smart clipboard (wl-copy/wl-paste + wtype, keeps clipboard clean)"
+	if inj.wlClipboardAvailable && inj.keys != nil {
+		return fmt.Sprintf("✅ Text injection: Smart clipboard (wl-copy/wl-paste + %s, keeps clipboard clean)", inj.keys.Name())
+	} else if inj.keys != nil {
+		return fmt.Sprintf("✅ Text injection: typing with %s (wl-clipboard not installed)", inj.keys.Name())
 	} else {
 		return "⚠️  Text injection: clipboard only (manual paste needed)"
 	}
@@ -0,0 +1,163 @@
+package inject
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keyboard sends the synthetic key presses of an injection
+type keyboard interface {
+	Name() string
+	Chord(chord string) error // Press a key chord like "ctrl+shift+v"
+	Type(text string) error   // Type text as if it was typed on the keyboard
+}
+
+// newKeyboard returns the keyboard of a backend, "auto" prefers wtype and falls
+// back to ydotool, the portal and uinput. Returns nil if the tool isn't available.
+func newKeyboard(backend string) keyboard {
+	switch backend {
+	case "wtype":
+		if checkCommand("wtype") {
+			return wtypeKeyboard{}
+		}
+	case "ydotool":
+		if checkCommand("ydotool") {
+			return ydotoolKeyboard{}
+		}
+	case "portal":
+		if portal := newPortalKeyboard(); portal != nil {
+			return portal
+		}
+	case "uinput":
+		uinput, err := newUinputKeyboard()
+		if err == nil {
+			return uinput
+		}
+		fmt.Printf("⚠️  %v\n", err)
+	default:
+		if checkCommand("wtype") {
+			return wtypeKeyboard{}
+		}
+		if checkCommand("ydotool") {
+			return ydotoolKeyboard{}
+		}
+		if portal := newPortalKeyboard(); portal != nil {
+			return portal
+		}
+		if uinput, err := newUinputKeyboard(); err == nil {
+			return uinput
+		}
+	}
+	return nil
+}
+
+// fallbackKeyboards returns the installed command line tools other than primary,
+// to retry a paste that wasn't confirmed. The portal and uinput need a session
+// or a device of their own and are only used when selected.
+func fallbackKeyboards(primary keyboard) []keyboard {
+	var fallbacks []keyboard
+	for _, k := range []keyboard{wtypeKeyboard{}, ydotoolKeyboard{}} {
+		if primary != nil && k.Name() == primary.Name() || !checkCommand(k.Name()) {
+			continue
+		}
+		fallbacks = append(fallbacks, k)
+	}
+	return fallbacks
+}
+
+// wtypeKeyboard uses wtype, which talks to the compositor's virtual keyboard protocol
+type wtypeKeyboard struct{}
+
+func (wtypeKeyboard) Name() string { return "wtype" }
+
+func (wtypeKeyboard) Chord(chord string) error {
+	if err := exec.Command("wtype", wtypeChordArgs(chord)...).Run(); err != nil {
+		return fmt.Errorf("wtype failed: %w", err)
+	}
+	return nil
+}
+
+func (wtypeKeyboard) Type(text string) error {
+	if err := exec.Command("wtype", "--", text).Run(); err != nil {
+		return fmt.Errorf("wtype failed: %w", err)
+	}
+	return nil
+}
+
+// wtypeChordArgs converts a key chord like "ctrl+shift+v" into wtype arguments
+// (press modifiers, tap the key, release modifiers in reverse order)
+func wtypeChordArgs(chord string) []string {
+	parts := strings.Split(chord, "+")
+	key := strings.TrimSpace(parts[len(parts)-1])
+	modifiers := parts[:len(parts)-1]
+
+	var args []string
+	for _, mod := range modifiers {
+		args = append(args, "-M", strings.ToLower(strings.TrimSpace(mod)))
+	}
+	args = append(args, "-k", key)
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		args = append(args, "-m", strings.ToLower(strings.TrimSpace(modifiers[i])))
+	}
+	return args
+}
+
+// ydotoolKeyboard uses ydotool, which emulates a keyboard through uinput and
+// works regardless of the compositor and in XWayland apps. It needs the
+// ydotoold daemon and access to /dev/uinput.
+type ydotoolKeyboard struct{}
+
+func (ydotoolKeyboard) Name() string { return "ydotool" }
+
+func (ydotoolKeyboard) Chord(chord string) error {
+	args, err := ydotoolChordArgs(chord)
+	if err != nil {
+		return err
+	}
+	if output, err := exec.Command("ydotool", append([]string{"key"}, args...)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("ydotool failed: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (ydotoolKeyboard) Type(text string) error {
+	if output, err := exec.Command("ydotool", "type", "--", text).CombinedOutput(); err != nil {
+		return fmt.Errorf("ydotool failed: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// keyCodes are the Linux input event codes of the keys chords can use
+var keyCodes = map[string]int{
+	"ctrl": 29, "control": 29, "shift": 42, "alt": 56, "super": 125, "logo": 125, "meta": 125,
+	"escape": 1, "minus": 12, "equal": 13, "backspace": 14, "tab": 15, "return": 28, "enter": 28,
+	"space": 57, "insert": 110, "delete": 111, "grave": 41, "leftbrace": 26, "rightbrace": 27,
+	"semicolon": 39, "apostrophe": 40, "backslash": 43, "comma": 51, "dot": 52, "slash": 53,
+	"1": 2, "2": 3, "3": 4, "4": 5, "5": 6, "6": 7, "7": 8, "8": 9, "9": 10, "0": 11,
+	"q": 16, "w": 17, "e": 18, "r": 19, "t": 20, "y": 21, "u": 22, "i": 23, "o": 24, "p": 25,
+	"a": 30, "s": 31, "d": 32, "f": 33, "g": 34, "h": 35, "j": 36, "k": 37, "l": 38,
+	"z": 44, "x": 45, "c": 46, "v": 47, "b": 48, "n": 49, "m": 50,
+}
+
+// ydotoolChordArgs converts a key chord like "ctrl+shift+v" into ydotool key
+// events (code:1 presses, code:0 releases), releasing in reverse order
+func ydotoolChordArgs(chord string) ([]string, error) {
+	var codes []int
+	for _, part := range strings.Split(chord, "+") {
+		code, ok := keyCodes[strings.ToLower(strings.TrimSpace(part))]
+		if !ok {
+			return nil, fmt.Errorf("ydotool: unsupported key %q in %q", part, chord)
+		}
+		codes = append(codes, code)
+	}
+
+	args := make([]string, 0, 2*len(codes))
+	for _, code := range codes {
+		args = append(args, fmt.Sprintf("%d:1", code))
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		args = append(args, fmt.Sprintf("%d:0", codes[i]))
+	}
+	return args, nil
+}
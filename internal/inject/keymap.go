@@ -0,0 +1,286 @@
+package inject
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rajveermalviya/go-wayland/wayland/client"
+	"golang.org/x/sys/unix"
+)
+
+// layoutKey is the key and modifiers typing a character
+type layoutKey struct {
+	code  int // Linux key code
+	shift bool
+	altGr bool // the level 3 modifier, AltGr on most layouts
+}
+
+// layout maps the characters a keyboard layout can type to its keys
+type layout struct {
+	name  string
+	keys  map[rune]layoutKey
+	altGr int // key code of the level 3 modifier, 0 if the layout has none
+}
+
+// usLayout is used when the compositor's keymap can't be read
+var usLayout = buildUSLayout()
+
+func buildUSLayout() *layout {
+	l := &layout{name: "us", keys: map[rune]layoutKey{' ': {code: 57}, '\n': {code: 28}, '\t': {code: 15}}}
+	for c := 'a'; c <= 'z'; c++ {
+		code := keyCodes[string(c)]
+		l.keys[c] = layoutKey{code: code}
+		l.keys[c-'a'+'A'] = layoutKey{code: code, shift: true}
+	}
+	for c := '0'; c <= '9'; c++ {
+		l.keys[c] = layoutKey{code: keyCodes[string(c)]}
+	}
+	// Shifted number row and punctuation keys
+	for i, c := range ")!@#$%^&*(" {
+		l.keys[c] = layoutKey{code: keyCodes[string(rune('0'+i))], shift: true}
+	}
+	for _, k := range []struct {
+		plain, shifted rune
+		code           int
+	}{
+		{'`', '~', 41}, {'-', '_', 12}, {'=', '+', 13}, {'[', '{', 26}, {']', '}', 27},
+		{'\\', '|', 43}, {';', ':', 39}, {'\'', '"', 40}, {',', '<', 51}, {'.', '>', 52}, {'/', '?', 53},
+	} {
+		l.keys[k.plain] = layoutKey{code: k.code}
+		l.keys[k.shifted] = layoutKey{code: k.code, shift: true}
+	}
+	return l
+}
+
+// activeLayout reads the keymap the compositor sends to every client with a
+// keyboard, so characters are typed with the keys of the layout in use.
+// Only the first layout (group) is used.
+func activeLayout() (*layout, error) {
+	display, err := client.Connect("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Wayland: %w", err)
+	}
+	ctx := display.Context()
+	defer ctx.Close()
+
+	var seat *client.Seat
+	registry, err := display.GetRegistry()
+	if err != nil {
+		return nil, err
+	}
+	registry.SetGlobalHandler(func(e client.RegistryGlobalEvent) {
+		if e.Interface == "wl_seat" && seat == nil {
+			seat = client.NewSeat(ctx)
+			registry.Bind(e.Name, e.Interface, 1, seat)
+		}
+	})
+	if err := waitSync(display); err != nil {
+		return nil, err
+	}
+	if seat == nil {
+		return nil, fmt.Errorf("the compositor has no seat")
+	}
+
+	keyboard, err := seat.GetKeyboard()
+	if err != nil {
+		return nil, err
+	}
+	var keymap string
+	var keymapErr error
+	keyboard.SetKeymapHandler(func(e client.KeyboardKeymapEvent) {
+		defer unix.Close(e.Fd)
+		if e.Format != uint32(client.KeyboardKeymapFormatXkbV1) {
+			keymapErr = fmt.Errorf("unsupported keymap format %d", e.Format)
+			return
+		}
+		data, err := unix.Mmap(e.Fd, 0, int(e.Size), unix.PROT_READ, unix.MAP_PRIVATE)
+		if err != nil {
+			keymapErr = fmt.Errorf("failed to map the keymap: %w", err)
+			return
+		}
+		keymap = strings.TrimRight(string(data), "\x00")
+		unix.Munmap(data)
+	})
+	if err := waitSync(display); err != nil {
+		return nil, err
+	}
+	if keymapErr != nil {
+		return nil, keymapErr
+	}
+	if keymap == "" {
+		return nil, fmt.Errorf("the compositor sent no keymap")
+	}
+	return parseKeymap(keymap)
+}
+
+// waitSync waits until the compositor handled all requests sent so far
+func waitSync(display *client.Display) error {
+	callback, err := display.Sync()
+	if err != nil {
+		return err
+	}
+	done := false
+	callback.SetDoneHandler(func(client.CallbackDoneEvent) { done = true })
+	for !done {
+		if err := dispatch(display.Context()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	keycodeLine = regexp.MustCompile(`<(\w+)>\s*=\s*(\d+)\s*;`)
+	symbolsKey  = regexp.MustCompile(`(?s)key\s+<(\w+)>\s*\{(.*?)\};`)
+	keyType     = regexp.MustCompile(`type(?:\[\w+\])?\s*=\s*"(\w+)"`)
+	firstGroup  = regexp.MustCompile(`(?s)^(?:[^\[]*?symbols\[(?:1|Group1)\]\s*=\s*)?\s*\[([^\]]*)\]`)
+	layoutName  = regexp.MustCompile(`name\[(?:1|Group1)\]\s*=\s*"([^"]*)"`)
+)
+
+// parseKeymap reads the first group of an XKB keymap in the text format
+// compositors send (xkb_keymap_get_as_string). Keys of the keypad and keys
+// whose levels aren't selected with Shift and AltGr are left out.
+func parseKeymap(keymap string) (*layout, error) {
+	codesStart := strings.Index(keymap, "xkb_keycodes")
+	symbolsStart := strings.Index(keymap, "xkb_symbols")
+	if codesStart < 0 || symbolsStart < 0 {
+		return nil, fmt.Errorf("keymap has no keycodes or symbols")
+	}
+
+	codes := map[string]int{}
+	codesEnd := strings.Index(keymap[codesStart:], "};")
+	if codesEnd < 0 {
+		codesEnd = len(keymap) - codesStart
+	}
+	for _, m := range keycodeLine.FindAllStringSubmatch(keymap[codesStart:codesStart+codesEnd], -1) {
+		code, _ := strconv.Atoi(m[2])
+		codes[m[1]] = code - 8 // XKB key codes are Linux key codes + 8
+	}
+
+	symbols := keymap[symbolsStart:]
+	l := &layout{name: "unknown", keys: map[rune]layoutKey{}}
+	if m := layoutName.FindStringSubmatch(symbols); m != nil {
+		l.name = m[1]
+	}
+	levels := map[rune]int{} // level each character was found on, lower wins
+	for _, m := range symbolsKey.FindAllStringSubmatch(symbols, -1) {
+		code, ok := codes[m[1]]
+		if !ok || code <= 0 || code > maxKeyCode {
+			continue
+		}
+		body := m[2]
+		maxLevel := 4
+		if t := keyType.FindStringSubmatch(body); t != nil {
+			switch {
+			case t[1] == "ONE_LEVEL":
+				maxLevel = 1
+			case strings.Contains(t[1], "KEYPAD"), strings.HasPrefix(t[1], "PC_"), strings.Contains(t[1], "CTRL"):
+				continue
+			}
+		}
+		group := firstGroup.FindStringSubmatch(body)
+		if group == nil {
+			continue
+		}
+		for i, name := range strings.Split(group[1], ",") {
+			name = strings.TrimSpace(name)
+			if i == 0 && name == "ISO_Level3_Shift" && l.altGr == 0 {
+				l.altGr = code
+			}
+			if i >= maxLevel {
+				break
+			}
+			r, ok := keysymRune(name)
+			if !ok {
+				continue
+			}
+			if level, seen := levels[r]; seen && level <= i {
+				continue
+			}
+			levels[r] = i
+			l.keys[r] = layoutKey{code: code, shift: i%2 == 1, altGr: i >= 2}
+		}
+	}
+
+	// Levels 3 and 4 can't be reached without a level 3 modifier
+	if l.altGr == 0 {
+		for r, key := range l.keys {
+			if key.altGr {
+				delete(l.keys, r)
+			}
+		}
+	}
+	if len(l.keys) == 0 {
+		return nil, fmt.Errorf("keymap has no keys")
+	}
+	l.keys['\n'] = layoutKey{code: 28}
+	l.keys['\t'] = layoutKey{code: 15}
+	return l, nil
+}
+
+// keysymRune returns the character of an XKB keysym name: a name from
+// keysymNames, a single character ("a", "5") or a Unicode keysym ("U20AC")
+func keysymRune(name string) (rune, bool) {
+	if r, ok := keysymNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	if hex, ok := strings.CutPrefix(name, "U"); ok && len(hex) >= 4 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+// keysymNames are the names of the keysyms of printable characters found on
+// common layouts (keysymdef.h), Latin-1 and the Latin letters and punctuation
+// of European layouts
+var keysymNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$', "percent": '%',
+	"ampersand": '&', "apostrophe": '\'', "parenleft": '(', "parenright": ')', "asterisk": '*',
+	"plus": '+', "comma": ',', "minus": '-', "period": '.', "slash": '/', "colon": ':', "semicolon": ';',
+	"less": '<', "equal": '=', "greater": '>', "question": '?', "at": '@', "bracketleft": '[',
+	"backslash": '\\', "bracketright": ']', "asciicircum": '^', "underscore": '_', "grave": '`',
+	"braceleft": '{', "bar": '|', "braceright": '}', "asciitilde": '~',
+
+	"nobreakspace": ' ', "exclamdown": '¡', "cent": '¢', "sterling": '£', "currency": '¤',
+	"yen": '¥', "brokenbar": '¦', "section": '§', "diaeresis": '¨', "copyright": '©',
+	"ordfeminine": 'ª', "guillemotleft": '«', "guillemetleft": '«', "notsign": '¬', "hyphen": '­',
+	"registered": '®', "macron": '¯', "degree": '°', "plusminus": '±', "twosuperior": '²',
+	"threesuperior": '³', "acute": '´', "mu": 'µ', "paragraph": '¶', "periodcentered": '·',
+	"cedilla": '¸', "onesuperior": '¹', "masculine": 'º', "ordmasculine": 'º', "guillemotright": '»',
+	"guillemetright": '»', "onequarter": '¼', "onehalf": '½', "threequarters": '¾', "questiondown": '¿',
+	"Agrave": 'À', "Aacute": 'Á', "Acircumflex": 'Â', "Atilde": 'Ã', "Adiaeresis": 'Ä', "Aring": 'Å',
+	"AE": 'Æ', "Ccedilla": 'Ç', "Egrave": 'È', "Eacute": 'É', "Ecircumflex": 'Ê', "Ediaeresis": 'Ë',
+	"Igrave": 'Ì', "Iacute": 'Í', "Icircumflex": 'Î', "Idiaeresis": 'Ï', "ETH": 'Ð', "Ntilde": 'Ñ',
+	"Ograve": 'Ò', "Oacute": 'Ó', "Ocircumflex": 'Ô', "Otilde": 'Õ', "Odiaeresis": 'Ö', "multiply": '×',
+	"Oslash": 'Ø', "Ooblique": 'Ø', "Ugrave": 'Ù', "Uacute": 'Ú', "Ucircumflex": 'Û', "Udiaeresis": 'Ü',
+	"Yacute": 'Ý', "THORN": 'Þ', "ssharp": 'ß', "agrave": 'à', "aacute": 'á', "acircumflex": 'â',
+	"atilde": 'ã', "adiaeresis": 'ä', "aring": 'å', "ae": 'æ', "ccedilla": 'ç', "egrave": 'è',
+	"eacute": 'é', "ecircumflex": 'ê', "ediaeresis": 'ë', "igrave": 'ì', "iacute": 'í',
+	"icircumflex": 'î', "idiaeresis": 'ï', "eth": 'ð', "ntilde": 'ñ', "ograve": 'ò', "oacute": 'ó',
+	"ocircumflex": 'ô', "otilde": 'õ', "odiaeresis": 'ö', "division": '÷', "oslash": 'ø', "ooblique": 'ø',
+	"ugrave": 'ù', "uacute": 'ú', "ucircumflex": 'û', "udiaeresis": 'ü', "yacute": 'ý', "thorn": 'þ',
+	"ydiaeresis": 'ÿ',
+
+	"Aogonek": 'Ą', "aogonek": 'ą', "Cacute": 'Ć', "cacute": 'ć', "Ccaron": 'Č', "ccaron": 'č',
+	"Dcaron": 'Ď', "dcaron": 'ď', "Eogonek": 'Ę', "eogonek": 'ę', "Ecaron": 'Ě', "ecaron": 'ě',
+	"Gbreve": 'Ğ', "gbreve": 'ğ', "Iabovedot": 'İ', "idotless": 'ı', "Lstroke": 'Ł', "lstroke": 'ł',
+	"Nacute": 'Ń', "nacute": 'ń', "Ncaron": 'Ň', "ncaron": 'ň', "Odoubleacute": 'Ő', "odoubleacute": 'ő',
+	"OE": 'Œ', "oe": 'œ', "Rcaron": 'Ř', "rcaron": 'ř', "Sacute": 'Ś', "sacute": 'ś', "Scedilla": 'Ş',
+	"scedilla": 'ş', "Scaron": 'Š', "scaron": 'š', "Tcaron": 'Ť', "tcaron": 'ť', "Uring": 'Ů',
+	"uring": 'ů', "Udoubleacute": 'Ű', "udoubleacute": 'ű', "Ydiaeresis": 'Ÿ', "Zacute": 'Ź',
+	"zacute": 'ź', "Zabovedot": 'Ż', "zabovedot": 'ż', "Zcaron": 'Ž', "zcaron": 'ž', "Abreve": 'Ă',
+	"abreve": 'ă',
+
+	"EuroSign": '€', "endash": '–', "emdash": '—', "ellipsis": '…', "enfilledcircbullet": '•',
+	"leftsinglequotemark": '‘', "rightsinglequotemark": '’', "singlelowquotemark": '‚',
+	"leftdoublequotemark": '“', "rightdoublequotemark": '”', "doublelowquotemark": '„',
+	"trademark": '™', "dagger": '†', "doubledagger": '‡', "permille": '‰', "leftarrow": '←',
+	"rightarrow": '→', "uparrow": '↑', "downarrow": '↓',
+}
@@ -0,0 +1,221 @@
+package inject
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/pa/hyprwhspr/internal/portal"
+)
+
+// RemoteDesktop portal interface and option values
+const (
+	remoteDesktop             = "org.freedesktop.portal.RemoteDesktop"
+	portalDeviceKeyboard      = uint32(1)
+	portalPersistUntilRevoked = uint32(2)
+)
+
+// portalStartTimeout is how long to wait for the user to allow remote control
+const portalStartTimeout = 2 * time.Minute
+
+// portalKeyboard sends key presses through the RemoteDesktop portal. It works
+// on compositors without the virtual keyboard protocol wtype needs (GNOME,
+// KDE) and inside Flatpak. The first use asks the user for permission, the
+// restore token saved afterwards skips the dialog until it is revoked.
+type portalKeyboard struct {
+	mu      sync.Mutex
+	conn    *portal.Conn
+	session dbus.ObjectPath // empty = no session started yet
+}
+
+// newPortalKeyboard connects to the session bus, returns nil if the portal
+// doesn't offer remote desktop control
+func newPortalKeyboard() *portalKeyboard {
+	conn, err := portal.Connect(remoteDesktop)
+	if err != nil {
+		return nil
+	}
+	return &portalKeyboard{conn: conn}
+}
+
+func (*portalKeyboard) Name() string { return "portal" }
+
+func (p *portalKeyboard) Chord(chord string) error {
+	var keysyms []uint32
+	for _, part := range strings.Split(chord, "+") {
+		keysym, ok := chordKeysym(strings.TrimSpace(part))
+		if !ok {
+			return fmt.Errorf("portal: unsupported key %q in %q", part, chord)
+		}
+		keysyms = append(keysyms, keysym)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.withSession(func() error {
+		for _, keysym := range keysyms {
+			if err := p.key(keysym, true); err != nil {
+				return err
+			}
+		}
+		for i := len(keysyms) - 1; i >= 0; i-- {
+			if err := p.key(keysyms[i], false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *portalKeyboard) Type(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.withSession(func() error {
+		for _, r := range text {
+			keysym := runeKeysym(r)
+			if err := p.key(keysym, true); err != nil {
+				return err
+			}
+			if err := p.key(keysym, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close ends the session by closing the bus connection
+func (p *portalKeyboard) Close() error {
+	return p.conn.Close()
+}
+
+// withSession runs fn in a remote desktop session, starting one if needed.
+// A session the user or compositor closed is started again once.
+func (p *portalKeyboard) withSession(fn func() error) error {
+	retried := false
+	for {
+		if p.session == "" {
+			if err := p.start(); err != nil {
+				return err
+			}
+		}
+		err := fn()
+		if err == nil || retried {
+			return err
+		}
+		p.session = ""
+		retried = true
+	}
+}
+
+// key presses or releases a key
+func (p *portalKeyboard) key(keysym uint32, pressed bool) error {
+	state := uint32(0)
+	if pressed {
+		state = 1
+	}
+	call := p.conn.Object().Call(remoteDesktop+".NotifyKeyboardKeysym", 0,
+		p.session, map[string]dbus.Variant{}, int32(keysym), state)
+	if call.Err != nil {
+		return fmt.Errorf("portal keyboard failed: %w", call.Err)
+	}
+	return nil
+}
+
+// start creates a session with keyboard access, asking the user unless a
+// saved restore token is still valid
+func (p *portalKeyboard) start() error {
+	session, err := p.conn.CreateSession(remoteDesktop)
+	if err != nil {
+		return fmt.Errorf("portal: failed to create session: %w", err)
+	}
+
+	_, err = p.conn.Request(remoteDesktop+".SelectDevices", 10*time.Second, func(options map[string]dbus.Variant) []interface{} {
+		options["types"] = dbus.MakeVariant(portalDeviceKeyboard)
+		options["persist_mode"] = dbus.MakeVariant(portalPersistUntilRevoked)
+		if token := loadRestoreToken(); token != "" {
+			options["restore_token"] = dbus.MakeVariant(token)
+		}
+		return []interface{}{session, options}
+	})
+	if err != nil {
+		return fmt.Errorf("portal: failed to select keyboard: %w", err)
+	}
+
+	fmt.Println("🔐 Waiting for permission to control the keyboard (remote desktop portal)...")
+	results, err := p.conn.Request(remoteDesktop+".Start", portalStartTimeout, func(options map[string]dbus.Variant) []interface{} {
+		return []interface{}{session, "", options}
+	})
+	if err != nil {
+		return fmt.Errorf("portal: remote control not allowed: %w", err)
+	}
+	if devices, _ := results["devices"].Value().(uint32); devices&portalDeviceKeyboard == 0 {
+		return fmt.Errorf("portal: keyboard access not granted")
+	}
+	if token, _ := results["restore_token"].Value().(string); token != "" {
+		if err := saveRestoreToken(token); err != nil {
+			fmt.Printf("⚠️  Failed to save portal restore token: %v\n", err)
+		}
+	}
+
+	p.session = session
+	return nil
+}
+
+// restoreTokenPath is where the portal's permission is remembered
+func restoreTokenPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "share", "hyprwhspr", "portal-restore-token")
+}
+
+func loadRestoreToken() string {
+	data, err := os.ReadFile(restoreTokenPath())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func saveRestoreToken(token string) error {
+	path := restoreTokenPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(token+"\n"), 0600)
+}
+
+// chordKeys are the X11 keysyms of named keys chords can use
+var chordKeys = map[string]uint32{
+	"ctrl": 0xffe3, "control": 0xffe3, "shift": 0xffe1, "alt": 0xffe9, "super": 0xffeb, "logo": 0xffeb, "meta": 0xffeb,
+	"escape": 0xff1b, "backspace": 0xff08, "tab": 0xff09, "return": 0xff0d, "enter": 0xff0d,
+	"space": 0x20, "insert": 0xff63, "delete": 0xffff, "minus": 0x2d, "equal": 0x3d,
+}
+
+// chordKeysym returns the keysym of a chord key ("ctrl", "Insert", "v")
+func chordKeysym(key string) (uint32, bool) {
+	if keysym, ok := chordKeys[strings.ToLower(key)]; ok {
+		return keysym, true
+	}
+	if len(key) == 1 && key[0] > 0x20 && key[0] < 0x7f {
+		return uint32(strings.ToLower(key)[0]), true
+	}
+	return 0, false
+}
+
+// runeKeysym returns the keysym typing a character: Latin-1 characters are
+// their own keysym, everything else uses the Unicode keysym range
+func runeKeysym(r rune) uint32 {
+	switch {
+	case r == '\n':
+		return 0xff0d
+	case r == '\t':
+		return 0xff09
+	case r >= 0x20 && r <= 0x7e, r >= 0xa0 && r <= 0xff:
+		return uint32(r)
+	}
+	return 0x01000000 + uint32(r)
+}
@@ -0,0 +1,65 @@
+package inject
+
+import "sync"
+
+// Queue keeps injections in dictation order. Every dictation takes a ticket when
+// it starts and waits for its turn before injecting, so a transcription that
+// finishes early (a short dictation, a redo) never lands before or in the middle
+// of one that was dictated first.
+type Queue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	issued uint64          // last ticket handed out
+	next   uint64          // ticket whose turn it is
+	done   map[uint64]bool // finished tickets waiting for earlier ones
+}
+
+// NewQueue creates an empty injection queue
+func NewQueue() *Queue {
+	q := &Queue{next: 1, done: make(map[uint64]bool)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Ticket reserves the next place in the queue
+func (q *Queue) Ticket() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.issued++
+	return q.issued
+}
+
+// Wait blocks until every earlier ticket is done. Returns whether it had to wait.
+func (q *Queue) Wait(ticket uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waited := false
+	for q.next < ticket {
+		waited = true
+		q.cond.Wait()
+	}
+	return waited
+}
+
+// Pending returns the number of tickets handed out and not released yet
+func (q *Queue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int(q.issued-q.next+1) - len(q.done)
+}
+
+// Done releases a ticket, whether or not it injected anything. Every ticket
+// must be released or later injections wait forever.
+func (q *Queue) Done(ticket uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if ticket < q.next {
+		return
+	}
+	q.done[ticket] = true
+	for q.done[q.next] {
+		delete(q.done, q.next)
+		q.next++
+	}
+	q.cond.Broadcast()
+}
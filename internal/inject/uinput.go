@@ -0,0 +1,202 @@
+package inject
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// uinput ioctls and event types from linux/uinput.h and linux/input-event-codes.h
+const (
+	uiDevCreate  = 0x5501     // _IO('U', 1)
+	uiDevDestroy = 0x5502     // _IO('U', 2)
+	uiSetEvBit   = 0x40045564 // _IOW('U', 100, int)
+	uiSetKeyBit  = 0x40045565 // _IOW('U', 101, int)
+
+	evSyn     = 0x00
+	evKey     = 0x01
+	synReport = 0
+
+	keyLeftShift = 42
+	maxKeyCode   = 248 // last key of the main keyboard range, KEY_MICMUTE
+)
+
+// uinputKeyDelay paces key presses so applications don't drop any
+const uinputKeyDelay = 2 * time.Millisecond
+
+// uinputUserDev is struct uinput_user_dev, the legacy device setup that
+// works on every kernel
+type uinputUserDev struct {
+	Name         [80]byte
+	Bustype      uint16
+	Vendor       uint16
+	Product      uint16
+	Version      uint16
+	FFEffectsMax uint32
+	AbsMax       [64]int32
+	AbsMin       [64]int32
+	AbsFuzz      [64]int32
+	AbsFlat      [64]int32
+}
+
+// inputEvent is struct input_event
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// uinputKeyboard is a virtual keyboard created through /dev/uinput. It needs
+// no external tools and works with every compositor, but needs write access
+// to /dev/uinput. It sends key codes, not characters, so text is typed with
+// the keys of the compositor's active layout; characters that need dead keys
+// or another layout can't be typed.
+type uinputKeyboard struct {
+	mu     sync.Mutex
+	file   *os.File
+	layout *layout
+}
+
+// newUinputKeyboard creates the virtual keyboard device
+func newUinputKeyboard() (*uinputKeyboard, error) {
+	file, err := os.OpenFile("/dev/uinput", os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("uinput: %w (needs write access to /dev/uinput, e.g. the input group or a udev rule)", err)
+	}
+
+	fd := file.Fd()
+	if err := ioctl(fd, uiSetEvBit, evKey); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("uinput: failed to enable key events: %w", err)
+	}
+	for code := 1; code <= maxKeyCode; code++ {
+		if err := ioctl(fd, uiSetKeyBit, uintptr(code)); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("uinput: failed to enable key %d: %w", code, err)
+		}
+	}
+
+	dev := uinputUserDev{Bustype: 0x06, Vendor: 0x1, Product: 0x1, Version: 1} // BUS_VIRTUAL
+	copy(dev.Name[:], "hyprwhspr virtual keyboard")
+	if err := binary.Write(file, binary.NativeEndian, &dev); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("uinput: failed to set up device: %w", err)
+	}
+	if err := ioctl(fd, uiDevCreate, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("uinput: failed to create device: %w", err)
+	}
+
+	keys, err := activeLayout()
+	if err != nil {
+		fmt.Printf("⚠️  uinput: can't read the keyboard layout (%v), typing with the US layout\n", err)
+		keys = usLayout
+	}
+
+	// The compositor needs a moment to pick up the new device
+	time.Sleep(200 * time.Millisecond)
+	return &uinputKeyboard{file: file, layout: keys}, nil
+}
+
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (*uinputKeyboard) Name() string { return "uinput" }
+
+func (k *uinputKeyboard) Chord(chord string) error {
+	var codes []int
+	for _, part := range strings.Split(chord, "+") {
+		code, ok := keyCodes[strings.ToLower(strings.TrimSpace(part))]
+		if !ok {
+			return fmt.Errorf("uinput: unsupported key %q in %q", part, chord)
+		}
+		codes = append(codes, code)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, code := range codes {
+		if err := k.key(code, true); err != nil {
+			return err
+		}
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		if err := k.key(codes[i], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *uinputKeyboard) Type(text string) error {
+	// Refuse up front rather than typing half of the text
+	for _, r := range text {
+		if _, ok := k.layout.keys[r]; !ok {
+			return fmt.Errorf("uinput can't type %q, it isn't on the %s keyboard layout", r, k.layout.name)
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, r := range text {
+		key := k.layout.keys[r]
+		var modifiers []int
+		if key.shift {
+			modifiers = append(modifiers, keyLeftShift)
+		}
+		if key.altGr {
+			modifiers = append(modifiers, k.layout.altGr)
+		}
+		for _, code := range modifiers {
+			if err := k.key(code, true); err != nil {
+				return err
+			}
+		}
+		if err := k.key(key.code, true); err != nil {
+			return err
+		}
+		if err := k.key(key.code, false); err != nil {
+			return err
+		}
+		for i := len(modifiers) - 1; i >= 0; i-- {
+			if err := k.key(modifiers[i], false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// key presses or releases a key, followed by a sync report
+func (k *uinputKeyboard) key(code int, pressed bool) error {
+	value := int32(0)
+	if pressed {
+		value = 1
+	}
+	events := []inputEvent{
+		{Type: evKey, Code: uint16(code), Value: value},
+		{Type: evSyn, Code: synReport},
+	}
+	if err := binary.Write(k.file, binary.NativeEndian, events); err != nil {
+		return fmt.Errorf("uinput: failed to send key: %w", err)
+	}
+	time.Sleep(uinputKeyDelay)
+	return nil
+}
+
+// Close removes the virtual keyboard
+func (k *uinputKeyboard) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	ioctl(k.file.Fd(), uiDevDestroy, 0)
+	return k.file.Close()
+}
@@ -0,0 +1,111 @@
+package inject
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// injectVerified pastes the text and confirms that the focused application actually
+// read the clipboard. The text is offered with "wl-copy --paste-once", so the wl-copy
+// process exits as soon as somebody pastes it. If the paste is not confirmed in time
+// the paste shortcut is sent again with the next keyboard tool, and finally the text
+// is typed directly. Other shortcuts aren't guessed, in terminals and editors they do
+// something else than pasting (ctrl+v inserts a literal ^V).
+func (inj *Injector) injectVerified(text string, opts Options) error {
+	fmt.Printf("📋 Injecting text with verification: %d chars\n", len(text))
+
+	timeout := opts.VerifyTimeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	oldClipboard, err := inj.saveClipboard()
+	if err != nil {
+		fmt.Printf("[WARN] Failed to save current clipboard: %v\n", err)
+		oldClipboard = nil
+	}
+	defer inj.restoreClipboardLater(oldClipboard)
+
+	shortcut := opts.PasteShortcut
+	if shortcut == "" {
+		shortcut = "shift+Insert"
+	}
+
+	for _, keys := range append([]keyboard{inj.keys}, inj.fallbackKeys...) {
+		confirmed, reliable, err := inj.pasteOnce(text, keys, shortcut, timeout)
+		if err != nil {
+			fmt.Printf("⚠️  Paste via %s with %s failed: %v\n", shortcut, keys.Name(), err)
+			continue
+		}
+		if !reliable {
+			// Something else (usually a clipboard manager) consumed the offer before we pasted,
+			// so paste-once can't tell us anything. Paste normally without confirmation.
+			fmt.Println("⚠️  Clipboard was read before pasting (clipboard manager?) - verification unavailable")
+			if err := inj.copyToClipboard(text); err != nil {
+				return err
+			}
+			time.Sleep(120 * time.Millisecond)
+			if err := keys.Chord(shortcut); err != nil {
+				return fmt.Errorf("paste failed: %w", err)
+			}
+			fmt.Println("✅ Text pasted (unverified)")
+			return nil
+		}
+		if confirmed {
+			fmt.Printf("✅ Text injected and verified (paste via %s with %s)\n", shortcut, keys.Name())
+			return nil
+		}
+		fmt.Printf("⚠️  Paste via %s with %s not confirmed within %v, trying next method\n", shortcut, keys.Name(), timeout)
+	}
+
+	// Last resort: type the text directly (cannot be verified, but does not rely on the clipboard)
+	fmt.Printf("⌨️  Falling back to typing the text with %s\n", inj.keys.Name())
+	if err := inj.keys.Type(text); err != nil {
+		// Leave the text in the clipboard so it can be pasted manually
+		inj.copyToClipboard(text)
+		return fmt.Errorf("injection failed: paste was never confirmed and typing failed: %w", err)
+	}
+	fmt.Printf("✅ Text typed via %s (paste could not be confirmed)\n", inj.keys.Name())
+	return nil
+}
+
+// pasteOnce offers text for a single paste, sends the paste shortcut with keys and waits for the paste.
+// Returns confirmed=true if the clipboard was read after the shortcut was sent, and
+// reliable=false if it was already read before the shortcut was sent.
+func (inj *Injector) pasteOnce(text string, keys keyboard, shortcut string, timeout time.Duration) (confirmed bool, reliable bool, err error) {
+	offer := exec.Command("wl-copy", "--foreground", "--paste-once")
+	offer.Stdin = bytes.NewBufferString(text)
+	if err := offer.Start(); err != nil {
+		return false, true, fmt.Errorf("failed to offer clipboard: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		offer.Wait()
+		close(done)
+	}()
+
+	// Wait for the clipboard to settle
+	select {
+	case <-done:
+		return false, false, nil
+	case <-time.After(120 * time.Millisecond):
+	}
+
+	if err := keys.Chord(shortcut); err != nil {
+		offer.Process.Kill()
+		<-done
+		return false, true, err
+	}
+
+	select {
+	case <-done:
+		return true, true, nil
+	case <-time.After(timeout):
+		offer.Process.Kill()
+		<-done
+		return false, true, nil
+	}
+}
@@ -33,10 +33,14 @@ func (c *Client) SendCommand(command string) (string, error) {
 		return "", fmt.Errorf("failed to send command: %w", err)
 	}
 
-	// Read response
+	// Read response, skipping events broadcast while we were connected
 	scanner := bufio.NewScanner(conn)
-	if scanner.Scan() {
-		return strings.TrimSpace(scanner.Text()), nil
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, EventPrefix) {
+			continue
+		}
+		return line, nil
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -45,3 +49,35 @@ func (c *Client) SendCommand(command string) (string, error) {
 
 	return "", fmt.Errorf("no response from daemon")
 }
+
+// Watch connects to the daemon, sends the initial command (e.g. "state") and
+// calls onLine for its response and every broadcast event until the connection closes.
+// Event lines are passed without the "EVENT " prefix.
+func (c *Client) Watch(initial string, onLine func(line string, isEvent bool)) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer conn.Close()
+
+	if initial != "" {
+		if _, err := conn.Write([]byte(initial + "\n")); err != nil {
+			return fmt.Errorf("failed to send command: %w", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, EventPrefix) {
+			onLine(strings.TrimPrefix(line, EventPrefix), true)
+		} else {
+			onLine(line, false)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("connection lost: %w", err)
+	}
+	return fmt.Errorf("daemon closed the connection")
+}
@@ -0,0 +1,47 @@
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Lock is held by the daemon serving a socket for as long as it runs, so a
+// second daemon can't start on the same socket
+type Lock struct {
+	file *os.File
+}
+
+// AcquireLock takes the lock of the daemon serving socketPath. It fails with
+// ErrDaemonRunning while another daemon holds it. The kernel releases the lock
+// when the process exits, however it ends.
+func AcquireLock(socketPath string) (*Lock, error) {
+	path := socketPath + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w on %s", ErrDaemonRunning, socketPath)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// The PID is informational, the lock itself is what counts
+	file.Truncate(0)
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	return &Lock{file: file}, nil
+}
+
+// Release gives up the lock
+func (l *Lock) Release() {
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+}
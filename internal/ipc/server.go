@@ -2,21 +2,49 @@ package ipc
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// EventPrefix marks lines the server pushes to clients without a request
+const EventPrefix = "EVENT "
+
+// ErrDaemonRunning is returned by Start when another daemon listens on the socket
+var ErrDaemonRunning = errors.New("daemon already running")
+
 // CommandHandler is a function that handles IPC commands
 type CommandHandler func(command string) string
 
+// client is a connected IPC client
+type client struct {
+	conn net.Conn
+	mu   sync.Mutex // serializes writes of responses and broadcasts
+}
+
+// write sends a single line to the client
+func (c *client) write(line string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_, err := c.conn.Write([]byte(line + "\n"))
+	return err
+}
+
 // Server represents an IPC server using Unix sockets
 type Server struct {
 	socketPath string
 	listener   net.Listener
 	handler    CommandHandler
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
 }
 
 // NewServer creates a new IPC server
@@ -24,13 +52,15 @@ func NewServer(socketPath string, handler CommandHandler) *Server {
 	return &Server{
 		socketPath: socketPath,
 		handler:    handler,
+		clients:    make(map[*client]struct{}),
 	}
 }
 
 // Start starts the IPC server
 func (s *Server) Start() error {
-	// Remove old socket if it exists
-	os.Remove(s.socketPath)
+	if err := removeStaleSocket(s.socketPath); err != nil {
+		return err
+	}
 
 	// Create socket directory
 	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
@@ -57,6 +87,32 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// removeStaleSocket removes a socket left behind by a daemon that didn't shut
+// down cleanly. A socket a daemon still listens on is kept.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%w on %s", ErrDaemonRunning, path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to probe %s: %w", path, err)
+	}
+	fmt.Printf("🧹 Removing stale socket %s\n", path)
+	return os.Remove(path)
+}
+
 // acceptConnections accepts and handles incoming connections
 func (s *Server) acceptConnections() {
 	for {
@@ -71,27 +127,79 @@ func (s *Server) acceptConnections() {
 	}
 }
 
-// handleConnection handles a single client connection
+// handleConnection handles a client connection. Clients may send any number of
+// commands and receive broadcast events for as long as they stay connected.
 func (s *Server) handleConnection(conn net.Conn) {
-	defer conn.Close()
+	c := &client{conn: conn}
+
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+		conn.Close()
+	}()
 
-	// Read command
 	scanner := bufio.NewScanner(conn)
-	if scanner.Scan() {
+	for scanner.Scan() {
 		command := strings.TrimSpace(scanner.Text())
+		if command == "" {
+			continue
+		}
 
 		// Process command
 		response := s.handler(command)
 
 		// Send response
-		conn.Write([]byte(response + "\n"))
+		if err := c.write(response); err != nil {
+			return
+		}
+	}
+}
+
+// Broadcast pushes an event line ("EVENT <event> <payload>") to every connected client
+func (s *Server) Broadcast(event, payload string) {
+	line := EventPrefix + event
+	if payload != "" {
+		line += " " + payload
+	}
+
+	s.mu.Lock()
+	clients := make([]*client, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		if err := c.write(line); err != nil {
+			// Drop clients that can't keep up or went away
+			c.conn.Close()
+		}
 	}
 }
 
+// ClientCount returns the number of connected clients
+func (s *Server) ClientCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
 // Stop stops the IPC server
 func (s *Server) Stop() {
 	if s.listener != nil {
 		s.listener.Close()
 	}
+
+	s.mu.Lock()
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+
 	os.Remove(s.socketPath)
 }
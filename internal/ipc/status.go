@@ -0,0 +1,16 @@
+package ipc
+
+// Status is the daemon's reply to "status json"
+type Status struct {
+	State            string  `json:"state"` // idle, recording, processing, stuck, success or error
+	Recording        bool    `json:"recording"`
+	RecordingSeconds float64 `json:"recording_seconds,omitempty"` // Elapsed time of the current recording
+	Queue            int     `json:"queue"`                       // Dictations not injected yet, including the current recording
+	Model            string  `json:"model"`                       // Model the transcriber loaded
+	ModelLoaded      bool    `json:"model_loaded"`                // False while unloaded or loading
+	Device           string  `json:"device,omitempty"`            // Hardware transcribing, e.g. "CUDA GPU 1"
+	Language         string  `json:"language"`                    // Fixed transcription language, "auto" when detected
+	Mode             string  `json:"mode,omitempty"`              // Constrained mode of the current or next recording
+	Profile          string  `json:"profile,omitempty"`
+	Injection        string  `json:"injection"` // Injection backend, e.g. "clipboard+wtype"
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Supported providers
+const (
+	ProviderOllama = "ollama" // Ollama /api/chat
+	ProviderOpenAI = "openai" // Any OpenAI-compatible /v1/chat/completions endpoint
+)
+
+// Config contains the LLM endpoint settings
+type Config struct {
+	Provider     string
+	Endpoint     string // Base URL, empty = provider default
+	Model        string
+	APIKey       string // Falls back to $OPENAI_API_KEY for the openai provider
+	SystemPrompt string
+	Timeout      time.Duration
+}
+
+// Client rewrites transcripts with a chat completion model
+type Client struct {
+	config Config
+	http   *http.Client
+}
+
+// New creates an LLM client
+func New(config Config) (*Client, error) {
+	config.Provider = strings.ToLower(config.Provider)
+	switch config.Provider {
+	case ProviderOllama:
+		if config.Endpoint == "" {
+			config.Endpoint = "http://localhost:11434"
+		}
+	case ProviderOpenAI:
+		if config.Endpoint == "" {
+			config.Endpoint = "https://api.openai.com"
+		}
+		if config.APIKey == "" {
+			config.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (use %q or %q)", config.Provider, ProviderOllama, ProviderOpenAI)
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("no LLM model configured")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	return &Client{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Name returns the processor name
+func (c *Client) Name() string { return "llm:" + c.config.Model }
+
+// Process rewrites the text, returning it unchanged if the request fails
+// so a dictation is never lost to an unreachable endpoint
+func (c *Client) Process(text string) string {
+	start := time.Now()
+	rewritten, err := c.Rewrite(text)
+	if err != nil {
+		fmt.Printf("⚠️  LLM post-processing failed, using raw transcript: %v\n", err)
+		return text
+	}
+	if rewritten == "" {
+		fmt.Println("⚠️  LLM returned an empty response, using raw transcript")
+		return text
+	}
+	fmt.Printf("🤖 LLM rewrite took %v\n", time.Since(start).Round(time.Millisecond))
+	return rewritten
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Rewrite sends the transcript with the system prompt and returns the model's answer
+func (c *Client) Rewrite(text string) (string, error) {
+	messages := []message{}
+	if c.config.SystemPrompt != "" {
+		messages = append(messages, message{Role: "system", Content: c.config.SystemPrompt})
+	}
+	messages = append(messages, message{Role: "user", Content: text})
+
+	var url string
+	var body interface{}
+	switch c.config.Provider {
+	case ProviderOllama:
+		url = c.config.Endpoint + "/api/chat"
+		body = map[string]interface{}{
+			"model":    c.config.Model,
+			"messages": messages,
+			"stream":   false,
+		}
+	default:
+		url = c.config.Endpoint + "/v1/chat/completions"
+		// Allow endpoints configured with the /v1 suffix already
+		if strings.HasSuffix(c.config.Endpoint, "/v1") {
+			url = c.config.Endpoint + "/chat/completions"
+		}
+		body = map[string]interface{}{
+			"model":    c.config.Model,
+			"messages": messages,
+		}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var content string
+	switch c.config.Provider {
+	case ProviderOllama:
+		var result struct {
+			Message message `json:"message"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return "", fmt.Errorf("invalid response: %w", err)
+		}
+		content = result.Message.Content
+	default:
+		var result struct {
+			Choices []struct {
+				Message message `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return "", fmt.Errorf("invalid response: %w", err)
+		}
+		if len(result.Choices) == 0 {
+			return "", fmt.Errorf("response contained no choices")
+		}
+		content = result.Choices[0].Message.Content
+	}
+
+	return strings.TrimSpace(content), nil
+}
@@ -0,0 +1,130 @@
+package markers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pa/hyprwhspr/internal/batch"
+	"github.com/pa/hyprwhspr/internal/whisper"
+)
+
+// cueLength is how long a marker is shown in SRT players
+const cueLength = 3 * time.Second
+
+// Marker is a bookmark set during a recording
+type Marker struct {
+	Offset time.Duration // Position within the recording
+	Label  string        // Optional description, empty for spoken markers
+	Spoken bool          // Set by saying the marker phrase instead of the hotkey
+}
+
+// String formats a marker as "[mm:ss] label"
+func (m Marker) String() string {
+	s := "[" + Timestamp(m.Offset) + "]"
+	if m.Label != "" {
+		s += " " + m.Label
+	}
+	return s
+}
+
+// Timestamp formats an offset as mm:ss, or h:mm:ss for long recordings
+func Timestamp(d time.Duration) string {
+	sec := int(d.Seconds())
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+	}
+	return fmt.Sprintf("%02d:%02d", sec/60, sec%60)
+}
+
+// Shift moves markers earlier by offset, dropping the ones before it. Used when
+// only the end of a recording is transcribed.
+func Shift(markers []Marker, offset time.Duration) []Marker {
+	var shifted []Marker
+	for _, m := range markers {
+		if m.Offset >= offset {
+			m.Offset -= offset
+			shifted = append(shifted, m)
+		}
+	}
+	return shifted
+}
+
+// phrasePattern matches a spoken marker phrase with trailing punctuation
+func phrasePattern(phrase string) *regexp.Regexp {
+	words := strings.Fields(phrase)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return regexp.MustCompile(`(?i)\b` + strings.Join(words, `[\s,]+`) + `\b[.,!?]*\s*`)
+}
+
+// FindSpoken looks for the marker phrase in the transcribed segments. Every
+// segment containing it gets a marker at its start, and the phrase is removed
+// from the segments and the text so it isn't injected.
+func FindSpoken(result *whisper.Result, phrase string) []Marker {
+	if strings.TrimSpace(phrase) == "" {
+		return nil
+	}
+	pattern := phrasePattern(phrase)
+
+	var found []Marker
+	for i, seg := range result.Segments {
+		if !pattern.MatchString(seg.Text) {
+			continue
+		}
+		found = append(found, Marker{Offset: seg.Start, Spoken: true})
+		result.Segments[i].Text = pattern.ReplaceAllString(seg.Text, "")
+	}
+	if len(found) > 0 {
+		result.Text = strings.TrimSpace(pattern.ReplaceAllString(result.Text, ""))
+	}
+	return found
+}
+
+// Sort orders markers by position
+func Sort(markers []Marker) {
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].Offset < markers[j].Offset })
+}
+
+// Context returns the text of the segment a marker falls in, or the next one
+// when the marker was set during a pause
+func Context(m Marker, segments []whisper.Segment) string {
+	for _, seg := range segments {
+		if m.Offset < seg.End && strings.TrimSpace(seg.Text) != "" {
+			return strings.TrimSpace(seg.Text)
+		}
+	}
+	return ""
+}
+
+// SRT renders the transcript as SubRip subtitles with a cue for every marker
+func SRT(segments []whisper.Segment, markers []Marker) string {
+	cues := make([]whisper.Segment, 0, len(segments)+len(markers))
+	cues = append(cues, segments...)
+	for _, m := range markers {
+		text := "🔖 MARKER"
+		if m.Label != "" {
+			text += ": " + m.Label
+		}
+		cues = append(cues, whisper.Segment{Text: text, Start: m.Offset, End: m.Offset + cueLength})
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+	return batch.FormatSRT(cues, strings.TrimSpace)
+}
+
+// Export writes the transcript and its markers to a timestamped SRT file in dir
+func Export(dir string, segments []whisper.Segment, markers []Marker) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create markers directory: %w", err)
+	}
+	path := filepath.Join(dir, time.Now().Format("2006-01-02_15-04-05")+".srt")
+	if err := os.WriteFile(path, []byte(SRT(segments, markers)), 0600); err != nil {
+		return "", fmt.Errorf("failed to write markers: %w", err)
+	}
+	return path, nil
+}
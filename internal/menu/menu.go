@@ -0,0 +1,65 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Launchers are the supported dmenu-style launchers, in the order "auto" tries them
+var Launchers = []string{"rofi", "wofi", "fuzzel"}
+
+// Find returns the launcher to use for name ("auto" = the first one installed)
+func Find(name string) (string, error) {
+	if name != "" && name != "auto" {
+		if _, err := exec.LookPath(name); err != nil {
+			return "", fmt.Errorf("%s not found", name)
+		}
+		return name, nil
+	}
+	for _, launcher := range Launchers {
+		if _, err := exec.LookPath(launcher); err == nil {
+			return launcher, nil
+		}
+	}
+	return "", fmt.Errorf("no launcher found (install %s)", strings.Join(Launchers, ", "))
+}
+
+// Edit opens launcher with text in its input field and returns the text once
+// Enter is pressed, with the user's changes. Returns false when the launcher
+// was closed with Escape or timeout passed. The input field has a single line,
+// line breaks in text become spaces.
+func Edit(launcher, prompt, text string, timeout time.Duration) (string, bool, error) {
+	text = strings.ReplaceAll(text, "\n", " ")
+	var args []string
+	switch launcher {
+	case "rofi":
+		args = []string{"-dmenu", "-p", prompt, "-filter", text, "-l", "0"}
+	case "wofi":
+		args = []string{"--dmenu", "--prompt", prompt, "--search", text, "--lines", "1"}
+	case "fuzzel":
+		args = []string{"--dmenu", "--prompt", prompt + ": ", "--search", text, "--lines", "0"}
+	default:
+		return "", false, fmt.Errorf("unknown launcher %q", launcher)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// No entries on stdin, so Enter returns what is in the input field
+	output, err := exec.CommandContext(ctx, launcher, args...).Output()
+	if ctx.Err() != nil {
+		return "", false, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", false, nil // Escape
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to run %s: %w", launcher, err)
+	}
+	edited := strings.TrimRight(string(output), "\n")
+	return edited, edited != "", nil
+}
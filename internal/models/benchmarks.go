@@ -0,0 +1,111 @@
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BenchmarksFile stores measured model performance in the model directory
+const BenchmarksFile = "benchmarks.json"
+
+// maxBenchmarkWeight limits how much history the running average keeps,
+// so measurements follow hardware or driver changes
+const maxBenchmarkWeight = 50
+
+// Benchmark is the measured performance of a model on this machine
+type Benchmark struct {
+	RealTimeFactor float64   `json:"real_time_factor"` // transcription time / audio time
+	MemoryMB       float64   `json:"memory_mb"`        // resident memory the loaded model takes
+	Runs           int       `json:"runs"`
+	Updated        time.Time `json:"updated"`
+}
+
+var benchmarksMu sync.Mutex
+
+func (m *Manager) benchmarksPath() string {
+	return filepath.Join(m.modelDir, BenchmarksFile)
+}
+
+// LoadBenchmarks returns the stored measurements keyed by model name
+func (m *Manager) LoadBenchmarks() (map[string]Benchmark, error) {
+	benchmarks := make(map[string]Benchmark)
+	data, err := os.ReadFile(m.benchmarksPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return benchmarks, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &benchmarks); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", BenchmarksFile, err)
+	}
+	return benchmarks, nil
+}
+
+// RecordBenchmark folds a measurement into the model's running average.
+// memoryMB <= 0 keeps the previously measured memory.
+func (m *Manager) RecordBenchmark(model string, realTimeFactor, memoryMB float64) error {
+	benchmarksMu.Lock()
+	defer benchmarksMu.Unlock()
+
+	benchmarks, err := m.LoadBenchmarks()
+	if err != nil {
+		benchmarks = make(map[string]Benchmark) // Start over if the file is corrupt
+	}
+
+	b := benchmarks[model]
+	weight := b.Runs
+	if weight > maxBenchmarkWeight {
+		weight = maxBenchmarkWeight
+	}
+	b.RealTimeFactor = (b.RealTimeFactor*float64(weight) + realTimeFactor) / float64(weight+1)
+	if memoryMB > 0 {
+		b.MemoryMB = memoryMB
+	}
+	b.Runs++
+	b.Updated = time.Now()
+	benchmarks[model] = b
+
+	if err := m.EnsureModelDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(benchmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.benchmarksPath(), data, 0644)
+}
+
+// ProcessMemoryMB returns the resident memory of the current process (0 if unknown)
+func ProcessMemoryMB() float64 {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0
+		}
+		kb, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0
+		}
+		return kb / 1024
+	}
+	return 0
+}
@@ -3,16 +3,25 @@ package models
 import (
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 const (
-	ModelBaseURL = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main"
+	ModelBaseURL    = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main"
+	VADModelBaseURL = "https://huggingface.co/ggml-org/whisper-vad/resolve/main"
 )
 
+// VADModels are voice activity detection models for vad_engine "silero",
+// downloaded and stored like whisper models
+var VADModels = []string{
+	"silero-v5.1.2",
+}
+
 var AvailableModels = []string{
 	"tiny",
 	"tiny.en",
@@ -28,6 +37,20 @@ var AvailableModels = []string{
 	"large",
 }
 
+// EnglishVariant returns the English-only variant of a model (e.g. "base.en"
+// for "base"), or "" if there is none
+func EnglishVariant(model string) string {
+	if strings.HasSuffix(model, ".en") {
+		return ""
+	}
+	for _, m := range AvailableModels {
+		if m == model+".en" {
+			return m
+		}
+	}
+	return ""
+}
+
 type Manager struct {
 	modelDir string
 }
@@ -64,6 +87,9 @@ func (m *Manager) ListDownloadedModels() ([]string, error) {
 		if strings.HasSuffix(file.Name(), ".bin") && strings.HasPrefix(file.Name(), "ggml-") {
 			modelName := strings.TrimPrefix(file.Name(), "ggml-")
 			modelName = strings.TrimSuffix(modelName, ".bin")
+			if isVADModel(modelName) {
+				continue // Not a transcription model
+			}
 			models = append(models, modelName)
 		}
 	}
@@ -98,7 +124,11 @@ func (m *Manager) DownloadModel(model string, progressCallback func(float64)) er
 	}
 
 	// Download URL
-	url := fmt.Sprintf("%s/ggml-%s.bin", ModelBaseURL, model)
+	baseURL := ModelBaseURL
+	if isVADModel(model) {
+		baseURL = VADModelBaseURL
+	}
+	url := fmt.Sprintf("%s/ggml-%s.bin", baseURL, model)
 	outputPath := m.GetModelPath(model)
 
 	fmt.Printf("📥 Downloading model '%s' from %s\n", model, url)
@@ -192,6 +222,39 @@ func (m *Manager) DeleteModel(model string) error {
 	return nil
 }
 
+// minModelSizesMB are the smallest plausible file sizes of the models, a
+// smaller file is the remainder of an interrupted download
+var minModelSizesMB = map[string]int64{
+	"tiny": 70, "base": 135, "small": 450, "medium": 1400, "large": 2800,
+}
+
+// ggmlMagic starts every ggml model file ("ggml" as a little endian uint32)
+var ggmlMagic = []byte{'l', 'm', 'g', 'g'}
+
+// Verify checks that a downloaded model file is complete: it starts like a
+// ggml model and is not smaller than the model can be
+func (m *Manager) Verify(model string) error {
+	file, err := os.Open(m.GetModelPath(model))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(ggmlMagic))
+	if _, err := io.ReadFull(file, header); err != nil || string(header) != string(ggmlMagic) {
+		return fmt.Errorf("not a ggml model file")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	family := strings.SplitN(strings.TrimSuffix(model, ".en"), "-", 2)[0]
+	if minMB, ok := minModelSizesMB[family]; ok && info.Size() < minMB*1024*1024 {
+		return fmt.Errorf("file is only %.1f MB, the download was probably interrupted", float64(info.Size())/(1024*1024))
+	}
+	return nil
+}
+
 func (m *Manager) GetModelSize(model string) (int64, error) {
 	if !m.IsModelDownloaded(model) {
 		return 0, fmt.Errorf("model %s is not downloaded", model)
@@ -212,9 +275,67 @@ func (m *Manager) isValidModel(model string) bool {
 			return true
 		}
 	}
+	return isVADModel(model)
+}
+
+// isVADModel returns whether model is a voice activity detection model
+func isVADModel(model string) bool {
+	for _, vadModel := range VADModels {
+		if vadModel == model {
+			return true
+		}
+	}
 	return false
 }
 
+// formatBenchmark describes a measured benchmark in one line
+func formatBenchmark(b Benchmark) string {
+	text := fmt.Sprintf("%.2fx real time", b.RealTimeFactor)
+	if b.MemoryMB > 0 {
+		text += fmt.Sprintf(", ~%.0f MB RAM", b.MemoryMB)
+	}
+	return text + fmt.Sprintf(", %d run(s)", b.Runs)
+}
+
+// ModelInfo describes a known or downloaded model for machine-readable output
+type ModelInfo struct {
+	Name       string     `json:"name"`
+	Downloaded bool       `json:"downloaded"`
+	SizeMB     float64    `json:"size_mb,omitempty"` // Size of the downloaded file
+	Active     bool       `json:"active"`
+	Benchmark  *Benchmark `json:"benchmark,omitempty"` // Measured on this machine
+}
+
+// ListModelInfo returns the available models followed by downloaded models
+// that aren't in the list
+func (m *Manager) ListModelInfo(activeModel string) ([]ModelInfo, error) {
+	downloaded, err := m.ListDownloadedModels()
+	if err != nil {
+		return nil, err
+	}
+	benchmarks, _ := m.LoadBenchmarks() // Measurements are optional
+
+	names := append([]string(nil), AvailableModels...)
+	for _, model := range downloaded {
+		if !m.isValidModel(model) {
+			names = append(names, model)
+		}
+	}
+	infos := make([]ModelInfo, 0, len(names))
+	for _, model := range names {
+		info := ModelInfo{Name: model, Active: model == activeModel}
+		if size, err := m.GetModelSize(model); err == nil {
+			info.Downloaded = true
+			info.SizeMB = math.Round(float64(size)/(1024*1024)*10) / 10
+		}
+		if b, ok := benchmarks[model]; ok {
+			info.Benchmark = &b
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
 func (m *Manager) PrintModelInfo(activeModel string) {
 	fmt.Println("🤖 Whisper Models:")
 	fmt.Printf("🎯 Active model: %s\n\n", activeModel)
@@ -226,15 +347,25 @@ func (m *Manager) PrintModelInfo(activeModel string) {
 		return
 	}
 
+	// Measurements from transcriptions on this machine
+	benchmarks, err := m.LoadBenchmarks()
+	if err != nil {
+		fmt.Printf("⚠️  Failed to load benchmarks: %v\n", err)
+	}
+
 	if len(downloaded) > 0 {
 		fmt.Println("📁 Downloaded models:")
 		for _, model := range downloaded {
 			size, _ := m.GetModelSize(model)
 			sizeMB := float64(size) / (1024 * 1024)
+			measured := ""
+			if b, ok := benchmarks[model]; ok {
+				measured = " - " + formatBenchmark(b)
+			}
 			if model == activeModel {
-				fmt.Printf("  ★ %s (%.1f MB) [ACTIVE]\n", model, sizeMB)
+				fmt.Printf("  ★ %s (%.1f MB) [ACTIVE]%s\n", model, sizeMB, measured)
 			} else {
-				fmt.Printf("  ✓ %s (%.1f MB)\n", model, sizeMB)
+				fmt.Printf("  ✓ %s (%.1f MB)%s\n", model, sizeMB, measured)
 			}
 		}
 		fmt.Println()
@@ -259,6 +390,31 @@ func (m *Manager) PrintModelInfo(activeModel string) {
 	}
 	fmt.Println()
 
+	// Recommendations based on local measurements
+	if len(benchmarks) > 0 {
+		fmt.Println("📊 Measured on this machine:")
+		names := make([]string, 0, len(benchmarks))
+		for model := range benchmarks {
+			names = append(names, model)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return benchmarks[names[i]].RealTimeFactor < benchmarks[names[j]].RealTimeFactor
+		})
+		for _, model := range names {
+			b := benchmarks[model]
+			hint := ""
+			switch {
+			case b.RealTimeFactor > 1:
+				hint = " (slower than real time - too slow for dictation)"
+			case b.RealTimeFactor > 0.5:
+				hint = " (noticeable delay for long dictations)"
+			}
+			fmt.Printf("  • %-10s %s%s\n", model, formatBenchmark(b), hint)
+		}
+		fmt.Println("  Real-time factor = transcription time / audio length, lower is faster.")
+		fmt.Println()
+	}
+
 	// Model recommendations
 	fmt.Println("💡 Recommendations:")
 	fmt.Println("  • tiny    - Fastest, lowest accuracy (~39MB)")
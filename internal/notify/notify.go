@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Send shows a desktop notification via notify-send (no-op if unavailable)
+func Send(summary, body string) {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return
+	}
+
+	cmd := exec.Command("notify-send", "--app-name=hyprwhspr", summary, body)
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("[WARN] Failed to send notification: %v\n", err)
+	}
+}
+
+// Action is a button of a notification shown with Ask
+type Action struct {
+	Key   string // Returned by Ask when the button is clicked
+	Label string
+}
+
+// Ask shows a notification with buttons and waits until one is clicked,
+// returning its key. Returns "" when the notification was dismissed or timed
+// out, and an error if the notification daemon or notify-send (before 0.7.10)
+// doesn't support actions.
+func Ask(summary, body string, timeout time.Duration, actions ...Action) (string, error) {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return "", fmt.Errorf("notify-send not found")
+	}
+
+	args := []string{"--app-name=hyprwhspr", "--wait", "--expire-time=" + strconv.FormatInt(timeout.Milliseconds(), 10)}
+	for _, a := range actions {
+		args = append(args, "--action="+a.Key+"="+a.Label)
+	}
+	args = append(args, summary, body)
+
+	// Some notification daemons ignore the expire time, don't wait forever
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "notify-send", args...).Output()
+	if ctx.Err() != nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("notification with actions failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,124 @@
+package overlay
+
+import (
+	"github.com/rajveermalviya/go-wayland/wayland/client"
+)
+
+// Just enough of wlr-layer-shell-unstable-v1 for a surface floating above all
+// windows. go-wayland ships no binding for it, Hyprland, Sway and most other
+// wlroots-style compositors implement it.
+
+const layerShellInterface = "zwlr_layer_shell_v1"
+
+// Layers and anchors of the protocol
+const (
+	layerOverlay = 3
+
+	anchorTop    = 1
+	anchorBottom = 2
+	anchorLeft   = 4
+	anchorRight  = 8
+)
+
+// layerShell is the zwlr_layer_shell_v1 global
+type layerShell struct {
+	client.BaseProxy
+}
+
+func newLayerShell(ctx *client.Context) *layerShell {
+	s := &layerShell{}
+	ctx.Register(s)
+	return s
+}
+
+// Dispatch implements client.Dispatcher, the global has no events
+func (s *layerShell) Dispatch(opcode uint32, fd int, data []byte) {}
+
+// getLayerSurface gives surface the layer surface role on the output the
+// compositor chooses
+func (s *layerShell) getLayerSurface(surface *client.Surface, layer uint32, namespace string) (*layerSurface, error) {
+	ls := &layerSurface{}
+	s.Context().Register(ls)
+
+	namespaceLen := client.PaddedLen(len(namespace) + 1)
+	msg := newMessage(s.ID(), 0, 4+4+4+4+(4+namespaceLen))
+	msg.uint32(ls.ID())
+	msg.uint32(surface.ID())
+	msg.uint32(0) // No output
+	msg.uint32(layer)
+	client.PutString(msg.buf[msg.off:], namespace, namespaceLen)
+	return ls, s.Context().WriteMsg(msg.buf, nil)
+}
+
+// layerSurface is a zwlr_layer_surface_v1
+type layerSurface struct {
+	client.BaseProxy
+	onConfigure func(serial, width, height uint32)
+	onClosed    func()
+}
+
+func (ls *layerSurface) request(opcode uint32, args ...uint32) error {
+	msg := newMessage(ls.ID(), opcode, 4*len(args))
+	for _, arg := range args {
+		msg.uint32(arg)
+	}
+	return ls.Context().WriteMsg(msg.buf, nil)
+}
+
+func (ls *layerSurface) setSize(width, height uint32) error {
+	return ls.request(0, width, height)
+}
+
+func (ls *layerSurface) setAnchor(anchor uint32) error {
+	return ls.request(1, anchor)
+}
+
+func (ls *layerSurface) setExclusiveZone(zone int32) error {
+	return ls.request(2, uint32(zone))
+}
+
+func (ls *layerSurface) setMargin(top, right, bottom, left int32) error {
+	return ls.request(3, uint32(top), uint32(right), uint32(bottom), uint32(left))
+}
+
+func (ls *layerSurface) ackConfigure(serial uint32) error {
+	return ls.request(6, serial)
+}
+
+func (ls *layerSurface) destroy() error {
+	defer ls.Context().Unregister(ls)
+	return ls.request(7)
+}
+
+// Dispatch implements client.Dispatcher
+func (ls *layerSurface) Dispatch(opcode uint32, fd int, data []byte) {
+	switch opcode {
+	case 0:
+		if ls.onConfigure != nil && len(data) >= 12 {
+			ls.onConfigure(client.Uint32(data[0:4]), client.Uint32(data[4:8]), client.Uint32(data[8:12]))
+		}
+	case 1:
+		if ls.onClosed != nil {
+			ls.onClosed()
+		}
+	}
+}
+
+// message is a request being encoded
+type message struct {
+	buf []byte
+	off int
+}
+
+func newMessage(id, opcode uint32, argsLen int) *message {
+	size := 8 + argsLen
+	m := &message{buf: make([]byte, size)}
+	m.uint32(id)
+	m.uint32(uint32(size<<16) | opcode&0xffff)
+	return m
+}
+
+func (m *message) uint32(v uint32) {
+	client.PutUint32(m.buf[m.off:m.off+4], v)
+	m.off += 4
+}
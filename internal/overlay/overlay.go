@@ -0,0 +1,554 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rajveermalviya/go-wayland/wayland/client"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/sys/unix"
+)
+
+// Size of the pill and its distance from the screen edge. States use a pill of
+// pillWidth, a result is as wide as its text up to maxWidth.
+const (
+	pillWidth = 168
+	maxWidth  = 640
+	height    = 32
+	margin    = 32
+	dotR      = 5.0
+	textLeft  = height/2 + int(dotR) + 8
+	textRight = height / 2
+)
+
+// resultDuration is how long a result stays on screen
+const resultDuration = 5 * time.Second
+
+var (
+	background = color.RGBA{24, 24, 27, 230}
+	textColor  = image.NewUniform(color.RGBA{240, 240, 240, 255})
+	markColor  = color.RGBA{245, 165, 36, 255}
+)
+
+// indicator is how a state is shown, states without one hide the overlay
+type indicator struct {
+	label string
+	dot   color.RGBA
+}
+
+var indicators = map[string]indicator{
+	"recording":  {"Recording", color.RGBA{229, 72, 77, 255}},
+	"processing": {"Transcribing", color.RGBA{245, 165, 36, 255}},
+	"stuck":      {"Stuck", color.RGBA{229, 72, 77, 255}},
+}
+
+// loadFace loads the label font. Go Mono covers the accented letters and
+// punctuation of most languages, the text of a result isn't plain ASCII.
+func loadFace() (font.Face, error) {
+	f, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: 12, DPI: 72, Hinting: font.HintingFull})
+}
+
+// Overlay is a small pill above all windows showing whether hyprwhspr is recording
+// or processing and for how long, and briefly the result of a dictation with the
+// words to double-check. It is a layer-shell surface that takes no input.
+type Overlay struct {
+	mu sync.Mutex // guards everything below, events are dispatched with it held
+
+	ctx        *client.Context
+	compositor *client.Compositor
+	shell      *layerShell
+	surface    *client.Surface
+	layer      *layerSurface
+	anchor     uint32
+	face       font.Face
+	pool       *client.ShmPool
+	buffers    [2]*buffer
+	pixels     []byte // mmapped memory of both buffers
+	width      int    // width of the buffers and the surface
+
+	state string
+	since time.Time // when state was entered
+
+	result      string   // text of the last dictation, shown while no state is
+	marks       [][2]int // byte ranges of result to highlight
+	resultUntil time.Time
+
+	awaiting   bool // the surface was committed without a buffer and waits for configure
+	configured bool // the configure was acked, buffers can be attached
+	mapped     bool
+	closed     bool
+	done       chan struct{}
+}
+
+// buffer is one of the two shared memory buffers drawn alternately
+type buffer struct {
+	wl     *client.Buffer
+	pixels []byte
+	busy   bool // the compositor hasn't released it yet
+}
+
+// New connects to the Wayland compositor and prepares the overlay at position
+// ("top", "bottom", "top-left", ...). It is hidden until SetState shows it.
+func New(position string) (*Overlay, error) {
+	face, err := loadFace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the font: %w", err)
+	}
+	display, err := client.Connect("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Wayland: %w", err)
+	}
+	o := &Overlay{ctx: display.Context(), anchor: anchorFor(position), face: face, done: make(chan struct{})}
+	display.SetErrorHandler(func(e client.DisplayErrorEvent) {
+		fmt.Printf("⚠️  Overlay: Wayland error %d: %s\n", e.Code, e.Message)
+	})
+
+	var shm *client.Shm
+	registry, err := display.GetRegistry()
+	if err != nil {
+		o.ctx.Close()
+		return nil, err
+	}
+	registry.SetGlobalHandler(func(e client.RegistryGlobalEvent) {
+		switch e.Interface {
+		case "wl_compositor":
+			o.compositor = client.NewCompositor(o.ctx)
+			registry.Bind(e.Name, e.Interface, 1, o.compositor)
+		case "wl_shm":
+			shm = client.NewShm(o.ctx)
+			registry.Bind(e.Name, e.Interface, 1, shm)
+		case layerShellInterface:
+			o.shell = newLayerShell(o.ctx)
+			registry.Bind(e.Name, e.Interface, 1, o.shell)
+		}
+	})
+	if err := roundtrip(display); err != nil {
+		o.ctx.Close()
+		return nil, err
+	}
+
+	switch {
+	case o.compositor == nil || shm == nil:
+		err = errors.New("the compositor offers no wl_compositor or wl_shm")
+	case o.shell == nil:
+		err = errors.New("the compositor doesn't support wlr-layer-shell")
+	default:
+		err = o.createBuffers(shm)
+	}
+	if err == nil {
+		err = o.createSurface()
+	}
+	if err != nil {
+		o.ctx.Close()
+		return nil, err
+	}
+
+	go o.dispatch()
+	go o.tick()
+	return o, nil
+}
+
+// anchorFor converts an overlay_position to layer surface anchors. Anchored to
+// one edge only, the pill is centered along it.
+func anchorFor(position string) uint32 {
+	switch position {
+	case "top":
+		return anchorTop
+	case "top-left":
+		return anchorTop | anchorLeft
+	case "top-right":
+		return anchorTop | anchorRight
+	case "bottom-left":
+		return anchorBottom | anchorLeft
+	case "bottom-right":
+		return anchorBottom | anchorRight
+	default:
+		return anchorBottom
+	}
+}
+
+// roundtrip waits until the compositor handled all requests sent so far
+func roundtrip(display *client.Display) error {
+	callback, err := display.Sync()
+	if err != nil {
+		return err
+	}
+	done := false
+	callback.SetDoneHandler(func(client.CallbackDoneEvent) { done = true })
+	for !done {
+		if err := display.Context().Dispatch(); err != nil {
+			return err
+		}
+	}
+	callback.Destroy()
+	return nil
+}
+
+// bufferSize is the memory of one buffer, enough for the widest overlay
+const bufferSize = maxWidth * 4 * height
+
+// createBuffers allocates two ARGB buffers in a memfd shared with the compositor
+func (o *Overlay) createBuffers(shm *client.Shm) error {
+	fd, err := unix.MemfdCreate("hyprwhspr-overlay", unix.MFD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("failed to create shared memory: %w", err)
+	}
+	defer unix.Close(fd)
+	if err := unix.Ftruncate(fd, 2*bufferSize); err != nil {
+		return fmt.Errorf("failed to create shared memory: %w", err)
+	}
+	o.pixels, err = unix.Mmap(fd, 0, 2*bufferSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("failed to map shared memory: %w", err)
+	}
+
+	o.pool, err = shm.CreatePool(fd, 2*bufferSize)
+	if err != nil {
+		return err
+	}
+	return o.resize(pillWidth)
+}
+
+// resize replaces the buffers with ones of the given width and sets the size of
+// the layer surface, applied with the next commit
+func (o *Overlay) resize(w int) error {
+	for i := range o.buffers {
+		if old := o.buffers[i]; old != nil {
+			old.wl.Destroy()
+		}
+		wl, err := o.pool.CreateBuffer(int32(i*bufferSize), int32(w), height, int32(w*4), uint32(client.ShmFormatArgb8888))
+		if err != nil {
+			return err
+		}
+		b := &buffer{wl: wl, pixels: o.pixels[i*bufferSize : i*bufferSize+w*4*height]}
+		wl.SetReleaseHandler(func(client.BufferReleaseEvent) { b.busy = false })
+		o.buffers[i] = b
+	}
+	o.width = w
+	if o.layer != nil {
+		o.layer.setSize(uint32(w), height)
+	}
+	return nil
+}
+
+// createSurface creates the layer surface, it is mapped once a buffer is attached
+func (o *Overlay) createSurface() error {
+	surface, err := o.compositor.CreateSurface()
+	if err != nil {
+		return err
+	}
+	layer, err := o.shell.getLayerSurface(surface, layerOverlay, "hyprwhspr")
+	if err != nil {
+		return err
+	}
+	o.surface, o.layer = surface, layer
+	layer.onConfigure = o.configure
+	layer.onClosed = o.recreate
+
+	layer.setSize(uint32(o.width), height)
+	layer.setAnchor(o.anchor)
+	layer.setMargin(margin, margin, margin, margin)
+	layer.setExclusiveZone(0)
+
+	// An empty input region lets clicks through to the windows below
+	region, err := o.compositor.CreateRegion()
+	if err != nil {
+		return err
+	}
+	surface.SetInputRegion(region)
+	region.Destroy()
+	return nil
+}
+
+// dispatch handles events until the connection is closed
+func (o *Overlay) dispatch() {
+	for {
+		sender, opcode, fd, data, err := o.ctx.ReadMsg()
+		o.mu.Lock()
+		if err != nil {
+			if !o.closed {
+				fmt.Printf("⚠️  Overlay lost the Wayland connection: %v\n", err)
+				o.closed = true
+				close(o.done)
+			}
+			o.mu.Unlock()
+			return
+		}
+		if d, ok := o.ctx.GetProxy(sender).(client.Dispatcher); ok {
+			d.Dispatch(opcode, fd, data)
+		}
+		o.mu.Unlock()
+	}
+}
+
+// tick redraws the elapsed time every second and hides a result once it expired
+func (o *Overlay) tick() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.done:
+			return
+		case <-ticker.C:
+			o.mu.Lock()
+			if o.mapped {
+				o.update()
+			}
+			o.mu.Unlock()
+		}
+	}
+}
+
+// SetState shows the overlay for "recording", "processing" and "stuck" and
+// hides it for every other state, unless a result is shown
+func (o *Overlay) SetState(state string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	if state != o.state {
+		o.state, o.since = state, time.Now()
+	}
+	if state == "recording" {
+		o.result = "" // The next dictation started
+	}
+	o.update()
+}
+
+// ShowResult shows the single line text of a dictation for a few seconds once
+// processing is done, with the byte ranges in marks (the words to double-check)
+// highlighted. Text too long for the overlay is cut around the first mark.
+func (o *Overlay) ShowResult(text string, marks [][2]int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	o.result, o.marks = o.fit(text, marks)
+	o.resultUntil = time.Now().Add(resultDuration)
+	o.update()
+}
+
+// fit cuts text to the widest overlay, starting a few words before the first
+// mark so it is in view, and moves the marks along
+func (o *Overlay) fit(text string, marks [][2]int) (string, [][2]int) {
+	available := maxWidth - textLeft - textRight
+	if font.MeasureString(o.face, text).Ceil() <= available {
+		return text, marks
+	}
+
+	start, prefix := 0, ""
+	if len(marks) > 0 {
+		start = marks[0][0]
+		for spaces := 0; start > 0; start-- {
+			if text[start-1] == ' ' {
+				if spaces == 3 {
+					break
+				}
+				spaces++
+			}
+		}
+	}
+	if start > 0 {
+		prefix = "…"
+	}
+	cut := text[start:]
+	for len(cut) > 0 && font.MeasureString(o.face, prefix+cut+"…").Ceil() > available {
+		_, size := utf8.DecodeLastRuneInString(cut)
+		cut = cut[:len(cut)-size]
+	}
+
+	var moved [][2]int
+	for _, m := range marks {
+		if m[0] >= start && m[1] <= start+len(cut) {
+			moved = append(moved, [2]int{m[0] - start + len(prefix), m[1] - start + len(prefix)})
+		}
+	}
+	return prefix + cut + "…", moved
+}
+
+// content returns what the overlay shows: the state's label, or the result for a
+// while after a dictation. ok is false when the overlay is hidden.
+func (o *Overlay) content() (label string, dot color.RGBA, marks [][2]int, w int, ok bool) {
+	if ind, ok := indicators[o.state]; ok {
+		elapsed := int(time.Since(o.since).Seconds())
+		return fmt.Sprintf("%s %d:%02d", ind.label, elapsed/60, elapsed%60), ind.dot, nil, pillWidth, true
+	}
+	if o.result != "" && time.Now().Before(o.resultUntil) {
+		w := textLeft + font.MeasureString(o.face, o.result).Ceil() + textRight
+		if w < pillWidth {
+			w = pillWidth
+		} else if w > maxWidth {
+			w = maxWidth
+		}
+		return o.result, markColor, o.marks, w, true
+	}
+	return "", color.RGBA{}, nil, 0, false
+}
+
+// update shows, redraws or hides the overlay for its content
+func (o *Overlay) update() {
+	_, _, _, w, ok := o.content()
+	if !ok {
+		if o.mapped {
+			// Attaching no buffer unmaps, the next show starts over with a configure
+			o.surface.Attach(nil, 0, 0)
+			o.surface.Commit()
+			o.mapped, o.configured = false, false
+		}
+		return
+	}
+	if w != o.width {
+		if err := o.resize(w); err != nil {
+			fmt.Printf("⚠️  Overlay: failed to resize: %v\n", err)
+			return
+		}
+	}
+	switch {
+	case o.configured:
+		o.redraw()
+	case !o.awaiting:
+		o.surface.Commit()
+		o.awaiting = true
+	}
+}
+
+// configure acks the layer surface size and draws if the overlay is shown
+func (o *Overlay) configure(serial, _, _ uint32) {
+	o.layer.ackConfigure(serial)
+	o.awaiting, o.configured = false, true
+	o.redraw()
+}
+
+// recreate replaces the layer surface the compositor closed, e.g. because its
+// output was unplugged
+func (o *Overlay) recreate() {
+	o.layer.destroy()
+	o.surface.Destroy()
+	o.awaiting, o.configured, o.mapped = false, false, false
+	if err := o.createSurface(); err != nil {
+		fmt.Printf("⚠️  Overlay: failed to recreate the surface: %v\n", err)
+		return
+	}
+	if _, _, _, _, ok := o.content(); ok {
+		o.surface.Commit()
+		o.awaiting = true
+	}
+}
+
+// redraw draws the current content into a free buffer and shows it
+func (o *Overlay) redraw() {
+	label, dot, marks, w, ok := o.content()
+	if !ok || !o.configured || w != o.width {
+		return
+	}
+	var b *buffer
+	for _, candidate := range o.buffers {
+		if !candidate.busy {
+			b = candidate
+			break
+		}
+	}
+	if b == nil {
+		return // Both still on screen, the next tick draws
+	}
+
+	toARGB(render(o.face, label, dot, marks, w), b.pixels)
+
+	o.surface.Attach(b.wl, 0, 0)
+	o.surface.Damage(0, 0, int32(w), height)
+	o.surface.Commit()
+	b.busy, o.mapped = true, true
+}
+
+// render draws a pill of width w: a dot in the given color followed by label,
+// with the byte ranges in marks underlined in the mark color
+func render(face font.Face, label string, dot color.RGBA, marks [][2]int, width int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	r := float64(height) / 2
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			// Distance to the capsule's center line, antialiased over one pixel
+			cx := math.Max(r, math.Min(float64(width)-r, px))
+			pill := coverage(r - math.Hypot(px-cx, py-r))
+			c := scale(background, pill)
+			if a := coverage(dotR - math.Hypot(px-r, py-r)); a > 0 {
+				c = over(scale(dot, a), c)
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	metrics := face.Metrics()
+	baseline := (height-(metrics.Ascent+metrics.Descent).Ceil())/2 + metrics.Ascent.Ceil()
+	d := font.Drawer{Dst: img, Src: textColor, Face: face, Dot: fixed.P(textLeft, baseline)}
+	last := 0
+	for _, m := range marks {
+		d.DrawString(label[last:m[0]])
+		start := d.Dot.X.Floor()
+		d.Src = image.NewUniform(markColor)
+		d.DrawString(label[m[0]:m[1]])
+		d.Src = textColor
+		for x := start; x < d.Dot.X.Ceil() && x < width-textRight/2; x++ {
+			img.SetRGBA(x, baseline+2, markColor)
+		}
+		last = m[1]
+	}
+	d.DrawString(label[last:])
+	return img
+}
+
+// coverage converts a signed distance inside a shape to an alpha value
+func coverage(inside float64) float64 {
+	return math.Max(0, math.Min(1, inside+0.5))
+}
+
+// scale premultiplies c with alpha a
+func scale(c color.RGBA, a float64) color.RGBA {
+	return color.RGBA{uint8(float64(c.R) * a), uint8(float64(c.G) * a), uint8(float64(c.B) * a), uint8(float64(c.A) * a)}
+}
+
+// over composites the premultiplied src over dst
+func over(src, dst color.RGBA) color.RGBA {
+	k := 255 - uint16(src.A)
+	blend := func(s, d uint8) uint8 { return s + uint8(uint16(d)*k/255) }
+	return color.RGBA{blend(src.R, dst.R), blend(src.G, dst.G), blend(src.B, dst.B), blend(src.A, dst.A)}
+}
+
+// toARGB copies img into a little-endian ARGB8888 buffer (bytes B, G, R, A)
+func toARGB(img *image.RGBA, dst []byte) {
+	for i := 0; i+3 < len(img.Pix) && i+3 < len(dst); i += 4 {
+		dst[i], dst[i+1], dst[i+2], dst[i+3] = img.Pix[i+2], img.Pix[i+1], img.Pix[i], img.Pix[i+3]
+	}
+}
+
+// Close removes the overlay and disconnects from the compositor
+func (o *Overlay) Close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	o.closed = true
+	close(o.done)
+	o.layer.destroy()
+	o.surface.Destroy()
+	o.pool.Destroy()
+	o.ctx.Close()
+	unix.Munmap(o.pixels)
+}
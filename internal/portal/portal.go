@@ -0,0 +1,133 @@
+package portal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// XDG desktop portal bus name and object
+const (
+	Service = "org.freedesktop.portal.Desktop"
+	Path    = "/org/freedesktop/portal/desktop"
+)
+
+// requestInterface is the interface of the objects portal requests answer on
+const requestInterface = "org.freedesktop.portal.Request"
+
+// Conn is a session bus connection to the desktop portal. Portal sessions
+// live as long as the connection that created them.
+type Conn struct {
+	*dbus.Conn
+	mu     sync.Mutex
+	serial int // handle token counter
+}
+
+// Connect opens a private session bus connection and checks that the portal
+// offers the interface (e.g. "org.freedesktop.portal.RemoteDesktop")
+func Connect(iface string) (*Conn, error) {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Object(Service, Path).GetProperty(iface + ".version"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("portal has no %s: %w", iface, err)
+	}
+	return &Conn{Conn: conn}, nil
+}
+
+// Object returns the portal object
+func (c *Conn) Object() dbus.BusObject {
+	return c.Conn.Object(Service, Path)
+}
+
+// Token returns a new handle token for requests and sessions
+func (c *Conn) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serial++
+	return fmt.Sprintf("hyprwhspr%d_%d", os.Getpid(), c.serial)
+}
+
+// Request calls a portal method and waits for its Request.Response signal.
+// args gets the options with the handle token and returns the call arguments.
+func (c *Conn) Request(method string, timeout time.Duration, args func(options map[string]dbus.Variant) []interface{}) (map[string]dbus.Variant, error) {
+	token := c.Token()
+
+	// Subscribe before calling, the response can arrive before the call returns
+	sender := strings.ReplaceAll(strings.TrimPrefix(c.Names()[0], ":"), ".", "_")
+	path := dbus.ObjectPath(Path + "/request/" + sender + "/" + token)
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(requestInterface),
+		dbus.WithMatchMember("Response"),
+	}
+	if err := c.AddMatchSignal(match...); err != nil {
+		return nil, err
+	}
+	defer c.RemoveMatchSignal(match...)
+	signals := make(chan *dbus.Signal, 4)
+	c.Signal(signals)
+	defer c.RemoveSignal(signals)
+
+	options := map[string]dbus.Variant{"handle_token": dbus.MakeVariant(token)}
+	if call := c.Object().Call(method, 0, args(options)...); call.Err != nil {
+		return nil, call.Err
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != path || len(signal.Body) < 2 {
+				continue
+			}
+			response, _ := signal.Body[0].(uint32)
+			results, _ := signal.Body[1].(map[string]dbus.Variant)
+			switch response {
+			case 0:
+				return results, nil
+			case 1:
+				return nil, fmt.Errorf("cancelled by the user")
+			default:
+				return nil, fmt.Errorf("request failed")
+			}
+		case <-deadline:
+			return nil, fmt.Errorf("no response after %v", timeout)
+		}
+	}
+}
+
+// CreateSession creates a session of a portal interface (e.g.
+// "org.freedesktop.portal.GlobalShortcuts")
+func (c *Conn) CreateSession(iface string) (dbus.ObjectPath, error) {
+	results, err := c.Request(iface+".CreateSession", 10*time.Second, func(options map[string]dbus.Variant) []interface{} {
+		options["session_handle_token"] = dbus.MakeVariant(c.Token())
+		return []interface{}{options}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Specified as a string, some implementations send an object path
+	switch handle := results["session_handle"].Value().(type) {
+	case string:
+		return dbus.ObjectPath(handle), nil
+	case dbus.ObjectPath:
+		return handle, nil
+	}
+	return "", fmt.Errorf("no session handle")
+}
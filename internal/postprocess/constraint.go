@@ -0,0 +1,124 @@
+package postprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ConstraintModes are the format-constrained dictation modes, for fields that
+// only take one kind of value (forms, spreadsheets, address bars)
+var ConstraintModes = []string{"number", "email", "url"}
+
+// constraintPrompts bias whisper towards the expected format
+var constraintPrompts = map[string]string{
+	"number": "3, 17, 42.5, 1200, 0.75, 2024, -8, 99.99",
+	"email":  "john.doe@example.com, anna_mueller@mail.de, info@company.org",
+	"url":    "https://example.com, github.com/user/project, www.wikipedia.org/wiki/Main_Page",
+}
+
+// spokenSymbol maps spoken punctuation (English and German) to the symbol
+type spokenSymbol struct {
+	pattern *regexp.Regexp
+	symbol  string
+}
+
+// spokenSymbols builds the replacements, longer phrases first so "forward slash"
+// wins over "slash"
+func spokenSymbols(symbols [][2]string) []spokenSymbol {
+	out := make([]spokenSymbol, len(symbols))
+	for i, s := range symbols {
+		out[i] = spokenSymbol{regexp.MustCompile(`(?i)\b` + s[0] + `\b`), s[1]}
+	}
+	return out
+}
+
+var (
+	emailSymbols = spokenSymbols([][2]string{
+		{"at sign", "@"}, {"at", "@"}, {"dot", "."}, {"period", "."}, {"punkt", "."},
+		{"underscore", "_"}, {"unterstrich", "_"}, {"hyphen", "-"}, {"dash", "-"},
+		{"minus", "-"}, {"bindestrich", "-"}, {"plus", "+"},
+	})
+	urlSymbols = spokenSymbols([][2]string{
+		{"forward slash", "/"}, {"question mark", "?"}, {"fragezeichen", "?"},
+		{"slash", "/"}, {"schrägstrich", "/"}, {"colon", ":"}, {"doppelpunkt", ":"},
+		{"dot", "."}, {"punkt", "."}, {"underscore", "_"}, {"unterstrich", "_"},
+		{"hyphen", "-"}, {"dash", "-"}, {"bindestrich", "-"}, {"equals", "="},
+		{"ampersand", "&"}, {"hash", "#"}, {"tilde", "~"},
+	})
+
+	negativeNumber = regexp.MustCompile(`(?i)\b(?:minus|negative|negativ)\s+(\d)`)
+	numberPattern  = regexp.MustCompile(`-?\d+(?:[.,]\d+)*%?`)
+	emailInvalid   = regexp.MustCompile(`[^a-z0-9@._+-]`)
+	urlInvalid     = regexp.MustCompile(`[^A-Za-z0-9:/?#\[\]@!$&'()*+,;=._~%-]`)
+)
+
+// Constraint reduces a dictation to a single kind of value: numbers, an email
+// address or a URL. Spoken symbols ("dot", "at", "slash") become characters
+// and everything that can't be part of the value is dropped.
+type Constraint struct {
+	mode    string
+	numbers *NumberNormalizer // nil if the language has no number normalization
+}
+
+// NewConstraint creates the processor for a mode in ConstraintModes. Spoken
+// numbers are converted for the languages NumberNormalizer supports.
+func NewConstraint(mode, language, locale string) (*Constraint, error) {
+	if _, ok := constraintPrompts[mode]; !ok {
+		return nil, fmt.Errorf("unknown mode %q (available: %s)", mode, strings.Join(ConstraintModes, ", "))
+	}
+	numbers := NewNumberNormalizer(language, locale)
+	if numbers != nil {
+		numbers.allDigits = true // "john two" is "john2", a lone "five" is the value
+	}
+	return &Constraint{mode: mode, numbers: numbers}, nil
+}
+
+// ConstraintPrompt returns the whisper prompt for a mode (empty if unknown)
+func ConstraintPrompt(mode string) string {
+	return constraintPrompts[mode]
+}
+
+// Name returns the processor name
+func (c *Constraint) Name() string { return "constrain-" + c.mode }
+
+// Process extracts the value, or returns "" if the dictation has none
+func (c *Constraint) Process(text string) string {
+	if c.numbers != nil {
+		text = c.numbers.Process(text)
+	}
+
+	switch c.mode {
+	case "number":
+		text = negativeNumber.ReplaceAllString(text, "-$1")
+		return strings.Join(numberPattern.FindAllString(text, -1), " ")
+	case "email":
+		text = strings.ToLower(replaceSymbols(text, emailSymbols))
+		return strings.Trim(emailInvalid.ReplaceAllString(text, ""), ".-")
+	case "url":
+		text = urlInvalid.ReplaceAllString(replaceSymbols(text, urlSymbols), "")
+		return lowerHost(strings.TrimRight(text, ".,;:!?"))
+	}
+	return text
+}
+
+// replaceSymbols turns spoken symbols into characters
+func replaceSymbols(text string, symbols []spokenSymbol) string {
+	for _, s := range symbols {
+		text = s.pattern.ReplaceAllString(text, s.symbol)
+	}
+	return text
+}
+
+// lowerHost lowercases the scheme and host of a URL, paths are case-sensitive
+func lowerHost(url string) string {
+	start := 0
+	if i := strings.Index(url, "://"); i >= 0 {
+		start = i + 3
+	}
+	end := len(url)
+	if i := strings.IndexAny(url[start:], "/?#"); i >= 0 {
+		end = start + i
+	}
+	return strings.ToLower(url[:end]) + url[end:]
+}
@@ -0,0 +1,134 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultFillers are hesitation sounds and phrases removed by the filler filter
+var DefaultFillers = []string{
+	"um", "umm", "uh", "uhh", "uhm", "erm", "ehm", "hmm",
+	"you know",
+}
+
+// repeatAllowed are words that are legitimately repeated ("I know that that works")
+var repeatAllowed = map[string]bool{
+	"that": true, "had": true, "is": true, "very": true, "no": true, "bye": true,
+}
+
+// FillerFilter removes filler words, filler phrases and accidental word repetitions
+type FillerFilter struct {
+	fillers [][]string // each filler split into normalized words
+}
+
+// NewFillerFilter creates a filler filter using the default fillers plus extra phrases
+func NewFillerFilter(extra []string) *FillerFilter {
+	f := &FillerFilter{}
+	for _, phrase := range append(append([]string{}, DefaultFillers...), extra...) {
+		words := strings.Fields(strings.ToLower(phrase))
+		if len(words) > 0 {
+			f.fillers = append(f.fillers, words)
+		}
+	}
+	return f
+}
+
+// Name returns the processor name
+func (f *FillerFilter) Name() string { return "remove-fillers" }
+
+// Process removes fillers and repetitions from the text
+func (f *FillerFilter) Process(text string) string {
+	words := strings.Fields(text)
+	out := make([]string, 0, len(words))
+	capitalizeNext := false
+
+	for i := 0; i < len(words); {
+		if n := f.matchFiller(words, i); n > 0 {
+			last := words[i+n-1]
+			punct := trailingPunct(last)
+
+			// Fillers at the start of a sentence: capitalize whatever follows
+			first, _ := utf8.DecodeRuneInString(words[i])
+			if (len(out) == 0 || endsSentence(out[len(out)-1])) && unicode.IsUpper(first) {
+				capitalizeNext = true
+			}
+
+			if len(out) > 0 {
+				prev := out[len(out)-1]
+				// Drop the comma that introduced the filler ("it was, um, fine" -> "it was fine")
+				if strings.HasSuffix(prev, ",") && (punct != "" || i+n == len(words)) {
+					prev = strings.TrimSuffix(prev, ",")
+				}
+				// Keep the sentence end the filler carried ("it works, um." -> "it works.")
+				if strings.ContainsAny(punct, ".!?") && trailingPunct(prev) == "" {
+					prev += punct
+				}
+				out[len(out)-1] = prev
+			}
+			i += n
+			continue
+		}
+
+		word := words[i]
+		if capitalizeNext {
+			word = capitalize(word)
+			capitalizeNext = false
+		}
+
+		// Collapse accidental repetitions ("the the" -> "the")
+		if len(out) > 0 {
+			prev := out[len(out)-1]
+			norm := normalizeWord(word)
+			if norm != "" && trailingPunct(prev) == "" && normalizeWord(prev) == norm && !repeatAllowed[norm] {
+				out[len(out)-1] = prev + trailingPunct(word)
+				i++
+				continue
+			}
+		}
+
+		out = append(out, word)
+		i++
+	}
+
+	return strings.Join(out, " ")
+}
+
+// matchFiller returns the number of words a filler matches at position i (0 = no match)
+func (f *FillerFilter) matchFiller(words []string, i int) int {
+	for _, filler := range f.fillers {
+		n := len(filler)
+		if i+n > len(words) {
+			continue
+		}
+
+		matched := true
+		for j, fw := range filler {
+			// Punctuation inside a multi-word phrase breaks it up
+			if j < n-1 && trailingPunct(words[i+j]) != "" {
+				matched = false
+				break
+			}
+			if normalizeWord(words[i+j]) != fw {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		// Multi-word phrases like "you know" are only fillers when set off by punctuation,
+		// so "do you know the way" stays intact
+		if n > 1 {
+			lastPunct := trailingPunct(words[i+n-1])
+			startsClause := i == 0 || trailingPunct(words[i-1]) != ""
+			endsClause := lastPunct != "" || i+n == len(words)
+			if lastPunct != "," && !(startsClause && endsClause) {
+				continue
+			}
+		}
+		return n
+	}
+	return 0
+}
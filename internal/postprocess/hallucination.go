@@ -0,0 +1,47 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultHallucinations are texts whisper is known to produce for silence,
+// breathing and background noise, learned from subtitled videos. A trailing
+// "*" matches any ending.
+var DefaultHallucinations = []string{
+	"you",
+	"thanks for watching", "thank you for watching", "thank you so much for watching",
+	"please subscribe", "like and subscribe", "subscribe to my channel",
+	"subtitles by*", "transcription by*", "captions by*",
+	"untertitel*", "untertitelung*", "vielen dank fürs zuschauen",
+	"sous-titres*", "sous-titrage*", "merci d'avoir regardé",
+}
+
+// IsHallucination reports whether text as a whole is one of the default
+// hallucinations or the extra phrases. Case and punctuation are ignored.
+func IsHallucination(text string, extra []string) bool {
+	text = normalizeHallucination(text)
+	if text == "" {
+		return false
+	}
+	for _, phrase := range append(append([]string{}, DefaultHallucinations...), extra...) {
+		prefix := strings.HasSuffix(phrase, "*")
+		phrase = normalizeHallucination(strings.TrimSuffix(phrase, "*"))
+		if phrase == "" {
+			continue
+		}
+		if text == phrase || prefix && strings.HasPrefix(text, phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHallucination lowercases text and reduces it to its words, so
+// "Thanks for watching!" and "thanks, for watching" compare equal
+func normalizeHallucination(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,450 @@
+package postprocess
+
+import (
+	"strconv"
+	"strings"
+)
+
+// wordKind classifies number words for combining them into a value
+type wordKind int
+
+const (
+	kindNone      wordKind = iota
+	kindZero               // zero
+	kindUnit               // 1-9
+	kindTeen               // 10-19
+	kindTens               // 20, 30, ... 90
+	kindHundred            // hundred
+	kindScale              // thousand, million, billion
+	kindComposite          // a single word holding a complete value (German "fünfundzwanzig")
+)
+
+// numWord is a parsed number word
+type numWord struct {
+	kind    wordKind
+	value   int64
+	ordinal bool // "third", "dritten"
+}
+
+// numberLanguage describes how numbers are spoken and written in a language
+type numberLanguage struct {
+	parse        func(word string) (numWord, bool)
+	and          string // filler allowed inside numbers ("one hundred and five")
+	decimalWords []string
+	percentWords []string
+	currencies   map[string]string // spoken currency -> symbol
+	currencyPre  bool              // "$10" (true) or "10 €" (false)
+	percentSpace bool              // "25 %" (German) vs "25%"
+	decimalSep   string
+	months       map[string]string // spoken month -> written month
+	dayFirst     bool              // "3. März" vs "March 3"
+	ordinal      func(n int64) string
+	dateOf       string // "the third of March"
+}
+
+// numberFormat is how a locale writes numbers
+type numberFormat struct {
+	decimalSep   string
+	percentSpace bool
+	currencyPre  bool
+}
+
+// localeFormats maps locales (or their primary language) to their number format
+var localeFormats = map[string]numberFormat{
+	"en":    {".", false, true},
+	"de":    {",", true, false},
+	"de-ch": {".", true, false},
+	"fr":    {",", true, false},
+	"es":    {",", true, false},
+	"it":    {",", true, false},
+	"nl":    {",", false, true},
+	"pl":    {",", true, false},
+	"pt":    {",", true, false},
+	"ru":    {",", true, false},
+}
+
+// normalizeLocale turns "de_DE.UTF-8" or "de-DE" into "de-de"
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+// primaryLanguage returns the language part of a normalized locale ("de-ch" -> "de")
+func primaryLanguage(locale string) string {
+	if i := strings.Index(locale, "-"); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// localeFormat returns the number format for a locale, trying the full locale first
+func localeFormat(locale string) (numberFormat, bool) {
+	locale = normalizeLocale(locale)
+	if f, ok := localeFormats[locale]; ok {
+		return f, true
+	}
+	f, ok := localeFormats[primaryLanguage(locale)]
+	return f, ok
+}
+
+// NumberNormalizer converts spoken numbers, percentages, currency and dates to digits
+type NumberNormalizer struct {
+	lang      *numberLanguage
+	allDigits bool // also write small standalone numbers as digits
+}
+
+// NewNumberNormalizer creates a number normalizer for a language code ("en", "de").
+// locale selects how numbers are written (decimal separator, percent and currency
+// placement), empty = the language's own conventions.
+// Returns nil if the language is not supported.
+func NewNumberNormalizer(language, locale string) *NumberNormalizer {
+	lang, ok := numberLanguages()[strings.ToLower(language)]
+	if !ok {
+		return nil
+	}
+	if f, ok := localeFormat(locale); ok && locale != "" {
+		custom := *lang
+		custom.decimalSep = f.decimalSep
+		custom.percentSpace = f.percentSpace
+		custom.currencyPre = f.currencyPre
+		lang = &custom
+	}
+	return &NumberNormalizer{lang: lang}
+}
+
+// SupportsNumberLanguage returns whether number normalization supports a language
+func SupportsNumberLanguage(language string) bool {
+	_, ok := numberLanguages()[strings.ToLower(language)]
+	return ok
+}
+
+// Name returns the processor name
+func (n *NumberNormalizer) Name() string { return "normalize-numbers" }
+
+// token is a whitespace separated word split into its parts
+type token struct {
+	raw   string
+	word  string // lowercase, surrounding punctuation removed
+	punct string // trailing punctuation
+}
+
+func tokenize(text string) []token {
+	fields := strings.Fields(text)
+	tokens := make([]token, len(fields))
+	for i, f := range fields {
+		tokens[i] = token{raw: f, word: normalizeWord(f), punct: trailingPunct(f)}
+	}
+	return tokens
+}
+
+// phrase is a parsed run of number words
+type phrase struct {
+	value   int64
+	ordinal bool
+	small   bool // a single word below ten ("one", "ein") - kept as a word by default
+	start   int  // index of the first token
+	end     int  // index after the last token
+	punct   string
+}
+
+// Process rewrites number words in the text
+func (n *NumberNormalizer) Process(text string) string {
+	tokens := tokenize(text)
+	out := make([]string, 0, len(tokens))
+
+	for i := 0; i < len(tokens); {
+		p, ok := n.parsePhrase(tokens, i)
+		if !ok {
+			out = append(out, tokens[i].raw)
+			i++
+			continue
+		}
+
+		written, next := n.render(tokens, p, &out)
+		out = append(out, written)
+		i = next
+	}
+
+	return strings.Join(out, " ")
+}
+
+// parsePhrase parses consecutive number words starting at i
+func (n *NumberNormalizer) parsePhrase(tokens []token, i int) (phrase, bool) {
+	var total, current int64
+	var prev wordKind
+	var lastScale int64
+	words := 0
+	p := phrase{start: i}
+
+	j := i
+	for j < len(tokens) {
+		t := tokens[j]
+
+		// "one hundred and five"
+		if n.lang.and != "" && t.word == n.lang.and && (prev == kindHundred || prev == kindScale) && t.punct == "" && j+1 < len(tokens) {
+			if w, ok := n.lang.parse(tokens[j+1].word); ok && (w.kind == kindUnit || w.kind == kindTeen || w.kind == kindTens) {
+				j++
+				continue
+			}
+			break
+		}
+
+		// English allows hyphenated numbers ("twenty-five")
+		parts := []string{t.word}
+		if strings.Contains(t.word, "-") {
+			parts = strings.Split(t.word, "-")
+		}
+
+		parsed := make([]numWord, 0, len(parts))
+		for _, part := range parts {
+			w, ok := n.lang.parse(part)
+			if !ok {
+				parsed = nil
+				break
+			}
+			parsed = append(parsed, w)
+		}
+		if parsed == nil {
+			break
+		}
+
+		// Check the words may follow each other, otherwise the number ends here
+		valid := true
+		for _, w := range parsed {
+			if !canFollow(prev, w, lastScale, words == 0) {
+				valid = false
+				break
+			}
+			switch w.kind {
+			case kindZero, kindUnit, kindTeen, kindTens, kindComposite:
+				current += w.value
+			case kindHundred:
+				if current == 0 {
+					current = 1
+				}
+				current *= 100
+			case kindScale:
+				if current == 0 {
+					current = 1
+				}
+				total += current * w.value
+				current = 0
+				lastScale = w.value
+			}
+			prev = w.kind
+			words++
+			if w.ordinal {
+				p.ordinal = true
+			}
+		}
+		if !valid {
+			break
+		}
+
+		j++
+		p.punct = t.punct
+		// Ordinals and punctuation end a number
+		if p.ordinal || t.punct != "" {
+			break
+		}
+	}
+
+	if words == 0 {
+		return phrase{}, false
+	}
+
+	p.value = total + current
+	p.end = j
+	p.small = words == 1 && p.value < 10
+	return p, true
+}
+
+// canFollow returns whether a number word may follow the previous one
+func canFollow(prev wordKind, w numWord, lastScale int64, first bool) bool {
+	if first {
+		return w.kind != kindHundred && w.kind != kindScale
+	}
+	switch prev {
+	case kindZero:
+		return false
+	case kindUnit, kindTeen:
+		return w.kind == kindHundred || w.kind == kindScale
+	case kindTens:
+		return w.kind == kindUnit || w.kind == kindScale
+	case kindHundred:
+		return w.kind == kindUnit || w.kind == kindTeen || w.kind == kindTens || w.kind == kindScale
+	case kindScale:
+		if w.kind == kindScale {
+			return false
+		}
+		return w.kind == kindUnit || w.kind == kindTeen || w.kind == kindTens || w.kind == kindComposite
+	case kindComposite:
+		return w.kind == kindScale && (lastScale == 0 || w.value < lastScale)
+	}
+	return false
+}
+
+// render formats a parsed phrase including following percent/currency/decimal/date words.
+// It may rewrite already emitted output (e.g. a preceding month name) and returns the
+// written text and the index of the next unconsumed token.
+func (n *NumberNormalizer) render(tokens []token, p phrase, out *[]string) (string, int) {
+	next := p.end
+	lang := n.lang
+
+	// Dates: "March third" / "dritter März" / "the third of March"
+	if p.value >= 1 && p.value <= 31 {
+		if p.start > 0 && !lang.dayFirst {
+			prevTok := tokens[p.start-1]
+			if month, ok := n.month(prevTok); ok && prevTok.punct == "" {
+				(*out)[len(*out)-1] = month
+				return n.withYear(tokens, strconv.FormatInt(p.value, 10), p, ", ")
+			}
+		}
+		if p.ordinal && p.punct == "" && next < len(tokens) {
+			monthIdx := next
+			if lang.dateOf != "" && tokens[next].word == lang.dateOf && next+1 < len(tokens) {
+				monthIdx = next + 1
+			}
+			if month, ok := n.month(tokens[monthIdx]); ok {
+				day := strconv.FormatInt(p.value, 10)
+				// "on the third of March" -> "on March 3"
+				if monthIdx != next && len(*out) > 0 && normalizeWord((*out)[len(*out)-1]) == "the" {
+					*out = (*out)[:len(*out)-1]
+				}
+				mp := phrase{end: monthIdx + 1, punct: tokens[monthIdx].punct}
+				if lang.dayFirst {
+					return n.withYear(tokens, day+". "+month, mp, " ")
+				}
+				return n.withYear(tokens, month+" "+day, mp, ", ")
+			}
+		}
+	}
+
+	// Years spoken as two pairs: "nineteen eighty four", "twenty twenty"
+	if !p.ordinal && p.value >= 15 && p.value <= 20 {
+		if y, ok := n.parseYear(tokens, p.start); ok && y.end > p.end {
+			return strconv.FormatInt(y.value, 10) + y.punct, y.end
+		}
+	}
+
+	if p.ordinal {
+		if p.value < 10 {
+			return n.rawPhrase(tokens, p), next
+		}
+		return lang.ordinal(p.value) + p.punct, next
+	}
+
+	digits := strconv.FormatInt(p.value, 10)
+	punct := p.punct
+
+	// Decimals: "three point five" / "drei Komma fünf"
+	if punct == "" && next+1 < len(tokens) && contains(lang.decimalWords, tokens[next].word) && tokens[next].punct == "" {
+		fraction := ""
+		k := next + 1
+		for k < len(tokens) {
+			w, ok := lang.parse(tokens[k].word)
+			if !ok || (w.kind != kindUnit && w.kind != kindZero) || w.ordinal {
+				break
+			}
+			fraction += strconv.FormatInt(w.value, 10)
+			punct = tokens[k].punct
+			k++
+			if punct != "" {
+				break
+			}
+		}
+		if fraction != "" {
+			digits += lang.decimalSep + fraction
+			next = k
+			p.small = false
+		}
+	}
+
+	// Percentages and currency
+	if punct == "" && next < len(tokens) {
+		word := tokens[next].word
+		if next+1 < len(tokens) && word == "per" && tokens[next+1].word == "cent" {
+			word = "percent"
+			next++
+		}
+		if contains(lang.percentWords, word) {
+			if lang.percentSpace {
+				return digits + " %" + tokens[next].punct, next + 1
+			}
+			return digits + "%" + tokens[next].punct, next + 1
+		}
+		if symbol, ok := lang.currencies[word]; ok {
+			if lang.currencyPre {
+				return symbol + digits + tokens[next].punct, next + 1
+			}
+			return digits + " " + symbol + tokens[next].punct, next + 1
+		}
+	}
+
+	// Keep small standalone numbers as words ("one question", "ein Haus")
+	if p.small && !n.allDigits {
+		return n.rawPhrase(tokens, p), p.end
+	}
+
+	return digits + punct, next
+}
+
+// withYear appends a following year ("March 3, 2024") to a rendered date
+func (n *NumberNormalizer) withYear(tokens []token, date string, p phrase, sep string) (string, int) {
+	if p.punct == "" && p.end < len(tokens) {
+		if y, ok := n.parseYear(tokens, p.end); ok {
+			return date + sep + strconv.FormatInt(y.value, 10) + y.punct, y.end
+		}
+	}
+	return date + p.punct, p.end
+}
+
+// parseYear parses a year either as a full number ("two thousand twenty four")
+// or as two pairs ("twenty twenty four", "nineteen eighty")
+func (n *NumberNormalizer) parseYear(tokens []token, i int) (phrase, bool) {
+	first, ok := n.parsePhrase(tokens, i)
+	if !ok || first.ordinal {
+		return phrase{}, false
+	}
+	if first.value >= 1000 && first.value <= 2999 {
+		return first, true
+	}
+	if first.value >= 11 && first.value <= 20 && first.punct == "" && first.end < len(tokens) {
+		second, ok := n.parsePhrase(tokens, first.end)
+		if ok && !second.ordinal && second.value >= 10 && second.value <= 99 {
+			second.value += first.value * 100
+			return second, true
+		}
+	}
+	return phrase{}, false
+}
+
+// month returns the written month for a capitalized month token
+func (n *NumberNormalizer) month(t token) (string, bool) {
+	month, ok := n.lang.months[t.word]
+	if !ok || t.raw == "" || strings.ToLower(t.raw[:1]) == t.raw[:1] {
+		return "", false
+	}
+	return month, true
+}
+
+// rawPhrase returns the original words of a phrase
+func (n *NumberNormalizer) rawPhrase(tokens []token, p phrase) string {
+	raws := make([]string, 0, p.end-p.start)
+	for _, t := range tokens[p.start:p.end] {
+		raws = append(raws, t.raw)
+	}
+	return strings.Join(raws, " ")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package postprocess
+
+import (
+	"strconv"
+	"strings"
+)
+
+// numberLanguages returns the supported number languages keyed by whisper language code
+func numberLanguages() map[string]*numberLanguage {
+	return map[string]*numberLanguage{
+		"en": english,
+		"de": german,
+	}
+}
+
+var englishCardinals = map[string]numWord{
+	"zero": {kindZero, 0, false}, "oh": {kindZero, 0, false},
+	"one": {kindUnit, 1, false}, "two": {kindUnit, 2, false}, "three": {kindUnit, 3, false},
+	"four": {kindUnit, 4, false}, "five": {kindUnit, 5, false}, "six": {kindUnit, 6, false},
+	"seven": {kindUnit, 7, false}, "eight": {kindUnit, 8, false}, "nine": {kindUnit, 9, false},
+	"ten": {kindTeen, 10, false}, "eleven": {kindTeen, 11, false}, "twelve": {kindTeen, 12, false},
+	"thirteen": {kindTeen, 13, false}, "fourteen": {kindTeen, 14, false}, "fifteen": {kindTeen, 15, false},
+	"sixteen": {kindTeen, 16, false}, "seventeen": {kindTeen, 17, false}, "eighteen": {kindTeen, 18, false},
+	"nineteen": {kindTeen, 19, false},
+	"twenty":   {kindTens, 20, false}, "thirty": {kindTens, 30, false}, "forty": {kindTens, 40, false},
+	"fifty": {kindTens, 50, false}, "sixty": {kindTens, 60, false}, "seventy": {kindTens, 70, false},
+	"eighty": {kindTens, 80, false}, "ninety": {kindTens, 90, false},
+	"hundred":  {kindHundred, 100, false},
+	"thousand": {kindScale, 1000, false}, "million": {kindScale, 1000000, false}, "billion": {kindScale, 1000000000, false},
+}
+
+var englishOrdinals = map[string]numWord{
+	"first": {kindUnit, 1, true}, "second": {kindUnit, 2, true}, "third": {kindUnit, 3, true},
+	"fourth": {kindUnit, 4, true}, "fifth": {kindUnit, 5, true}, "sixth": {kindUnit, 6, true},
+	"seventh": {kindUnit, 7, true}, "eighth": {kindUnit, 8, true}, "ninth": {kindUnit, 9, true},
+	"tenth": {kindTeen, 10, true}, "eleventh": {kindTeen, 11, true}, "twelfth": {kindTeen, 12, true},
+	"thirteenth": {kindTeen, 13, true}, "fourteenth": {kindTeen, 14, true}, "fifteenth": {kindTeen, 15, true},
+	"sixteenth": {kindTeen, 16, true}, "seventeenth": {kindTeen, 17, true}, "eighteenth": {kindTeen, 18, true},
+	"nineteenth": {kindTeen, 19, true},
+	"twentieth":  {kindTens, 20, true}, "thirtieth": {kindTens, 30, true}, "fortieth": {kindTens, 40, true},
+	"fiftieth": {kindTens, 50, true}, "sixtieth": {kindTens, 60, true}, "seventieth": {kindTens, 70, true},
+	"eightieth": {kindTens, 80, true}, "ninetieth": {kindTens, 90, true},
+	"hundredth":  {kindHundred, 100, true},
+	"thousandth": {kindScale, 1000, true}, "millionth": {kindScale, 1000000, true},
+}
+
+var english = &numberLanguage{
+	parse: func(word string) (numWord, bool) {
+		if w, ok := englishCardinals[word]; ok {
+			return w, true
+		}
+		w, ok := englishOrdinals[word]
+		return w, ok
+	},
+	and:          "and",
+	decimalWords: []string{"point"},
+	percentWords: []string{"percent"},
+	currencies: map[string]string{
+		"dollars": "$", "dollar": "$", "euros": "€", "euro": "€",
+	},
+	currencyPre: true,
+	decimalSep:  ".",
+	months: map[string]string{
+		"january": "January", "february": "February", "march": "March", "april": "April",
+		"may": "May", "june": "June", "july": "July", "august": "August",
+		"september": "September", "october": "October", "november": "November", "december": "December",
+	},
+	ordinal: func(n int64) string {
+		suffix := "th"
+		switch {
+		case n%100 >= 11 && n%100 <= 13:
+		case n%10 == 1:
+			suffix = "st"
+		case n%10 == 2:
+			suffix = "nd"
+		case n%10 == 3:
+			suffix = "rd"
+		}
+		return strconv.FormatInt(n, 10) + suffix
+	},
+	dateOf: "of",
+}
+
+var germanBelowHundred = map[string]int64{
+	"null": 0, "eins": 1, "ein": 1, "eine": 1, "zwei": 2, "drei": 3, "vier": 4, "fünf": 5,
+	"sechs": 6, "sieben": 7, "acht": 8, "neun": 9, "zehn": 10, "elf": 11, "zwölf": 12,
+	"dreizehn": 13, "vierzehn": 14, "fünfzehn": 15, "sechzehn": 16, "siebzehn": 17,
+	"achtzehn": 18, "neunzehn": 19, "zwanzig": 20, "dreißig": 30, "vierzig": 40,
+	"fünfzig": 50, "sechzig": 60, "siebzig": 70, "achtzig": 80, "neunzig": 90,
+}
+
+// germanUnitsInCompounds are the unit forms used before "und" ("einundzwanzig")
+var germanUnitsInCompounds = map[string]int64{
+	"ein": 1, "zwei": 2, "drei": 3, "vier": 4, "fünf": 5, "sechs": 6, "sieben": 7, "acht": 8, "neun": 9,
+}
+
+// parseGermanCompound parses a complete German number word ("zweihundertfünfundzwanzig")
+func parseGermanCompound(word string) (int64, bool) {
+	if word == "" {
+		return 0, false
+	}
+	if v, ok := germanBelowHundred[word]; ok {
+		return v, true
+	}
+
+	// Scales written into the word: "zweitausend...", "dreihundert..."
+	for _, scale := range []struct {
+		name  string
+		value int64
+	}{{"tausend", 1000}, {"hundert", 100}} {
+		idx := strings.Index(word, scale.name)
+		if idx < 0 {
+			continue
+		}
+		left, right := word[:idx], word[idx+len(scale.name):]
+
+		multiplier := int64(1)
+		if left != "" {
+			m, ok := parseGermanCompound(left)
+			if !ok || m == 0 {
+				return 0, false
+			}
+			multiplier = m
+		}
+		rest := int64(0)
+		if right != "" {
+			// "hunderteins" ends in "eins", "tausendundeins" may contain "und"
+			right = strings.TrimPrefix(right, "und")
+			r, ok := parseGermanCompound(right)
+			if !ok {
+				return 0, false
+			}
+			rest = r
+		}
+		return multiplier*scale.value + rest, true
+	}
+
+	// "fünfundzwanzig"
+	if idx := strings.Index(word, "und"); idx > 0 {
+		unit, ok := germanUnitsInCompounds[word[:idx]]
+		tens, ok2 := germanBelowHundred[word[idx+3:]]
+		if ok && ok2 && tens >= 20 && tens%10 == 0 {
+			return tens + unit, true
+		}
+	}
+	return 0, false
+}
+
+// germanOrdinalStems maps irregular ordinal stems to their value
+var germanOrdinalStems = map[string]int64{"erst": 1, "dritt": 3, "siebt": 7, "acht": 8}
+
+// parseGermanOrdinal parses ordinals like "dritte", "dritten", "zwanzigster"
+func parseGermanOrdinal(word string) (int64, bool) {
+	for _, ending := range []string{"en", "er", "es", "em", "e"} {
+		stem := strings.TrimSuffix(word, ending)
+		if stem == word {
+			continue
+		}
+		if v, ok := germanOrdinalStems[stem]; ok {
+			return v, true
+		}
+		// 1-19 add "t" ("vierte"), 20+ add "st" ("zwanzigste")
+		if s := strings.TrimSuffix(stem, "st"); s != stem {
+			if v, ok := parseGermanCompound(s); ok && v >= 20 {
+				return v, true
+			}
+		}
+		if s := strings.TrimSuffix(stem, "t"); s != stem {
+			if v, ok := parseGermanCompound(s); ok && v >= 2 && v < 20 {
+				return v, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func germanKind(v int64) wordKind {
+	switch {
+	case v == 0:
+		return kindZero
+	case v < 10:
+		return kindUnit
+	default:
+		return kindComposite
+	}
+}
+
+var german = &numberLanguage{
+	parse: func(word string) (numWord, bool) {
+		switch word {
+		case "million", "millionen":
+			return numWord{kindScale, 1000000, false}, true
+		case "milliarde", "milliarden":
+			return numWord{kindScale, 1000000000, false}, true
+		}
+		if v, ok := parseGermanCompound(word); ok {
+			return numWord{germanKind(v), v, false}, true
+		}
+		if v, ok := parseGermanOrdinal(word); ok {
+			return numWord{germanKind(v), v, true}, true
+		}
+		return numWord{}, false
+	},
+	decimalWords: []string{"komma"},
+	percentWords: []string{"prozent"},
+	currencies: map[string]string{
+		"euro": "€", "dollar": "$",
+	},
+	currencyPre:  false,
+	percentSpace: true,
+	decimalSep:   ",",
+	months: map[string]string{
+		"januar": "Januar", "februar": "Februar", "märz": "März", "april": "April",
+		"mai": "Mai", "juni": "Juni", "juli": "Juli", "august": "August",
+		"september": "September", "oktober": "Oktober", "november": "November", "dezember": "Dezember",
+	},
+	dayFirst: true,
+	ordinal: func(n int64) string {
+		return strconv.FormatInt(n, 10) + "."
+	},
+}
@@ -0,0 +1,75 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Processor transforms transcribed text before it is injected or executed
+type Processor interface {
+	Name() string
+	Process(text string) string
+}
+
+// Chain runs processors in order
+type Chain []Processor
+
+// Process runs the text through every processor of the chain
+func (c Chain) Process(text string) string {
+	for _, p := range c {
+		text = p.Process(text)
+	}
+	return strings.TrimSpace(text)
+}
+
+// Names returns the processor names in order
+func (c Chain) Names() []string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// TrailingPeriodStripper removes a single trailing "." (useful for terminals)
+type TrailingPeriodStripper struct{}
+
+// Name returns the processor name
+func (TrailingPeriodStripper) Name() string { return "strip-trailing-period" }
+
+// Process removes the trailing period
+func (TrailingPeriodStripper) Process(text string) string {
+	text = strings.TrimRight(text, " ")
+	if strings.HasSuffix(text, "...") {
+		return text
+	}
+	return strings.TrimSuffix(text, ".")
+}
+
+// normalizeWord lowercases a word and strips surrounding punctuation
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) && r != '\'' && r != '-'
+	}))
+}
+
+// trailingPunct returns the punctuation a word ends with (e.g. "," or "?")
+func trailingPunct(word string) string {
+	trimmed := strings.TrimRightFunc(word, unicode.IsPunct)
+	return word[len(trimmed):]
+}
+
+// endsSentence returns whether a word ends a sentence
+func endsSentence(word string) bool {
+	return strings.ContainsAny(trailingPunct(word), ".!?")
+}
+
+// capitalize upper-cases the first letter of a word
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,37 @@
+package postprocess
+
+import (
+	"strings"
+	"time"
+)
+
+// Prefix prepends a template such as "[{time}] " to the text. Placeholders:
+// {time} (14:32), {seconds} (14:32:05), {date} (2024-05-01), {weekday} (Monday)
+// and {app} (class of the window the text goes to).
+type Prefix struct {
+	Template string
+	App      string
+	Now      func() time.Time // nil = time.Now
+}
+
+// Name returns the processor name
+func (Prefix) Name() string { return "prefix" }
+
+// Process prepends the expanded template
+func (p Prefix) Process(text string) string {
+	if p.Template == "" {
+		return text
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	t := now()
+	return strings.NewReplacer(
+		"{time}", t.Format("15:04"),
+		"{seconds}", t.Format("15:04:05"),
+		"{date}", t.Format("2006-01-02"),
+		"{weekday}", t.Weekday().String(),
+		"{app}", p.App,
+	).Replace(p.Template) + text
+}
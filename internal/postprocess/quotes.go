@@ -0,0 +1,90 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+)
+
+// quoteStyle holds the typographic quotes of a locale
+type quoteStyle struct {
+	open, close             string // double quotes
+	singleOpen, singleClose string
+	apostrophe              string
+}
+
+// quoteStyles maps locales (or their primary language) to their quote style
+var quoteStyles = map[string]quoteStyle{
+	"en":    {"“", "”", "‘", "’", "’"},
+	"de":    {"„", "“", "‚", "‘", "’"},
+	"de-ch": {"«", "»", "‹", "›", "’"},
+	"fr":    {"«\u202f", "\u202f»", "‹\u202f", "\u202f›", "’"}, // narrow no-break spaces
+	"es":    {"«", "»", "“", "”", "’"},
+	"it":    {"«", "»", "“", "”", "’"},
+	"nl":    {"“", "”", "‘", "’", "’"},
+	"pl":    {"„", "”", "‚", "’", "’"},
+	"pt":    {"“", "”", "‘", "’", "’"},
+	"ru":    {"«", "»", "„", "“", "’"},
+	"cs":    {"„", "“", "‚", "‘", "’"},
+	"sv":    {"”", "”", "’", "’", "’"},
+}
+
+// SmartQuotes replaces straight quotes and apostrophes with a locale's typographic ones
+type SmartQuotes struct {
+	style quoteStyle
+}
+
+// NewSmartQuotes creates a smart quote processor for a locale ("de", "en-US", "de_CH").
+// Returns nil if the locale has no known quote style.
+func NewSmartQuotes(locale string) *SmartQuotes {
+	locale = normalizeLocale(locale)
+	style, ok := quoteStyles[locale]
+	if !ok {
+		style, ok = quoteStyles[primaryLanguage(locale)]
+	}
+	if !ok {
+		return nil
+	}
+	return &SmartQuotes{style: style}
+}
+
+// Name returns the processor name
+func (q *SmartQuotes) Name() string { return "smart-quotes" }
+
+// Process replaces the quotes in text
+func (q *SmartQuotes) Process(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	doubleOpen, singleOpen := false, false
+
+	for i, r := range runes {
+		// A quote opens at the start or after whitespace/opening brackets, otherwise it closes
+		opening := i == 0 || unicode.IsSpace(runes[i-1]) || strings.ContainsRune("([{—–", runes[i-1])
+		followedBySpace := i == len(runes)-1 || unicode.IsSpace(runes[i+1]) || unicode.IsPunct(runes[i+1])
+
+		switch r {
+		case '"':
+			if !doubleOpen && opening {
+				b.WriteString(q.style.open)
+				doubleOpen = true
+			} else {
+				b.WriteString(q.style.close)
+				doubleOpen = false
+			}
+		case '\'':
+			switch {
+			case opening && !followedBySpace:
+				b.WriteString(q.style.singleOpen)
+				singleOpen = true
+			case singleOpen && followedBySpace:
+				b.WriteString(q.style.singleClose)
+				singleOpen = false
+			default:
+				// "don't", "geht's", "the '90s"
+				b.WriteString(q.style.apostrophe)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+)
+
+// vocabularyFuzzyThreshold is how alike a transcribed word must be to a term
+// (1 - edit distance / length) to be corrected. Only terms of at least
+// vocabularyFuzzyMinLength letters are matched fuzzily, shorter ones are too
+// close to everyday words.
+const (
+	vocabularyFuzzyThreshold = 0.8
+	vocabularyFuzzyMinLength = 6
+)
+
+// Vocabulary corrects the spelling of names, jargon and product terms whisper
+// got almost right: "kubernetes" -> "Kubernetes", "Chat GPT" -> "ChatGPT",
+// "Kubernetis" -> "Kubernetes". Words are compared without case, spaces and
+// punctuation.
+type Vocabulary struct {
+	terms []vocabularyTerm
+}
+
+type vocabularyTerm struct {
+	text  string
+	key   []rune // normalized
+	words int    // words in text
+}
+
+// NewVocabulary creates a vocabulary corrector for terms
+func NewVocabulary(terms []string) *Vocabulary {
+	v := &Vocabulary{}
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if key := vocabularyKey(term); key != "" {
+			v.terms = append(v.terms, vocabularyTerm{text: term, key: []rune(key), words: len(strings.Fields(term))})
+		}
+	}
+	return v
+}
+
+// Name returns the processor name
+func (v *Vocabulary) Name() string { return "vocabulary" }
+
+// Process replaces the words matching a term with the term
+func (v *Vocabulary) Process(text string) string {
+	if len(v.terms) == 0 {
+		return text
+	}
+	words := strings.Fields(text)
+	out := make([]string, 0, len(words))
+	for i := 0; i < len(words); {
+		term, n := v.match(words, i)
+		if n == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+		first, last := words[i], words[i+n-1]
+		leading := first[:len(first)-len(strings.TrimLeftFunc(first, unicode.IsPunct))]
+		out = append(out, leading+term+trailingPunct(last))
+		i += n
+	}
+	return strings.Join(out, " ")
+}
+
+// match finds the term spelled by the words starting at i, preferring exact
+// matches and more words. Returns the number of words it covers, 0 if none.
+func (v *Vocabulary) match(words []string, i int) (string, int) {
+	best, bestWords, bestScore := "", 0, 0.0
+	for _, term := range v.terms {
+		// Spoken terms are often split up: "ChatGPT" -> "Chat GPT"
+		for n := term.words + 2; n >= 1; n-- {
+			if i+n > len(words) {
+				continue
+			}
+			key := []rune(vocabularyKey(strings.Join(words[i:i+n], "")))
+			score := 0.0
+			switch {
+			case string(key) == string(term.key):
+				score = 1
+			// The first letter must match, so a word before the term isn't swallowed
+			// ("a kubernetes"), and the term with an ending ("Kubernetes's") is left alone
+			case len(term.key) >= vocabularyFuzzyMinLength && n <= term.words+1 && len(key) > 0 && key[0] == term.key[0] &&
+				!strings.HasPrefix(string(key), string(term.key)):
+				score = 1 - float64(editDistance(key, term.key))/float64(maxInt(len(key), len(term.key)))
+				if score < vocabularyFuzzyThreshold {
+					score = 0
+				}
+			}
+			if score > bestScore || score > 0 && score == bestScore && n > bestWords {
+				best, bestWords, bestScore = term.text, n, score
+			}
+		}
+	}
+	return best, bestWords
+}
+
+// vocabularyKey lowercases s and drops everything but letters and digits
+func vocabularyKey(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// editDistance returns the number of single character edits between a and b
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,77 @@
+package priority
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// Options lower the priority of CPU-heavy work so the compositor and audio
+// keep running smoothly. Zero values leave the priority unchanged.
+type Options struct {
+	Nice     int    // Niceness 1-19, higher is lower priority
+	IOClass  string // "idle" or "best-effort", empty = unchanged
+	MaxProcs int    // GOMAXPROCS while running
+}
+
+// I/O scheduling classes for ioprio_set
+const (
+	ioprioClassBestEffort = 2
+	ioprioClassIdle       = 3
+	ioprioClassShift      = 13
+	ioprioWhoProcess      = 1
+)
+
+// Run runs fn with the given priority. Niceness and I/O priority are set on a
+// dedicated OS thread; threads fn starts (e.g. whisper's worker threads) inherit them.
+func Run(opts Options, fn func()) {
+	if opts == (Options{}) {
+		fn()
+		return
+	}
+
+	if opts.MaxProcs > 0 {
+		previous := runtime.GOMAXPROCS(opts.MaxProcs)
+		defer runtime.GOMAXPROCS(previous)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The thread is never unlocked, so it exits with this goroutine: without
+		// privileges a thread's priority can't be raised back for reuse
+		runtime.LockOSThread()
+		tid := syscall.Gettid()
+
+		if opts.Nice != 0 {
+			if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, opts.Nice); err != nil {
+				fmt.Printf("⚠️  Failed to set processing niceness: %v\n", err)
+			}
+		}
+		if opts.IOClass != "" {
+			if err := setIOClass(tid, opts.IOClass); err != nil {
+				fmt.Printf("⚠️  Failed to set processing I/O priority: %v\n", err)
+			}
+		}
+
+		fn()
+	}()
+	<-done
+}
+
+// setIOClass sets the I/O scheduling class of a thread, like ionice -c
+func setIOClass(tid int, class string) error {
+	var prio uintptr
+	switch class {
+	case "idle":
+		prio = ioprioClassIdle << ioprioClassShift
+	case "best-effort":
+		prio = ioprioClassBestEffort<<ioprioClassShift | 7 // Lowest level within the class
+	default:
+		return fmt.Errorf("unknown I/O class %q", class)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), prio); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// csvHeader names the columns of the metrics log
+var csvHeader = []string{"time", "duration_s", "speech_ratio", "transcribe_ms", "rtf", "confidence", "words", "model", "language"}
+
+// Utterance is the measurement of a single dictation for the metrics log.
+// Transcription fields are zero when nothing was transcribed (e.g. no voice).
+type Utterance struct {
+	Time        time.Time
+	Audio       time.Duration // Length of the recording
+	SpeechRatio float64       // Share of the recording the VAD kept, -1 without VAD
+	Wall        time.Duration // Time spent transcribing
+	Confidence  float32       // Mean word probability
+	Words       int
+	Model       string
+	Language    string
+}
+
+// CSVLog appends utterance metrics to a CSV file for offline analysis
+type CSVLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewCSVLog creates a metrics log writing to path
+func NewCSVLog(path string) *CSVLog {
+	return &CSVLog{path: path}
+}
+
+// Path returns the metrics file path
+func (l *CSVLog) Path() string {
+	return l.path
+}
+
+// Append adds a row, writing the header first if the file is new
+func (l *CSVLog) Append(u Utterance) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if dir := filepath.Dir(l.path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create metrics directory: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics log: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		w.Write(csvHeader)
+	}
+
+	row := []string{
+		u.Time.Format(time.RFC3339),
+		strconv.FormatFloat(u.Audio.Seconds(), 'f', 2, 64),
+		"",
+		"",
+		"",
+		"",
+		"",
+		u.Model,
+		u.Language,
+	}
+	if u.SpeechRatio >= 0 {
+		row[2] = strconv.FormatFloat(u.SpeechRatio, 'f', 3, 64)
+	}
+	if u.Wall > 0 {
+		row[3] = strconv.FormatInt(u.Wall.Milliseconds(), 10)
+		if u.Audio > 0 {
+			row[4] = strconv.FormatFloat(u.Wall.Seconds()/u.Audio.Seconds(), 'f', 3, 64)
+		}
+		row[5] = strconv.FormatFloat(float64(u.Confidence), 'f', 3, 64)
+		row[6] = strconv.Itoa(u.Words)
+	}
+	w.Write(row)
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxLatencies bounds the latencies kept per model for percentiles
+const maxLatencies = 1000
+
+// Sample is the measurement of a single transcription
+type Sample struct {
+	Model string
+	Audio time.Duration // Length of the transcribed audio
+	Wall  time.Duration // Time spent transcribing
+	Words int
+}
+
+// ModelStats aggregates the transcriptions of one model
+type ModelStats struct {
+	Model        string  `json:"model"`
+	Count        int     `json:"count"`
+	AudioSeconds float64 `json:"audio_seconds"`
+	WallSeconds  float64 `json:"wall_seconds"`
+	Words        int     `json:"words"`
+	RealTime     float64 `json:"real_time_factor"` // wall time / audio time (< 1 = faster than real time)
+	P50Ms        int64   `json:"p50_ms"`
+	P95Ms        int64   `json:"p95_ms"`
+	LastMs       int64   `json:"last_ms"`
+}
+
+// Summary holds the aggregates since the daemon started
+type Summary struct {
+	Since  time.Time    `json:"since"`
+	Device string       `json:"device,omitempty"` // Hardware transcribing, e.g. "CUDA GPU 1"
+	Total  ModelStats   `json:"total"`
+	Models []ModelStats `json:"models"`
+}
+
+type modelData struct {
+	stats     ModelStats
+	latencies []time.Duration
+}
+
+// Tracker collects transcription metrics
+type Tracker struct {
+	mu     sync.Mutex
+	since  time.Time
+	models map[string]*modelData
+	total  modelData
+}
+
+// NewTracker creates an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		since:  time.Now(),
+		models: make(map[string]*modelData),
+	}
+}
+
+// Record adds a transcription sample
+func (t *Tracker) Record(s Sample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, ok := t.models[s.Model]
+	if !ok {
+		data = &modelData{stats: ModelStats{Model: s.Model}}
+		t.models[s.Model] = data
+	}
+	data.add(s)
+	t.total.add(s)
+}
+
+func (d *modelData) add(s Sample) {
+	d.stats.Count++
+	d.stats.AudioSeconds += s.Audio.Seconds()
+	d.stats.WallSeconds += s.Wall.Seconds()
+	d.stats.Words += s.Words
+	d.stats.LastMs = s.Wall.Milliseconds()
+
+	d.latencies = append(d.latencies, s.Wall)
+	if len(d.latencies) > maxLatencies {
+		d.latencies = d.latencies[len(d.latencies)-maxLatencies:]
+	}
+}
+
+// summarize computes the derived values of the aggregate
+func (d *modelData) summarize() ModelStats {
+	s := d.stats
+	if s.AudioSeconds > 0 {
+		s.RealTime = s.WallSeconds / s.AudioSeconds
+	}
+	if len(d.latencies) > 0 {
+		sorted := append([]time.Duration(nil), d.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s.P50Ms = percentile(sorted, 0.50).Milliseconds()
+		s.P95Ms = percentile(sorted, 0.95).Milliseconds()
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(p*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// Summary returns the aggregates per model and in total
+func (t *Tracker) Summary() Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := Summary{Since: t.since, Total: t.total.summarize()}
+	summary.Total.Model = "all"
+	for _, data := range t.models {
+		summary.Models = append(summary.Models, data.summarize())
+	}
+	sort.Slice(summary.Models, func(i, j int) bool { return summary.Models[i].Model < summary.Models[j].Model })
+	return summary
+}
@@ -0,0 +1,153 @@
+package transcript
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pa/hyprwhspr/internal/hyprland"
+	"github.com/pa/hyprwhspr/internal/whisper"
+)
+
+// Result is a dictation on its way through the pipeline: the text every stage
+// works on together with what is known about it. Post-processing, the LLM and
+// formatting change Text, history and events get the whole result.
+type Result struct {
+	Text       string            `json:"text"` // Current text
+	Raw        string            `json:"raw"`  // Text as whisper transcribed it
+	Segments   []whisper.Segment `json:"-"`    // Whisper's segments with word confidences
+	Language   string            `json:"language,omitempty"`
+	Confidence float32           `json:"confidence"`        // Mean word probability (0-1)
+	Mode       string            `json:"mode,omitempty"`    // Constrained mode, empty = free dictation
+	Backend    string            `json:"backend"`           // Model that transcribed it
+	Profile    string            `json:"profile,omitempty"` // Named profile active while dictating
+	Window     *hyprland.Window  `json:"-"`                 // Window dictated into, nil outside Hyprland
+	Action     string            `json:"action,omitempty"`  // What became of it: "injected", "command", "composed" or "readback"
+	Timings    Timings           `json:"timings"`
+
+	// Words whisper was unsure about (below low_confidence_threshold), in order,
+	// for surfaces showing the text to mark them for double-checking
+	Uncertain []string `json:"uncertain,omitempty"`
+}
+
+// Timings are the durations of the pipeline stages
+type Timings struct {
+	Audio       time.Duration // Length of the recording
+	Preprocess  time.Duration // Audio chain (echo cancellation, filters, VAD)
+	Transcribe  time.Duration
+	PostProcess time.Duration // Post-processors and the LLM rewrite
+	Inject      time.Duration
+}
+
+// New creates the result of a transcription
+func New(result *whisper.Result) *Result {
+	return &Result{
+		Text:       result.Text,
+		Raw:        result.Text,
+		Segments:   result.Segments,
+		Language:   result.Language,
+		Confidence: result.MeanProbability(),
+	}
+}
+
+// App returns the class of the window dictated into, empty if unknown
+func (r *Result) App() string {
+	if r.Window == nil {
+		return ""
+	}
+	return r.Window.Class
+}
+
+// Words returns the number of words of the text
+func (r *Result) Words() int {
+	return len(strings.Fields(r.Text))
+}
+
+// Redacted returns a copy without the text, for logs and events with
+// redact_transcripts
+func (r *Result) Redacted() *Result {
+	redacted := *r
+	redacted.Text = ""
+	redacted.Raw = ""
+	redacted.Segments = nil
+	redacted.Uncertain = nil
+	return &redacted
+}
+
+// MarshalJSON adds the window class and writes the timings in milliseconds
+func (r *Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		*plain
+		App string `json:"app,omitempty"`
+	}{(*plain)(r), r.App()})
+}
+
+// MarshalJSON writes the timings in milliseconds
+func (t Timings) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	return json.Marshal(struct {
+		Audio       float64 `json:"audio_ms"`
+		Preprocess  float64 `json:"preprocess_ms"`
+		Transcribe  float64 `json:"transcribe_ms"`
+		PostProcess float64 `json:"postprocess_ms"`
+		Inject      float64 `json:"inject_ms"`
+	}{ms(t.Audio), ms(t.Preprocess), ms(t.Transcribe), ms(t.PostProcess), ms(t.Inject)})
+}
+
+// Spans returns the byte ranges of words in text. The words are looked up in
+// order, ignoring case and punctuation, so a word that occurs several times is
+// matched at the right place. Words post-processing changed or removed are
+// skipped.
+func Spans(text string, words []string) [][2]int {
+	type field struct{ start, end int }
+	var fields []field
+	start := -1
+	for i, r := range text + " " {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			fields = append(fields, field{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+
+	var spans [][2]int
+	next := 0
+	for _, word := range words {
+		key := strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+		if key == "" {
+			continue
+		}
+		for i := next; i < len(fields); i++ {
+			f := fields[i]
+			core := strings.TrimFunc(text[f.start:f.end], unicode.IsPunct)
+			if strings.ToLower(core) != key {
+				continue
+			}
+			offset := f.start + strings.Index(text[f.start:f.end], core)
+			spans = append(spans, [2]int{offset, offset + len(core)})
+			next = i + 1
+			break
+		}
+	}
+	return spans
+}
+
+// Highlight returns text with the words wrapped in the open/close markers
+// (e.g. "⟨", "⟩"), see Spans
+func Highlight(text string, words []string, open, close string) string {
+	var sb strings.Builder
+	last := 0
+	for _, span := range Spans(text, words) {
+		sb.WriteString(text[last:span[0]])
+		sb.WriteString(open + text[span[0]:span[1]] + close)
+		last = span[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
@@ -0,0 +1,99 @@
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// D-Bus name, object and interface the dbus trigger exports on the session bus
+const (
+	BusName       = "io.github.parnoldx.hyprwhspr"
+	busPath       = "/io/github/parnoldx/hyprwhspr"
+	busInterface  = "io.github.parnoldx.hyprwhspr.Recording"
+	busIntrospect = `<node>
+  <interface name="` + busInterface + `">
+    <method name="Start"><arg direction="out" type="s"/></method>
+    <method name="Stop"><arg direction="out" type="s"/></method>
+    <method name="Toggle"><arg direction="out" type="s"/></method>
+  </interface>
+  <interface name="org.freedesktop.DBus.Introspectable">
+    <method name="Introspect"><arg direction="out" type="s"/></method>
+  </interface>
+</node>`
+)
+
+// dbusTrigger exports Start, Stop and Toggle methods on the session bus, for
+// desktop environments, scripts and other programs that speak D-Bus rather
+// than the IPC socket
+type dbusTrigger struct {
+	conn *dbus.Conn
+}
+
+func newDBus() *dbusTrigger {
+	return &dbusTrigger{}
+}
+
+func (t *dbusTrigger) Name() string {
+	return "dbus " + BusName
+}
+
+// recording is the exported object, its methods are the D-Bus methods
+type recording struct {
+	handler Handler
+}
+
+func (r recording) Start() (string, *dbus.Error)  { return r.handler("start"), nil }
+func (r recording) Stop() (string, *dbus.Error)   { return r.handler("stop"), nil }
+func (r recording) Toggle() (string, *dbus.Error) { return r.handler("toggle"), nil }
+
+func (t *dbusTrigger) Start(handler Handler) error {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+
+	if err := conn.Export(recording{handler}, busPath, busInterface); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := conn.Export(introspectable(busIntrospect), busPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		conn.Close()
+		return err
+	}
+
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to claim %s: %w", BusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return fmt.Errorf("%s is already taken (another hyprwhspr running?)", BusName)
+	}
+
+	t.conn = conn
+	return nil
+}
+
+func (t *dbusTrigger) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+// introspectable answers Introspect with a fixed description
+type introspectable string
+
+func (i introspectable) Introspect() (string, *dbus.Error) {
+	return string(i), nil
+}
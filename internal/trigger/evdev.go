@@ -0,0 +1,138 @@
+package trigger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// evKey is the event type of key and button events (linux/input-event-codes.h)
+const evKey = 0x01
+
+// evdevKeys are the Linux key codes of keys that make good push-to-talk keys
+var evdevKeys = map[string]uint16{
+	"leftctrl": 29, "rightctrl": 97, "leftshift": 42, "rightshift": 54, "leftalt": 56, "rightalt": 100,
+	"leftmeta": 125, "rightmeta": 126, "capslock": 58, "scrolllock": 70, "pause": 119, "insert": 110,
+	"menu": 127, "compose": 127, "space": 57, "mute": 113, "micmute": 248,
+	"f1": 59, "f2": 60, "f3": 61, "f4": 62, "f5": 63, "f6": 64, "f7": 65, "f8": 66, "f9": 67, "f10": 68,
+	"f11": 87, "f12": 88, "f13": 183, "f14": 184, "f15": 185, "f16": 186, "f17": 187, "f18": 188,
+	"f19": 189, "f20": 190, "f21": 191, "f22": 192, "f23": 193, "f24": 194,
+	"btn_side": 0x113, "btn_extra": 0x114, "btn_forward": 0x115, "btn_back": 0x116, "btn_middle": 0x112,
+}
+
+// ParseKey returns the key code of a key name or "code:N"
+func ParseKey(name string) (uint16, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if code, ok := evdevKeys[name]; ok {
+		return code, nil
+	}
+	if raw, ok := strings.CutPrefix(name, "code:"); ok {
+		code, err := strconv.ParseUint(raw, 10, 16)
+		if err == nil {
+			return uint16(code), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key %q (use a name like f13 or rightctrl, or code:N from evtest)", name)
+}
+
+// inputEvent is struct input_event
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// evdevTrigger reads a key of an input device directly, for a push-to-talk
+// key that works in every compositor, even while another app grabs shortcuts.
+// Reading input devices needs membership in the input group.
+type evdevTrigger struct {
+	device string
+	code   uint16
+	mode   string
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool // closed before or while the device was opened
+}
+
+func newEvdev(spec Spec) (*evdevTrigger, error) {
+	if spec.Device == "" {
+		return nil, fmt.Errorf("evdev trigger needs a device")
+	}
+	code, err := ParseKey(spec.Key)
+	if err != nil {
+		return nil, err
+	}
+	mode := spec.Mode
+	if mode == "" {
+		mode = "hold"
+	}
+	return &evdevTrigger{device: spec.Device, code: code, mode: mode}, nil
+}
+
+func (t *evdevTrigger) Name() string {
+	return fmt.Sprintf("evdev %s (key %d, %s)", t.device, t.code, t.mode)
+}
+
+func (t *evdevTrigger) Start(handler Handler) error {
+	file, err := os.Open(t.device)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w (reading input devices needs the input group)", t.device, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		file.Close()
+		return fmt.Errorf("closed")
+	}
+	t.file = file
+
+	go func() {
+		for {
+			var event inputEvent
+			if err := binary.Read(file, binary.NativeEndian, &event); err != nil {
+				if !t.isClosed() {
+					fmt.Printf("⚠️  Trigger %s stopped: %v\n", t.Name(), err)
+				}
+				return
+			}
+			if event.Type != evKey || event.Code != t.code {
+				continue
+			}
+			// 1 = pressed, 0 = released, 2 = autorepeat
+			command := ""
+			switch event.Value {
+			case 1:
+				command = press(t.mode)
+			case 0:
+				command = release(t.mode)
+			}
+			if command != "" {
+				handler(command)
+			}
+		}
+	}()
+	return nil
+}
+
+func (t *evdevTrigger) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *evdevTrigger) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
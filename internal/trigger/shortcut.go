@@ -0,0 +1,123 @@
+package trigger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/pa/hyprwhspr/internal/portal"
+)
+
+// GlobalShortcuts portal interface and the ID of hyprwhspr's shortcut
+const (
+	globalShortcuts = "org.freedesktop.portal.GlobalShortcuts"
+	ShortcutID      = "dictate"
+)
+
+// shortcutTrigger registers a desktop-wide shortcut with the GlobalShortcuts
+// portal. The user confirms or changes the key in the desktop's dialog
+// (GNOME, KDE); on Hyprland the shortcut is bound in hyprland.conf with the
+// global dispatcher ("hyprctl globalshortcuts" lists its name).
+type shortcutTrigger struct {
+	preferred string // suggested key, e.g. "SUPER+D"
+	mode      string
+
+	mu     sync.Mutex
+	conn   *portal.Conn
+	closed bool // closed while still waiting for the user to confirm
+}
+
+func newShortcut(spec Spec) (*shortcutTrigger, error) {
+	mode := spec.Mode
+	if mode == "" {
+		mode = "toggle"
+	}
+	return &shortcutTrigger{preferred: spec.Key, mode: mode}, nil
+}
+
+func (t *shortcutTrigger) Name() string {
+	return fmt.Sprintf("portal shortcut %q (%s)", ShortcutID, t.mode)
+}
+
+func (t *shortcutTrigger) Start(handler Handler) error {
+	conn, err := portal.Connect(globalShortcuts)
+	if err != nil {
+		return err
+	}
+
+	session, err := conn.CreateSession(globalShortcuts)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create shortcut session: %w", err)
+	}
+
+	// Listen before binding, pressing the key can't be missed
+	activated := []dbus.MatchOption{dbus.WithMatchInterface(globalShortcuts), dbus.WithMatchMember("Activated")}
+	deactivated := []dbus.MatchOption{dbus.WithMatchInterface(globalShortcuts), dbus.WithMatchMember("Deactivated")}
+	if err := conn.AddMatchSignal(activated...); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := conn.AddMatchSignal(deactivated...); err != nil {
+		conn.Close()
+		return err
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	shortcut := map[string]dbus.Variant{"description": dbus.MakeVariant("Dictate with hyprwhspr")}
+	if t.preferred != "" {
+		shortcut["preferred_trigger"] = dbus.MakeVariant(t.preferred)
+	}
+	shortcuts := []struct {
+		ID      string
+		Options map[string]dbus.Variant
+	}{{ShortcutID, shortcut}}
+	_, err = conn.Request(globalShortcuts+".BindShortcuts", 2*time.Minute, func(options map[string]dbus.Variant) []interface{} {
+		return []interface{}{session, shortcuts, "", options}
+	})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to bind shortcut: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		conn.Close()
+		return fmt.Errorf("closed")
+	}
+	t.conn = conn
+	go func() {
+		for signal := range signals {
+			if len(signal.Body) < 2 {
+				continue
+			}
+			if path, _ := signal.Body[0].(dbus.ObjectPath); path != session {
+				continue
+			}
+			if id, _ := signal.Body[1].(string); id != ShortcutID {
+				continue
+			}
+			command := press(t.mode)
+			if signal.Name == globalShortcuts+".Deactivated" {
+				command = release(t.mode)
+			}
+			if command != "" {
+				handler(command)
+			}
+		}
+	}()
+	return nil
+}
+
+func (t *shortcutTrigger) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
@@ -0,0 +1,52 @@
+package trigger
+
+import "fmt"
+
+// Handler runs a daemon command ("start", "stop" or "toggle") and returns the
+// response, like a command sent over the IPC socket
+type Handler func(command string) string
+
+// Trigger is a source of recording start and stop requests besides the IPC
+// socket, e.g. a push-to-talk key or a desktop-wide shortcut. Triggers run
+// in the background and can be active at the same time.
+type Trigger interface {
+	Name() string
+	Start(handler Handler) error // Start delivering commands to the handler
+	Close() error
+}
+
+// Spec configures a trigger
+type Spec struct {
+	Type   string // "evdev", "dbus" or "portal"
+	Device string // evdev: input device, e.g. /dev/input/by-id/usb-...-event-kbd
+	Key    string // evdev: key name ("f13", "rightctrl", "code:183"); portal: suggested shortcut ("SUPER+D")
+	Mode   string // "hold" (record while the key is held) or "toggle", empty = the type's default
+}
+
+// New creates a trigger, it does nothing until started
+func New(spec Spec) (Trigger, error) {
+	switch spec.Type {
+	case "evdev":
+		return newEvdev(spec)
+	case "dbus":
+		return newDBus(), nil
+	case "portal":
+		return newShortcut(spec)
+	}
+	return nil, fmt.Errorf("unknown trigger type %q", spec.Type)
+}
+
+// press and release turn a key event into the command of the trigger mode
+func press(mode string) string {
+	if mode == "hold" {
+		return "start"
+	}
+	return "toggle"
+}
+
+func release(mode string) string {
+	if mode == "hold" {
+		return "stop"
+	}
+	return ""
+}
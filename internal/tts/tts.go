@@ -0,0 +1,81 @@
+package tts
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// DefaultCommand speaks with espeak-ng, which is small and in every distribution
+const DefaultCommand = "espeak-ng --stdin"
+
+// Speaker reads text aloud with an external text-to-speech program. The
+// command is run by the shell with the text on stdin, so pipelines work too,
+// e.g. "piper --model voice.onnx --output-raw | aplay -r 22050 -f S16_LE -t raw -".
+type Speaker struct {
+	command string
+	mu      sync.Mutex // one phrase at a time, so they don't talk over each other
+
+	runMu   sync.Mutex
+	running *exec.Cmd // phrase being spoken, nil = silent
+}
+
+// NewSpeaker creates a speaker running command (empty = DefaultCommand)
+func NewSpeaker(command string) *Speaker {
+	if command == "" {
+		command = DefaultCommand
+	}
+	return &Speaker{command: command}
+}
+
+// Say speaks text and returns once it was spoken or interrupted by Stop
+func (s *Speaker) Say(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cmd := exec.Command("sh", "-c", s.command)
+	cmd.Stdin = strings.NewReader(text)
+	// Own process group, so Stop also ends the players of a pipeline
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var output strings.Builder
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start text-to-speech command: %w", err)
+	}
+
+	s.runMu.Lock()
+	s.running = cmd
+	s.runMu.Unlock()
+
+	err := cmd.Wait()
+
+	s.runMu.Lock()
+	stopped := s.running == nil
+	s.running = nil
+	s.runMu.Unlock()
+
+	if err != nil && !stopped {
+		return fmt.Errorf("text-to-speech command failed: %w\nOutput: %s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// Stop interrupts the phrase being spoken, e.g. when a recording starts so the
+// speech isn't recorded. Phrases waiting for their turn are still spoken.
+func (s *Speaker) Stop() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if s.running == nil || s.running.Process == nil {
+		return
+	}
+	syscall.Kill(-s.running.Process.Pid, syscall.SIGTERM)
+	s.running = nil
+}
@@ -0,0 +1,429 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pa/hyprwhspr/internal/config"
+	"github.com/pa/hyprwhspr/internal/history"
+	"github.com/pa/hyprwhspr/internal/ipc"
+	"github.com/pa/hyprwhspr/internal/models"
+	"github.com/pa/hyprwhspr/internal/transcript"
+)
+
+// Dashboard layout and refresh
+const (
+	recentCount  = 8                      // transcriptions listed
+	pollInterval = 500 * time.Millisecond // status refresh (recording time, queue)
+	retryDelay   = 2 * time.Second        // reconnect after the daemon went away
+	meterWidth   = 40
+)
+
+// languages offered when allowed_languages doesn't narrow them down
+var languages = []string{"en", "de", "fr", "es", "it", "nl", "pl", "pt", "ja", "zh"}
+
+// Messages from the daemon connection and the commands run for the user
+type (
+	statusMsg  ipc.Status
+	offlineMsg struct{ err error }
+	stateMsg   string
+	levelMsg   struct {
+		RMSdB  float64 `json:"rms_db"`
+		PeakdB float64 `json:"peak_db"`
+	}
+	transcriptionMsg recent
+	replyMsg         string
+	pickerMsg        picker
+	tickMsg          time.Time
+)
+
+// recent is a transcription listed on the dashboard
+type recent struct {
+	Time      time.Time
+	App       string
+	Action    string
+	Text      string
+	Uncertain []string // words to double-check, underlined
+}
+
+// picker is a list of choices the user is picking from, apply runs the choice
+type picker struct {
+	title   string
+	options []string
+	cursor  int
+	apply   func(choice string) tea.Cmd
+}
+
+type model struct {
+	client  *ipc.Client
+	cfgPath string
+
+	status  ipc.Status
+	online  bool
+	offline string // why the daemon can't be reached
+	level   levelMsg
+	leveled time.Time // when the last level arrived, the meter drops without
+	recent  []recent
+	message string // reply to the last action
+	picker  *picker
+	width   int
+}
+
+// Run shows the dashboard until the user quits: the daemon state, the input
+// level while recording and the recent transcriptions. The model, profile and
+// language are switched from here.
+func Run(cfgPath string, cfg *config.Config) error {
+	m := &model{client: ipc.NewClient(cfg.SocketPath), cfgPath: cfgPath, width: 80}
+	if cfg.History {
+		entries, _ := history.Read(cfg.HistoryPath, recentCount, "")
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			action := "injected"
+			if e.Command {
+				action = "command"
+			}
+			m.recent = append(m.recent, recent{Time: e.Time, App: e.WindowClass, Action: action, Text: e.Text})
+		}
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	go watch(p, m.client)
+	_, err := p.Run()
+	return err
+}
+
+// watch forwards the daemon's events to the program, reconnecting whenever
+// the connection is lost
+func watch(p *tea.Program, client *ipc.Client) {
+	for {
+		err := client.Watch("", func(line string, isEvent bool) {
+			if !isEvent {
+				return
+			}
+			event, payload, _ := strings.Cut(line, " ")
+			switch event {
+			case "state":
+				p.Send(stateMsg(payload))
+			case "level":
+				var level levelMsg
+				if json.Unmarshal([]byte(payload), &level) == nil {
+					p.Send(level)
+				}
+			case "transcription":
+				var res struct {
+					Text      string   `json:"text"`
+					App       string   `json:"app"`
+					Action    string   `json:"action"`
+					Uncertain []string `json:"uncertain"`
+				}
+				if json.Unmarshal([]byte(payload), &res) == nil {
+					p.Send(transcriptionMsg{Time: time.Now(), App: res.App, Action: res.Action, Text: res.Text, Uncertain: res.Uncertain})
+				}
+			}
+		})
+		p.Send(offlineMsg{err})
+		time.Sleep(retryDelay)
+	}
+}
+
+func (m *model) Init() tea.Cmd {
+	return m.poll()
+}
+
+// poll asks the daemon for its status
+func (m *model) poll() tea.Cmd {
+	return func() tea.Msg {
+		response, err := m.client.SendCommand("status json")
+		if err != nil {
+			return offlineMsg{err}
+		}
+		var status ipc.Status
+		if err := json.Unmarshal([]byte(response), &status); err != nil {
+			return offlineMsg{fmt.Errorf("unexpected reply: %s", response)}
+		}
+		return statusMsg(status)
+	}
+}
+
+// send runs a control command and shows its reply
+func (m *model) send(command string) tea.Cmd {
+	return func() tea.Msg {
+		response, err := m.client.SendCommand(command)
+		if err != nil {
+			return replyMsg("ERROR: " + err.Error())
+		}
+		return replyMsg(response)
+	}
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyMsg:
+		return m, m.key(msg.String())
+	case statusMsg:
+		m.status = ipc.Status(msg)
+		m.online = true
+		return m, tea.Tick(pollInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+	case offlineMsg:
+		m.online = false
+		m.offline = "daemon not running"
+		if msg.err != nil {
+			m.offline = msg.err.Error()
+		}
+		return m, tea.Tick(retryDelay, func(t time.Time) tea.Msg { return tickMsg(t) })
+	case tickMsg:
+		return m, m.poll()
+	case stateMsg:
+		m.status.State = string(msg)
+	case levelMsg:
+		m.level = msg
+		m.leveled = time.Now()
+	case transcriptionMsg:
+		m.recent = append([]recent{recent(msg)}, m.recent...)
+		if len(m.recent) > recentCount {
+			m.recent = m.recent[:recentCount]
+		}
+	case replyMsg:
+		m.message = string(msg)
+	case pickerMsg:
+		p := picker(msg)
+		m.picker = &p
+	}
+	return m, nil
+}
+
+// key handles a key press, in the picker if one is open
+func (m *model) key(key string) tea.Cmd {
+	if m.picker != nil {
+		switch key {
+		case "up", "k":
+			if m.picker.cursor > 0 {
+				m.picker.cursor--
+			}
+		case "down", "j":
+			if m.picker.cursor < len(m.picker.options)-1 {
+				m.picker.cursor++
+			}
+		case "enter":
+			p := m.picker
+			m.picker = nil
+			return p.apply(p.options[p.cursor])
+		case "esc", "q":
+			m.picker = nil
+		case "ctrl+c":
+			return tea.Quit
+		}
+		return nil
+	}
+
+	switch key {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case " ", "t":
+		return m.send("toggle")
+	case "c":
+		return m.send("cancel")
+	case "m":
+		return m.pickModel
+	case "p":
+		return m.pickProfile
+	case "l":
+		return m.pickLanguage
+	}
+	return nil
+}
+
+// pickModel offers the downloaded models
+func (m *model) pickModel() tea.Msg {
+	cfg, err := config.Load(m.cfgPath)
+	if err != nil {
+		return replyMsg("ERROR: " + err.Error())
+	}
+	downloaded, err := models.NewManager(cfg.WhisperModelDir).ListDownloadedModels()
+	if err != nil || len(downloaded) == 0 {
+		return replyMsg("ERROR: no downloaded models (hyprwhspr download <model>)")
+	}
+	return pickerMsg{title: "Model", options: downloaded, cursor: indexOf(downloaded, cfg.Model), apply: func(choice string) tea.Cmd {
+		return m.send("model " + choice)
+	}}
+}
+
+// pickProfile offers the named profiles
+func (m *model) pickProfile() tea.Msg {
+	response, err := m.client.SendCommand("profiles")
+	if err != nil {
+		return replyMsg("ERROR: " + err.Error())
+	}
+	options := append([]string{"none"}, strings.Fields(response)...)
+	return pickerMsg{title: "Profile", options: options, cursor: indexOf(options, m.status.Profile), apply: func(choice string) tea.Cmd {
+		return m.send("profile " + choice)
+	}}
+}
+
+// pickLanguage offers auto-detection and the allowed languages. The language
+// is saved to the config, which the daemon reloads.
+func (m *model) pickLanguage() tea.Msg {
+	cfg, err := config.Load(m.cfgPath)
+	if err != nil {
+		return replyMsg("ERROR: " + err.Error())
+	}
+	options := append([]string{"auto"}, languages...)
+	if len(cfg.AllowedLanguages) > 0 {
+		options = append([]string{"auto"}, cfg.AllowedLanguages...)
+	}
+	return pickerMsg{title: "Language", options: options, cursor: indexOf(options, m.status.Language), apply: func(choice string) tea.Cmd {
+		return func() tea.Msg {
+			return replyMsg(m.setLanguage(choice))
+		}
+	}}
+}
+
+// setLanguage saves the transcription language, "auto" detects it
+func (m *model) setLanguage(language string) string {
+	cfg, err := config.Load(m.cfgPath)
+	if err != nil {
+		return "ERROR: " + err.Error()
+	}
+	value := language
+	if language == "auto" {
+		value = "null"
+	}
+	updated, err := cfg.Set("language", value)
+	if err != nil {
+		return "ERROR: " + err.Error()
+	}
+	if err := updated.Save(m.cfgPath); err != nil {
+		return "ERROR: " + err.Error()
+	}
+	return "OK: Language set to " + language
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+	b.WriteString("hyprwhspr")
+	if !m.online {
+		fmt.Fprintf(&b, " - offline (%s)\n", m.offline)
+	} else {
+		state := m.status.State
+		if m.status.Recording {
+			state += fmt.Sprintf(" %.1fs", m.status.RecordingSeconds)
+		}
+		if m.status.Mode != "" {
+			state += " [" + m.status.Mode + "]"
+		}
+		fmt.Fprintf(&b, " - %s\n\n", state)
+
+		loaded := "not loaded"
+		if m.status.ModelLoaded {
+			loaded = m.status.Device
+		}
+		profile := m.status.Profile
+		if profile == "" {
+			profile = "none"
+		}
+		fmt.Fprintf(&b, "Model     %s (%s)\n", m.status.Model, loaded)
+		fmt.Fprintf(&b, "Language  %s\n", m.status.Language)
+		fmt.Fprintf(&b, "Profile   %s\n", profile)
+		fmt.Fprintf(&b, "Injection %s\n", m.status.Injection)
+		fmt.Fprintf(&b, "Queue     %d\n\n", m.status.Queue)
+		b.WriteString(m.meter() + "\n")
+	}
+
+	b.WriteString("\nRecent transcriptions\n")
+	if len(m.recent) == 0 {
+		b.WriteString("  none yet\n")
+	}
+	for _, r := range m.recent {
+		prefix := fmt.Sprintf("  %s %-10s ", r.Time.Local().Format("15:04:05"), truncate(r.App, 10))
+		text := strings.ReplaceAll(r.Text, "\n", " ")
+		line := prefix + text
+		if r.Action != "" && r.Action != "injected" {
+			line += " (" + r.Action + ")"
+		}
+		line = truncate(line, m.width)
+		var spans [][2]int
+		for _, span := range transcript.Spans(text, r.Uncertain) {
+			spans = append(spans, [2]int{len(prefix) + span[0], len(prefix) + span[1]})
+		}
+		b.WriteString(underline(line, spans) + "\n")
+	}
+
+	if m.picker != nil {
+		fmt.Fprintf(&b, "\n%s (enter to switch, esc to cancel)\n", m.picker.title)
+		for i, option := range m.picker.options {
+			cursor := " "
+			if i == m.picker.cursor {
+				cursor = ">"
+			}
+			fmt.Fprintf(&b, " %s %s\n", cursor, option)
+		}
+	}
+
+	if m.message != "" {
+		b.WriteString("\n" + truncate(m.message, m.width) + "\n")
+	}
+	b.WriteString("\nspace toggle · c cancel · m model · p profile · l language · q quit\n")
+	return b.String()
+}
+
+// meter draws the input level of the recording, empty while not recording
+func (m *model) meter() string {
+	db := -100.0
+	if m.status.Recording && time.Since(m.leveled) < time.Second {
+		db = m.level.RMSdB
+	}
+	// -60 dBFS (quiet room) to 0 dBFS (full scale)
+	filled := int(math.Round((db + 60) / 60 * meterWidth))
+	if filled < 0 {
+		filled = 0
+	} else if filled > meterWidth {
+		filled = meterWidth
+	}
+	label := "   -"
+	if db > -100 {
+		label = fmt.Sprintf("%4.0f", db)
+	}
+	return fmt.Sprintf("Level     [%s%s] %s dBFS", strings.Repeat("█", filled), strings.Repeat("░", meterWidth-filled), label)
+}
+
+// truncate shortens text to width runes
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if width <= 1 || len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// underline underlines the byte ranges of line in the terminal, ranges cut off
+// by truncate are left out
+func underline(line string, spans [][2]int) string {
+	visible := strings.TrimSuffix(line, "…")
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span[1] > len(visible) {
+			break
+		}
+		b.WriteString(line[last:span[0]] + "\x1b[4m" + line[span[0]:span[1]] + "\x1b[24m")
+		last = span[1]
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// indexOf returns the position of value in options, 0 if missing
+func indexOf(options []string, value string) int {
+	for i, option := range options {
+		if option == value {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// releaseURL is the GitHub API endpoint of the newest release
+const releaseURL = "https://api.github.com/repos/parnoldx/hyprwhspr-go/releases/latest"
+
+// Release is a published hyprwhspr release
+type Release struct {
+	Version string // tag without the leading "v"
+	URL     string // release page
+	Notes   string // changelog in markdown
+}
+
+// Latest fetches the newest release from GitHub
+func Latest(timeout time.Duration) (*Release, error) {
+	req, err := http.NewRequest(http.MethodGet, releaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+
+	var body struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+		Body    string `json:"body"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	if body.TagName == "" {
+		return nil, fmt.Errorf("release has no tag")
+	}
+
+	return &Release{
+		Version: strings.TrimPrefix(body.TagName, "v"),
+		URL:     body.HTMLURL,
+		Notes:   body.Body,
+	}, nil
+}
+
+// Newer reports whether version a is newer than b. Versions are compared by
+// their dot-separated numbers, a suffix ("-go", "-rc1") is ignored.
+func Newer(a, b string) bool {
+	va, vb := parseVersion(a), parseVersion(b)
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// parseVersion returns the numbers of a version like "v1.2.3-go"
+func parseVersion(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// Highlights returns up to limit list items of the release notes, the
+// changelog's bullet points without the markdown markers
+func (r *Release) Highlights(limit int) []string {
+	var items []string
+	for _, line := range strings.Split(r.Notes, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+			continue
+		}
+		items = append(items, strings.TrimSpace(line[2:]))
+		if len(items) == limit {
+			break
+		}
+	}
+	return items
+}
@@ -0,0 +1,406 @@
+package whisper
+
+/*
+#include <whisper.h>
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Element types of a compiled grammar rule, as in whisper.h (enum whisper_gretype)
+const (
+	greEnd          = 0 // end of rule definition
+	greAlt          = 1 // start of alternate definition for rule
+	greRuleRef      = 2 // non-terminal element: reference to rule
+	greChar         = 3 // terminal element: character (code point)
+	greCharNot      = 4 // inverse char(s) ([^a], [^a-b] [^abc])
+	greCharRngUpper = 5 // modifies a preceding greChar or greCharAlt to be an inclusive range ([a-z])
+	greCharAlt      = 6 // modifies a preceding greChar or greCharRngUpper to add an alternate char to match ([ab], [a-zA])
+)
+
+// grammarElement is one element of a compiled rule
+type grammarElement struct {
+	typ   uint32
+	value uint32 // code point or rule ID
+}
+
+// Grammar is a GBNF grammar compiled to the rule format whisper.cpp decodes
+// with. Decoding is restricted to text the grammar's "root" rule accepts, e.g.
+// only "yes" or "no", only digits or only the configured command words.
+type Grammar struct {
+	rules [][]grammarElement
+	root  int
+}
+
+// BuiltinGrammars are grammars for common constrained answers
+var BuiltinGrammars = map[string]string{
+	"yesno": `root ::= " "? ([Yy] "es" | [Nn] "o")`,
+	"digits": `root   ::= " "? number (" " number)*
+number ::= "-"? [0-9]+ ([.,] [0-9]+)?`,
+}
+
+// VocabularyGrammar builds a grammar that only accepts one of the given words
+// or phrases (in lower or sentence case), optionally followed by free text
+// as its arguments
+func VocabularyGrammar(words []string, arguments bool) string {
+	sorted := append([]string(nil), words...)
+	sort.Strings(sorted)
+
+	var alternatives []string
+	for _, w := range sorted {
+		w = strings.ToLower(w)
+		alternatives = append(alternatives, strconv.Quote(w))
+		r, size := utf8.DecodeRuneInString(w)
+		if upper := strings.ToUpper(string(r)) + w[size:]; upper != w {
+			alternatives = append(alternatives, strconv.Quote(upper))
+		}
+	}
+
+	src := `root ::= " "? (` + strings.Join(alternatives, " | ") + `)`
+	if arguments {
+		src += ` (" " [^\n]+)?`
+	}
+	return src
+}
+
+// ParseGrammar compiles a grammar in GBNF notation (the format of whisper.cpp's
+// and llama.cpp's grammars), starting at the rule named "root"
+func ParseGrammar(src string) (*Grammar, error) {
+	p := &grammarParser{src: src, symbols: make(map[string]uint32)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	root, ok := p.symbols["root"]
+	if !ok {
+		return nil, fmt.Errorf("grammar has no root rule")
+	}
+	for name, id := range p.symbols {
+		if int(id) >= len(p.rules) || len(p.rules[id]) == 0 {
+			return nil, fmt.Errorf("grammar uses undefined rule %q", name)
+		}
+	}
+	return &Grammar{rules: p.rules, root: int(root)}, nil
+}
+
+// grammarParser is a recursive descent parser for GBNF, a port of whisper.cpp's
+// examples/grammar-parser.cpp. Parenthesized groups and repetitions become
+// generated rules.
+type grammarParser struct {
+	src     string
+	pos     int
+	symbols map[string]uint32 // rule name -> rule ID
+	rules   [][]grammarElement
+}
+
+func (p *grammarParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("grammar line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *grammarParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// symbol returns the ID of a rule name, assigning one on first use
+func (p *grammarParser) symbol(name string) uint32 {
+	if id, ok := p.symbols[name]; ok {
+		return id
+	}
+	id := uint32(len(p.symbols))
+	p.symbols[name] = id
+	return id
+}
+
+// generateSymbol returns the ID of a new rule derived from base
+func (p *grammarParser) generateSymbol(base string) uint32 {
+	id := uint32(len(p.symbols))
+	p.symbols[base+"_"+strconv.Itoa(int(id))] = id
+	return id
+}
+
+func (p *grammarParser) addRule(id uint32, rule []grammarElement) {
+	for len(p.rules) <= int(id) {
+		p.rules = append(p.rules, nil)
+	}
+	p.rules[id] = rule
+}
+
+func (p *grammarParser) parse() error {
+	p.skipSpace(true)
+	for p.pos < len(p.src) {
+		if err := p.parseRule(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipSpace skips blanks and comments, and newlines if allowed
+func (p *grammarParser) skipSpace(newlines bool) {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\r' && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || (newlines && (c == '\r' || c == '\n')):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
+
+func (p *grammarParser) parseName() (string, error) {
+	start := p.pos
+	for p.pos < len(p.src) && isWordChar(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expecting name")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *grammarParser) parseRule() error {
+	name, err := p.parseName()
+	if err != nil {
+		return err
+	}
+	p.skipSpace(false)
+	id := p.symbol(name)
+
+	if !strings.HasPrefix(p.src[p.pos:], "::=") {
+		return p.errorf("expecting ::= after %s", name)
+	}
+	p.pos += 3
+	p.skipSpace(true)
+
+	if err := p.parseAlternates(name, id, false); err != nil {
+		return err
+	}
+
+	switch p.peek() {
+	case '\r':
+		p.pos++
+		if p.peek() == '\n' {
+			p.pos++
+		}
+	case '\n':
+		p.pos++
+	case 0:
+	default:
+		return p.errorf("expecting newline or end, got %q", p.peek())
+	}
+	p.skipSpace(true)
+	return nil
+}
+
+func (p *grammarParser) parseAlternates(name string, id uint32, nested bool) error {
+	var rule []grammarElement
+	if err := p.parseSequence(name, &rule, nested); err != nil {
+		return err
+	}
+	for p.peek() == '|' {
+		rule = append(rule, grammarElement{greAlt, 0})
+		p.pos++
+		p.skipSpace(true)
+		if err := p.parseSequence(name, &rule, nested); err != nil {
+			return err
+		}
+	}
+	rule = append(rule, grammarElement{greEnd, 0})
+	p.addRule(id, rule)
+	return nil
+}
+
+func (p *grammarParser) parseSequence(name string, out *[]grammarElement, nested bool) error {
+	lastSymStart := len(*out)
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '"':
+			// Literal string
+			p.pos++
+			lastSymStart = len(*out)
+			for p.peek() != '"' {
+				if p.pos >= len(p.src) {
+					return p.errorf("unexpected end of input")
+				}
+				r, err := p.parseChar()
+				if err != nil {
+					return err
+				}
+				*out = append(*out, grammarElement{greChar, uint32(r)})
+			}
+			p.pos++
+			p.skipSpace(nested)
+
+		case c == '[':
+			// Character class
+			p.pos++
+			typ := uint32(greChar)
+			if p.peek() == '^' {
+				p.pos++
+				typ = greCharNot
+			}
+			lastSymStart = len(*out)
+			for p.peek() != ']' {
+				if p.pos >= len(p.src) {
+					return p.errorf("unexpected end of input")
+				}
+				r, err := p.parseChar()
+				if err != nil {
+					return err
+				}
+				elemType := typ
+				if len(*out) > lastSymStart {
+					elemType = greCharAlt
+				}
+				*out = append(*out, grammarElement{elemType, uint32(r)})
+				if p.peek() == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ']' {
+					p.pos++
+					upper, err := p.parseChar()
+					if err != nil {
+						return err
+					}
+					*out = append(*out, grammarElement{greCharRngUpper, uint32(upper)})
+				}
+			}
+			p.pos++
+			p.skipSpace(nested)
+
+		case isWordChar(c):
+			// Rule reference
+			ref, err := p.parseName()
+			if err != nil {
+				return err
+			}
+			p.skipSpace(nested)
+			lastSymStart = len(*out)
+			*out = append(*out, grammarElement{greRuleRef, p.symbol(ref)})
+
+		case c == '(':
+			// Grouping, parsed as a generated rule
+			p.pos++
+			p.skipSpace(true)
+			sub := p.generateSymbol(name)
+			if err := p.parseAlternates(name, sub, true); err != nil {
+				return err
+			}
+			lastSymStart = len(*out)
+			*out = append(*out, grammarElement{greRuleRef, sub})
+			if p.peek() != ')' {
+				return p.errorf("expecting ')'")
+			}
+			p.pos++
+			p.skipSpace(nested)
+
+		case c == '*' || c == '+' || c == '?':
+			// Repetition of the previous symbol, rewritten as a generated rule:
+			// S* --> S' ::= S S' |
+			// S+ --> S' ::= S S' | S
+			// S? --> S' ::= S |
+			if lastSymStart == len(*out) {
+				return p.errorf("expecting preceding item to %c", c)
+			}
+			sub := p.generateSymbol(name)
+			symbol := append([]grammarElement(nil), (*out)[lastSymStart:]...)
+			rule := append([]grammarElement(nil), symbol...)
+			if c == '*' || c == '+' {
+				rule = append(rule, grammarElement{greRuleRef, sub})
+			}
+			rule = append(rule, grammarElement{greAlt, 0})
+			if c == '+' {
+				rule = append(rule, symbol...)
+			}
+			rule = append(rule, grammarElement{greEnd, 0})
+			p.addRule(sub, rule)
+
+			*out = append((*out)[:lastSymStart], grammarElement{greRuleRef, sub})
+			p.pos++
+			p.skipSpace(nested)
+
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseChar reads a character of a literal or class, decoding escapes
+func (p *grammarParser) parseChar() (rune, error) {
+	if p.src[p.pos] != '\\' {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+		return r, nil
+	}
+
+	if p.pos+1 >= len(p.src) {
+		return 0, p.errorf("unexpected end of input")
+	}
+	c := p.src[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'x', 'u', 'U':
+		digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+		if p.pos+digits > len(p.src) {
+			return 0, p.errorf("expecting %d hex digits", digits)
+		}
+		value, err := strconv.ParseUint(p.src[p.pos:p.pos+digits], 16, 32)
+		if err != nil {
+			return 0, p.errorf("expecting %d hex digits", digits)
+		}
+		p.pos += digits
+		return rune(value), nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case 'n':
+		return '\n', nil
+	case '\\', '"', '[', ']':
+		return rune(c), nil
+	}
+	return 0, p.errorf("unknown escape \\%c", c)
+}
+
+// cRules copies the rules to C memory for whisper_full_params, as Go memory
+// must not hold pointers that are passed to C. The result must be freed.
+func (g *Grammar) cRules() (**C.whisper_grammar_element, func()) {
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	table := C.malloc(C.size_t(uintptr(len(g.rules)) * ptrSize))
+	rules := unsafe.Slice((**C.whisper_grammar_element)(table), len(g.rules))
+
+	for i, rule := range g.rules {
+		mem := C.malloc(C.size_t(uintptr(len(rule)) * unsafe.Sizeof(C.whisper_grammar_element{})))
+		elements := unsafe.Slice((*C.whisper_grammar_element)(mem), len(rule))
+		for j, e := range rule {
+			elements[j]._type = C.enum_whisper_gretype(e.typ)
+			elements[j].value = C.uint32_t(e.value)
+		}
+		rules[i] = (*C.whisper_grammar_element)(mem)
+	}
+
+	free := func() {
+		for _, rule := range rules {
+			C.free(unsafe.Pointer(rule))
+		}
+		C.free(table)
+	}
+	return (**C.whisper_grammar_element)(table), free
+}
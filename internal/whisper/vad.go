@@ -0,0 +1,98 @@
+package whisper
+
+/*
+#include <whisper.h>
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// SpeechSegment is a stretch of speech found by the VAD
+type SpeechSegment struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// VAD detects speech with the Silero model through whisper.cpp. Unlike an
+// energy detector it is not fooled by music or loud background noise.
+type VAD struct {
+	mu        sync.Mutex
+	ctx       *C.struct_whisper_vad_context
+	threshold float32
+}
+
+// NewVAD loads a Silero VAD model (ggml-silero-*.bin). threshold is the speech
+// probability above which audio counts as speech (0.5 is a good default).
+func NewVAD(modelPath string, threads int, threshold float64) (*VAD, error) {
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("VAD model file not found: %s", modelPath)
+	}
+
+	cModelPath := C.CString(modelPath)
+	defer C.free(unsafe.Pointer(cModelPath))
+
+	params := C.whisper_vad_default_context_params()
+	params.n_threads = C.int(threads)
+	params.use_gpu = C.bool(false) // Tiny model, not worth a GPU round trip
+
+	ctx := C.whisper_vad_init_from_file_with_params(cModelPath, params)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to initialize VAD model: %s", modelPath)
+	}
+	fmt.Printf("[whisper] VAD model: %s\n", modelPath)
+
+	return &VAD{ctx: ctx, threshold: float32(threshold)}, nil
+}
+
+// Detect returns the speech segments of 16 kHz mono audio
+func (v *VAD) Detect(samples []float32) ([]SpeechSegment, error) {
+	if len(samples) == 0 {
+		return nil, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.ctx == nil {
+		return nil, fmt.Errorf("VAD context not initialized")
+	}
+
+	params := C.whisper_vad_default_params()
+	params.threshold = C.float(v.threshold)
+	params.speech_pad_ms = 0 // The caller pads segments itself
+
+	segments := C.whisper_vad_segments_from_samples(v.ctx, params, (*C.float)(unsafe.Pointer(&samples[0])), C.int(len(samples)))
+	if segments == nil {
+		return nil, fmt.Errorf("speech detection failed")
+	}
+	defer C.whisper_vad_free_segments(segments)
+
+	// Segment times are in centiseconds, like whisper's timestamps
+	n := int(C.whisper_vad_segments_n_segments(segments))
+	result := make([]SpeechSegment, n)
+	for i := 0; i < n; i++ {
+		t0 := float64(C.whisper_vad_segments_get_segment_t0(segments, C.int(i)))
+		t1 := float64(C.whisper_vad_segments_get_segment_t1(segments, C.int(i)))
+		result[i] = SpeechSegment{
+			Start: time.Duration(t0 * float64(10*time.Millisecond)),
+			End:   time.Duration(t1 * float64(10*time.Millisecond)),
+		}
+	}
+	return result, nil
+}
+
+// Close frees the VAD model
+func (v *VAD) Close() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.ctx != nil {
+		C.whisper_vad_free(v.ctx)
+		v.ctx = nil
+	}
+}
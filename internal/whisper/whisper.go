@@ -8,16 +8,158 @@ import "C"
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"time"
 	"unsafe"
 )
 
 // Transcriber handles audio transcription using whisper.cpp
 type Transcriber struct {
+	mu               sync.Mutex // whisper contexts are not safe for concurrent use
 	ctx              *C.struct_whisper_context
 	modelPath        string
 	threads          int
+	gpuDevice        int // CUDA device the model runs on
 	prompt           string
-	allowedLanguages []string // Restrict detection to these languages (e.g. ["de", "en"])
+	languagePrompts  map[string]string // Prompt by language code, replaces prompt once the language is known
+	allowedLanguages []string          // Restrict detection to these languages (e.g. ["de", "en"])
+	decoding         Decoding
+	noSpeechThold    float32 // Drop segments more likely than this to be silence or noise, 0 = keep all
+
+	// Language detected for recent utterances (language_cache_seconds)
+	languageCacheTTL time.Duration
+	cachedLang       string
+	cachedUntil      time.Time
+}
+
+// Language cache confidence: a detection is only cached above
+// languageCacheMinDetection, and dropped again when a transcription in the
+// cached language has a mean word probability below languageCacheMinProbability
+const (
+	languageCacheMinDetection   = 0.8
+	languageCacheMinProbability = 0.5
+)
+
+// Word is a single transcribed word with its decoder confidence
+type Word struct {
+	Text        string  // Word text including its leading space
+	Probability float32 // Lowest token probability within the word (0.0-1.0)
+}
+
+// Segment is a transcribed segment as returned by whisper
+type Segment struct {
+	Text         string
+	Start        time.Duration // Offset of the segment within the audio
+	End          time.Duration
+	Words        []Word
+	NoSpeechProb float32 // Probability that the segment is silence or noise rather than speech
+}
+
+// Result holds the outcome of a transcription
+type Result struct {
+	Text     string
+	Language string
+	Segments []Segment
+}
+
+// Words returns all words of the result in order
+func (r *Result) Words() []Word {
+	var words []Word
+	for _, seg := range r.Segments {
+		words = append(words, seg.Words...)
+	}
+	return words
+}
+
+// MeanProbability returns the average word probability, 1 for a result without words
+func (r *Result) MeanProbability() float32 {
+	words := r.Words()
+	if len(words) == 0 {
+		return 1
+	}
+	var sum float32
+	for _, w := range words {
+		sum += w.Probability
+	}
+	return sum / float32(len(words))
+}
+
+// LowConfidenceWords returns the number of words below the given probability threshold
+func (r *Result) LowConfidenceWords(threshold float32) int {
+	count := 0
+	for _, w := range r.Words() {
+		if w.Probability < threshold {
+			count++
+		}
+	}
+	return count
+}
+
+// LowConfidence returns the words below the given probability threshold in order,
+// without surrounding spaces
+func (r *Result) LowConfidence(threshold float32) []string {
+	var words []string
+	for _, w := range r.Words() {
+		if w.Probability < threshold {
+			if text := strings.TrimSpace(w.Text); text != "" {
+				words = append(words, text)
+			}
+		}
+	}
+	return words
+}
+
+// Highlight returns the text with every word below the probability threshold
+// wrapped in the open/close markers (e.g. "⟨", "⟩")
+func (r *Result) Highlight(threshold float32, open, close string) string {
+	var sb strings.Builder
+	for _, w := range r.Words() {
+		if w.Probability >= threshold {
+			sb.WriteString(w.Text)
+			continue
+		}
+		// Keep the leading space outside of the markers
+		trimmed := strings.TrimLeft(w.Text, " ")
+		sb.WriteString(w.Text[:len(w.Text)-len(trimmed)])
+		sb.WriteString(open + trimmed + close)
+	}
+	return sb.String()
+}
+
+// Options holds per-transcription overrides
+type Options struct {
+	Prompt          string            // Initial prompt (empty = transcriber default)
+	LanguagePrompts map[string]string // Prompt by language code, replaces Prompt once the language is known
+	Language        string            // Transcribe in this language instead of detecting it (e.g. "de")
+
+	Grammar        *Grammar // Restrict decoding to text the grammar accepts (nil = free text)
+	GrammarPenalty float32  // Logit penalty for tokens the grammar rejects
+}
+
+// Decoding selects how whisper picks tokens and when it decodes a segment
+// again at a higher temperature because the result looks like a hallucination
+type Decoding struct {
+	BeamSize int // Beams searched per segment, 0 or 1 = greedy decoding
+	BestOf   int // Candidates sampled when falling back to a higher temperature (greedy only, 0 = whisper default)
+
+	Temperature    float32 // Initial sampling temperature, 0 = always the most likely token
+	TemperatureInc float32 // Added to the temperature for every fallback, 0 = no fallback
+	EntropyThold   float32 // Fall back when the token entropy is below this (repetitions)
+	LogprobThold   float32 // Fall back when the average token log probability is below this
+}
+
+// DefaultDecoding is whisper.cpp's default greedy decoding
+var DefaultDecoding = Decoding{BestOf: 5, TemperatureInc: 0.2, EntropyThold: 2.4, LogprobThold: -1}
+
+// String describes the decoding strategy for logs
+func (d Decoding) String() string {
+	strategy := "greedy"
+	if d.BeamSize > 1 {
+		strategy = fmt.Sprintf("beam search (%d beams)", d.BeamSize)
+	}
+	return fmt.Sprintf("%s, temperature %.2f +%.2f, entropy %.2f, logprob %.2f",
+		strategy, d.Temperature, d.TemperatureInc, d.EntropyThold, d.LogprobThold)
 }
 
 // IsCudaEnabled returns whether CUDA support is enabled
@@ -25,8 +167,27 @@ func IsCudaEnabled() bool {
 	return cudaEnabled
 }
 
-// New creates a new transcriber
-func New(modelPath string, threads int, prompt string, allowedLanguages []string) (*Transcriber, error) {
+// Version returns the version of the linked whisper.cpp, e.g. "1.7.4"
+func Version() string {
+	return C.GoString(C.whisper_version())
+}
+
+// SystemInfo returns whisper.cpp's summary of the backends and CPU features it
+// uses, e.g. "WHISPER : COREML = 0 | OPENVINO = 0 | CPU : SSE3 = 1 | AVX = 1 ..."
+func SystemInfo() string {
+	return strings.TrimSpace(C.GoString(C.whisper_print_system_info()))
+}
+
+// IsLanguage reports whether whisper knows a language code (e.g. "de") or name
+func IsLanguage(code string) bool {
+	cCode := C.CString(code)
+	defer C.free(unsafe.Pointer(cCode))
+	return C.whisper_lang_id(cCode) >= 0
+}
+
+// New creates a new transcriber. gpuDevice selects the GPU on systems with
+// several of them (0 = first), it is ignored without CUDA.
+func New(modelPath string, threads int, gpuDevice int, prompt string, allowedLanguages []string) (*Transcriber, error) {
 	// Check if model file exists
 	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("model file not found: %s", modelPath)
@@ -43,9 +204,12 @@ func New(modelPath string, threads int, prompt string, allowedLanguages []string
 	}
 
 	if cudaEnabled {
-		fmt.Println("[whisper] Acceleration: CUDA (GPU)")
+		fmt.Printf("[whisper] Acceleration: CUDA (GPU %d)\n", gpuDevice)
 	} else {
 		fmt.Println("[whisper] Acceleration: CPU only")
+		if gpuDevice != 0 {
+			fmt.Printf("[whisper] Ignoring GPU device %d, built without CUDA\n", gpuDevice)
+		}
 	}
 	if prompt != "" {
 		fmt.Printf("[whisper] Initial prompt: %s\n", prompt)
@@ -55,7 +219,9 @@ func New(modelPath string, threads int, prompt string, allowedLanguages []string
 	cModelPath := C.CString(modelPath)
 	defer C.free(unsafe.Pointer(cModelPath))
 
-	ctx := C.whisper_init_from_file(cModelPath)
+	ctxParams := C.whisper_context_default_params()
+	ctxParams.gpu_device = C.int(gpuDevice)
+	ctx := C.whisper_init_from_file_with_params(cModelPath, ctxParams)
 	if ctx == nil {
 		return nil, fmt.Errorf("failed to initialize whisper model: %s", modelPath)
 	}
@@ -66,26 +232,63 @@ func New(modelPath string, threads int, prompt string, allowedLanguages []string
 		ctx:              ctx,
 		modelPath:        modelPath,
 		threads:          threads,
+		gpuDevice:        gpuDevice,
 		prompt:           prompt,
 		allowedLanguages: allowedLanguages,
+		decoding:         DefaultDecoding,
 	}, nil
 }
 
+// Device describes the hardware transcribing, e.g. "CUDA GPU 1" or "CPU"
+func (t *Transcriber) Device() string {
+	if cudaEnabled {
+		return fmt.Sprintf("CUDA GPU %d", t.gpuDevice)
+	}
+	return "CPU"
+}
+
 // Transcribe transcribes audio data to text
-func (t *Transcriber) Transcribe(samples []float32) (string, error) {
+func (t *Transcriber) Transcribe(samples []float32, opts Options) (*Result, error) {
 	if len(samples) == 0 {
-		return "", fmt.Errorf("no audio data")
+		return nil, fmt.Errorf("no audio data")
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if t.ctx == nil {
-		return "", fmt.Errorf("whisper context not initialized")
+		return nil, fmt.Errorf("whisper context not initialized")
 	}
+	return t.transcribe(samples, opts)
+}
 
-	fmt.Printf("🧠 Processing audio with Whisper (auto-detect language)...\n")
+// transcribe runs whisper on the audio, t.mu must be held
+func (t *Transcriber) transcribe(samples []float32, opts Options) (*Result, error) {
+	if opts.Language != "" {
+		fmt.Printf("🧠 Processing audio with Whisper (language: %s)...\n", opts.Language)
+	} else {
+		fmt.Printf("🧠 Processing audio with Whisper (auto-detect language)...\n")
+	}
 	fmt.Printf("   Samples: %d\n", len(samples))
 
-	// Get default parameters
-	params := C.whisper_full_default_params(C.WHISPER_SAMPLING_GREEDY)
+	// Get default parameters for the decoding strategy
+	var params C.struct_whisper_full_params
+	if t.decoding.BeamSize > 1 {
+		params = C.whisper_full_default_params(C.WHISPER_SAMPLING_BEAM_SEARCH)
+		params.beam_search.beam_size = C.int(t.decoding.BeamSize)
+	} else {
+		params = C.whisper_full_default_params(C.WHISPER_SAMPLING_GREEDY)
+	}
+	if t.decoding.BestOf > 0 {
+		params.greedy.best_of = C.int(t.decoding.BestOf)
+	}
+	params.temperature = C.float(t.decoding.Temperature)
+	params.temperature_inc = C.float(t.decoding.TemperatureInc)
+	params.entropy_thold = C.float(t.decoding.EntropyThold)
+	params.logprob_thold = C.float(t.decoding.LogprobThold)
+	if t.noSpeechThold > 0 {
+		params.no_speech_thold = C.float(t.noSpeechThold)
+	}
 
 	// Configure parameters
 	params.n_threads = C.int(t.threads)
@@ -99,72 +302,54 @@ func (t *Transcriber) Transcribe(samples []float32) (string, error) {
 	params.duration_ms = 0
 	params.single_segment = C.bool(false)
 
-	// Set initial prompt if provided
-	var cPrompt *C.char
-	if t.prompt != "" {
-		cPrompt = C.CString(t.prompt)
-		defer C.free(unsafe.Pointer(cPrompt))
-		params.initial_prompt = cPrompt
+	// Constrain decoding to the grammar
+	if opts.Grammar != nil {
+		rules, free := opts.Grammar.cRules()
+		defer free()
+		params.grammar_rules = rules
+		params.n_grammar_rules = C.size_t(len(opts.Grammar.rules))
+		params.i_start_rule = C.size_t(opts.Grammar.root)
+		params.grammar_penalty = C.float(opts.GrammarPenalty)
+	}
+
+	prompt, languagePrompts := t.prompt, t.languagePrompts
+	if opts.Prompt != "" || opts.LanguagePrompts != nil {
+		prompt, languagePrompts = opts.Prompt, opts.LanguagePrompts
 	}
 
-	// Pre-detect language if allowed_languages is set
-	if len(t.allowedLanguages) > 0 {
-		// First, process audio to get mel spectrogram for language detection
-		// We need to encode the audio first
-		if C.whisper_pcm_to_mel(t.ctx, (*C.float)(unsafe.Pointer(&samples[0])), C.int(len(samples)), C.int(t.threads)) != 0 {
-			fmt.Printf("[WARN] Failed to encode audio for language detection, using auto-detect\n")
-			params.language = nil
+	// Use a fixed language, or pre-detect it if allowed_languages is set or the
+	// prompt depends on it
+	cachedLanguage := false
+	lang := opts.Language
+	if lang == "" && (len(t.allowedLanguages) > 0 || len(languagePrompts) > 0) {
+		lang = t.cachedLanguage()
+		if lang != "" {
+			fmt.Printf("[CACHED] Using language: %s\n", lang)
+			cachedLanguage = true
 		} else {
-			// Get language probabilities
-			maxLangID := int(C.whisper_lang_max_id())
-			probs := make([]float32, maxLangID+1)
-
-			langID := C.whisper_lang_auto_detect(
-				t.ctx,
-				0, // offset_ms
-				C.int(t.threads),
-				(*C.float)(unsafe.Pointer(&probs[0])),
-			)
-
-			if langID < 0 {
-				fmt.Printf("[WARN] Language detection failed, using auto-detect\n")
-				params.language = nil
-			} else {
-				// Find best language from allowed list
-				bestLang := ""
-				bestProb := float32(-1.0)
-
-				for _, lang := range t.allowedLanguages {
-					cLangTemp := C.CString(lang)
-					id := int(C.whisper_lang_id(cLangTemp))
-					C.free(unsafe.Pointer(cLangTemp))
-
-					if id >= 0 && id < len(probs) {
-						prob := probs[id]
-						fmt.Printf("[DETECT] %s: %.2f%%\n", lang, prob*100)
-						if prob > bestProb {
-							bestProb = prob
-							bestLang = lang
-						}
-					}
-				}
-
-				if bestLang != "" {
-					fmt.Printf("[SELECTED] Using language: %s (%.2f%% confidence)\n", bestLang, bestProb*100)
-					cLang := C.CString(bestLang)
-					defer C.free(unsafe.Pointer(cLang))
-					params.language = cLang
-				} else {
-					fmt.Printf("[WARN] No allowed language detected, using auto-detect\n")
-					params.language = nil
-				}
-			}
+			lang = t.detectLanguage(samples)
 		}
+	}
+	if lang != "" {
+		cLang := C.CString(lang)
+		defer C.free(unsafe.Pointer(cLang))
+		params.language = cLang
 	} else {
 		// No restriction, auto-detect from all languages
 		params.language = nil
 	}
 
+	// Set initial prompt if provided, the one for the language if there is one
+	if languagePrompt, ok := languagePrompts[lang]; ok && lang != "" {
+		prompt = languagePrompt
+	}
+	var cPrompt *C.char
+	if prompt != "" {
+		cPrompt = C.CString(prompt)
+		defer C.free(unsafe.Pointer(cPrompt))
+		params.initial_prompt = cPrompt
+	}
+
 	// Run transcription
 	ret := C.whisper_full(
 		t.ctx,
@@ -174,22 +359,38 @@ func (t *Transcriber) Transcribe(samples []float32) (string, error) {
 	)
 
 	if ret != 0 {
-		return "", fmt.Errorf("whisper_full failed with code: %d", ret)
+		return nil, fmt.Errorf("whisper_full failed with code: %d", ret)
 	}
 
 	// Get number of segments
 	nSegments := int(C.whisper_full_n_segments(t.ctx))
 	if nSegments == 0 {
-		return "", fmt.Errorf("no segments transcribed")
+		return nil, fmt.Errorf("no segments transcribed")
 	}
 
 	// Concatenate all segments
-	var result string
+	result := &Result{}
 	for i := 0; i < nSegments; i++ {
 		text := C.whisper_full_get_segment_text(t.ctx, C.int(i))
-		if text != nil {
-			result += C.GoString(text)
+		if text == nil {
+			continue
+		}
+		// Segment timestamps are in units of 10ms
+		seg := Segment{
+			Text:         C.GoString(text),
+			Start:        time.Duration(C.whisper_full_get_segment_t0(t.ctx, C.int(i))) * 10 * time.Millisecond,
+			End:          time.Duration(C.whisper_full_get_segment_t1(t.ctx, C.int(i))) * 10 * time.Millisecond,
+			Words:        t.segmentWords(i),
+			NoSpeechProb: float32(C.whisper_full_get_segment_no_speech_prob(t.ctx, C.int(i))),
 		}
+		// Breathing and noise come out as "Thank you." and the like
+		if t.noSpeechThold > 0 && seg.NoSpeechProb > t.noSpeechThold {
+			fmt.Printf("[whisper] Dropped segment %.1fs-%.1fs (%.0f%% no speech)\n",
+				seg.Start.Seconds(), seg.End.Seconds(), seg.NoSpeechProb*100)
+			continue
+		}
+		result.Text += seg.Text
+		result.Segments = append(result.Segments, seg)
 	}
 
 	// Show final language used for transcription
@@ -197,16 +398,249 @@ func (t *Transcriber) Transcribe(samples []float32) (string, error) {
 	if langID >= 0 {
 		langStr := C.whisper_lang_str(langID)
 		if langStr != nil {
-			detectedLang := C.GoString(langStr)
-			fmt.Printf("[TRANSCRIBED] Language: %s\n", detectedLang)
+			result.Language = C.GoString(langStr)
+			fmt.Printf("[TRANSCRIBED] Language: %s\n", result.Language)
 		}
 	}
 
+	// Unsure words throughout mean the cached language no longer fits, detect it again
+	if cachedLanguage {
+		if p := result.MeanProbability(); p < languageCacheMinProbability {
+			fmt.Printf("[CACHED] Low confidence (%.0f%%), detecting the language again\n", p*100)
+			t.cachedLang = ""
+			return t.transcribe(samples, opts)
+		}
+		t.cachedUntil = time.Now().Add(t.languageCacheTTL)
+	}
+
 	return result, nil
 }
 
+// sampleRate is the rate of the audio whisper transcribes
+const sampleRate = 16000
+
+// Span is a part of a recording, in samples
+type Span struct {
+	Start, End int
+}
+
+// TranscribeSpans transcribes every span of samples on its own, each in the
+// language detected for it, so a recording switching between the allowed
+// languages isn't forced into one of them. Segment times are relative to
+// samples, the result's language is the one spoken longest.
+func (t *Transcriber) TranscribeSpans(samples []float32, spans []Span, opts Options) (*Result, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ctx == nil {
+		return nil, fmt.Errorf("whisper context not initialized")
+	}
+
+	// A span's language says nothing about the next dictation, keep the cache as it was
+	cachedLang, cachedUntil := t.cachedLang, t.cachedUntil
+	defer func() { t.cachedLang, t.cachedUntil = cachedLang, cachedUntil }()
+
+	result := &Result{}
+	spoken := make(map[string]int)
+	for i, span := range spans {
+		if span.Start < 0 || span.End > len(samples) || span.Start >= span.End {
+			continue
+		}
+		part := samples[span.Start:span.End]
+		spanOpts := opts
+		spanOpts.Language = t.detectLanguage(part)
+		fmt.Printf("[SPAN %d] %.1fs-%.1fs\n", i+1, float64(span.Start)/sampleRate, float64(span.End)/sampleRate)
+
+		res, err := t.transcribe(part, spanOpts)
+		if err != nil {
+			// Nothing but noise in this span
+			continue
+		}
+		offset := time.Duration(span.Start) * time.Second / sampleRate
+		for _, seg := range res.Segments {
+			seg.Start += offset
+			seg.End += offset
+			result.Segments = append(result.Segments, seg)
+		}
+		result.Text += res.Text
+		spoken[res.Language] += len(part)
+	}
+	if len(result.Segments) == 0 {
+		return nil, fmt.Errorf("no segments transcribed")
+	}
+
+	longest := 0
+	for language, n := range spoken {
+		if language != "" && n > longest {
+			result.Language, longest = language, n
+		}
+	}
+	return result, nil
+}
+
+// detectLanguage runs whisper's language detection and picks the most likely
+// of the allowed languages (all languages without allowed_languages). Confident
+// detections are cached. Returns "" when detection fails.
+func (t *Transcriber) detectLanguage(samples []float32) string {
+	// First, process audio to get mel spectrogram for language detection
+	// We need to encode the audio first
+	if C.whisper_pcm_to_mel(t.ctx, (*C.float)(unsafe.Pointer(&samples[0])), C.int(len(samples)), C.int(t.threads)) != 0 {
+		fmt.Printf("[WARN] Failed to encode audio for language detection, using auto-detect\n")
+		return ""
+	}
+
+	// Get language probabilities
+	maxLangID := int(C.whisper_lang_max_id())
+	probs := make([]float32, maxLangID+1)
+
+	langID := C.whisper_lang_auto_detect(
+		t.ctx,
+		0, // offset_ms
+		C.int(t.threads),
+		(*C.float)(unsafe.Pointer(&probs[0])),
+	)
+
+	if langID < 0 {
+		fmt.Printf("[WARN] Language detection failed, using auto-detect\n")
+		return ""
+	}
+
+	// Find best language from allowed list
+	bestLang := ""
+	bestProb := float32(-1.0)
+
+	candidates := t.allowedLanguages
+	if len(candidates) == 0 {
+		candidates = []string{C.GoString(C.whisper_lang_str(langID))}
+	}
+	for _, lang := range candidates {
+		cLangTemp := C.CString(lang)
+		id := int(C.whisper_lang_id(cLangTemp))
+		C.free(unsafe.Pointer(cLangTemp))
+
+		if id >= 0 && id < len(probs) {
+			prob := probs[id]
+			fmt.Printf("[DETECT] %s: %.2f%%\n", lang, prob*100)
+			if prob > bestProb {
+				bestProb = prob
+				bestLang = lang
+			}
+		}
+	}
+
+	if bestLang == "" {
+		fmt.Printf("[WARN] No allowed language detected, using auto-detect\n")
+		return ""
+	}
+	fmt.Printf("[SELECTED] Using language: %s (%.2f%% confidence)\n", bestLang, bestProb*100)
+
+	// Only a clear detection is worth reusing for the next utterances
+	if t.languageCacheTTL > 0 && bestProb >= languageCacheMinDetection {
+		t.cachedLang = bestLang
+		t.cachedUntil = time.Now().Add(t.languageCacheTTL)
+	}
+	return bestLang
+}
+
+// cachedLanguage returns the language detected for a recent utterance, or ""
+// when there is none or it expired
+func (t *Transcriber) cachedLanguage() string {
+	if t.cachedLang == "" || time.Now().After(t.cachedUntil) {
+		t.cachedLang = ""
+		return ""
+	}
+	return t.cachedLang
+}
+
+// SetLanguageCache keeps a detected language for consecutive utterances that
+// follow each other within ttl, skipping the detection pass (0 disables caching)
+func (t *Transcriber) SetLanguageCache(ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.languageCacheTTL = ttl
+	if ttl <= 0 {
+		t.cachedLang = ""
+	}
+}
+
+// SetDecoding switches between greedy decoding and beam search and sets the
+// temperature fallback
+func (t *Transcriber) SetDecoding(decoding Decoding) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if decoding != t.decoding {
+		fmt.Printf("[whisper] Decoding: %s\n", decoding)
+	}
+	t.decoding = decoding
+}
+
+// SetAllowedLanguages restricts language detection to languages (empty = all
+// languages). A cached detection is dropped when they change.
+func (t *Transcriber) SetAllowedLanguages(languages []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if strings.Join(languages, ",") == strings.Join(t.allowedLanguages, ",") {
+		return
+	}
+	if len(languages) > 0 {
+		fmt.Printf("[whisper] Language mode: AUTO-DETECT (restricted to: %v)\n", languages)
+	} else {
+		fmt.Println("[whisper] Language mode: AUTO-DETECT (all languages)")
+	}
+	t.allowedLanguages = languages
+	t.cachedLang = ""
+}
+
+// SetLanguagePrompts sets prompts by language code, used instead of the
+// transcriber's prompt once the language is fixed or detected
+func (t *Transcriber) SetLanguagePrompts(prompts map[string]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.languagePrompts = prompts
+}
+
+// SetNoSpeechThreshold drops segments whose no-speech probability is above
+// threshold from the results (0 keeps all segments)
+func (t *Transcriber) SetNoSpeechThreshold(threshold float32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.noSpeechThold = threshold
+}
+
+// segmentWords merges the tokens of a segment into words with their confidence
+func (t *Transcriber) segmentWords(segment int) []Word {
+	eot := C.whisper_token_eot(t.ctx)
+	nTokens := int(C.whisper_full_n_tokens(t.ctx, C.int(segment)))
+
+	var words []Word
+	for j := 0; j < nTokens; j++ {
+		// Skip special tokens (timestamps, start/end of transcript)
+		if C.whisper_full_get_token_id(t.ctx, C.int(segment), C.int(j)) >= eot {
+			continue
+		}
+
+		text := C.GoString(C.whisper_full_get_token_text(t.ctx, C.int(segment), C.int(j)))
+		prob := float32(C.whisper_full_get_token_p(t.ctx, C.int(segment), C.int(j)))
+
+		// A leading space starts a new word, everything else continues the current one
+		if len(words) == 0 || strings.HasPrefix(text, " ") {
+			words = append(words, Word{Text: text, Probability: prob})
+			continue
+		}
+		last := &words[len(words)-1]
+		last.Text += text
+		if prob < last.Probability {
+			last.Probability = prob
+		}
+	}
+	return words
+}
+
 // Close releases resources
 func (t *Transcriber) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if t.ctx != nil {
 		C.whisper_free(t.ctx)
 		t.ctx = nil
@@ -1,54 +1,249 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/pa/hyprwhspr/internal/audio"
+	"github.com/pa/hyprwhspr/internal/batch"
 	"github.com/pa/hyprwhspr/internal/command"
+	"github.com/pa/hyprwhspr/internal/compose"
 	"github.com/pa/hyprwhspr/internal/config"
+	"github.com/pa/hyprwhspr/internal/doctor"
+	"github.com/pa/hyprwhspr/internal/history"
+	"github.com/pa/hyprwhspr/internal/hyprland"
 	"github.com/pa/hyprwhspr/internal/inject"
 	"github.com/pa/hyprwhspr/internal/ipc"
+	"github.com/pa/hyprwhspr/internal/llm"
+	"github.com/pa/hyprwhspr/internal/markers"
+	"github.com/pa/hyprwhspr/internal/menu"
 	"github.com/pa/hyprwhspr/internal/models"
+	"github.com/pa/hyprwhspr/internal/notify"
+	"github.com/pa/hyprwhspr/internal/overlay"
+	"github.com/pa/hyprwhspr/internal/postprocess"
+	"github.com/pa/hyprwhspr/internal/priority"
+	"github.com/pa/hyprwhspr/internal/stats"
+	"github.com/pa/hyprwhspr/internal/transcript"
+	"github.com/pa/hyprwhspr/internal/trigger"
+	"github.com/pa/hyprwhspr/internal/tts"
+	"github.com/pa/hyprwhspr/internal/tui"
+	"github.com/pa/hyprwhspr/internal/update"
 	"github.com/pa/hyprwhspr/internal/whisper"
 )
 
+// version of this build, packagers can set it with -ldflags "-X main.version=1.2.3"
+var version = "1.0.0-go"
+
 type App struct {
-	cfg         *config.Config
+	cfg         *config.Config // effective config: the file with the active named profile applied
+	baseCfg     *config.Config // config as loaded from the file
+	profile     string         // active named profile (empty = none)
+	language    string         // language set with "hyprwhspr lang" ("auto" = detect), empty = from the config
+	cfgPath     string
 	cfgWatcher  *config.Watcher
 	ipcServer   *ipc.Server
 	recorder    *audio.Recorder
 	loopbackRec *audio.LoopbackRecorder
-	aecProc     *audio.AECProcessor
-	vadProc     *audio.VADProcessor
+	aecProc     audio.AECProcessor
+	vadProc     audio.VoiceDetector
+	sileroVAD   *whisper.VAD // model behind vadProc with vad_engine "silero"
 	transcriber *whisper.Transcriber
+	activeModel string // model the transcriber loaded, the English-only variant of cfg.Model with english_model
 	injector    *inject.Injector
+	injectQueue *inject.Queue // keeps injections in dictation order
 	player      *audio.Player
 	cmdExecutor *command.Executor
+	speaker     *tts.Speaker // Spoken command confirmations and readback
+	lastText    string       // Last injected dictation, for readback
+	history     *history.Store
+	archive     *audio.Archive
+	stats       *stats.Tracker
+	metrics     *stats.CSVLog     // per-dictation metrics (--log-metrics), nil = disabled
+	composer    *compose.Buffer   // nil unless compose mode is on
+	triggers    []trigger.Trigger // push-to-talk keys, D-Bus and global shortcuts
+	overlay     *overlay.Overlay  // on-screen indicator, nil = disabled
+
+	modelMemoryMB float64 // resident memory measured when the model was loaded
 
 	isRecording  bool
 	isProcessing bool
+
+	stopWarnings chan struct{} // closed when the recording stops
+	ticket       uint64        // injection queue place of the current recording
+	stream       *streamState  // partial injection progress (streaming_injection)
+
+	generation      uint64           // incremented for every processing run, a run whose generation is outdated was cancelled
+	processingStuck bool             // processing exceeded the watchdog timeout
+	lastRecording   *lastRecording   // kept for "redo"
+	markers         []markers.Marker // bookmarks set during the current recording
+	nextMode        string           // constrained mode ("number", "yesno", a grammar, ...) of the next recording, "" = free dictation
+	recordingMode   string           // constrained mode of the current recording
+	recordingStart  time.Time        // when the current recording started
+
+	result      string      // "success" or "error" for result_state_seconds after processing
+	resultTimer *time.Timer // clears result
+
+	audioChanged bool // audio settings changed during a recording, recreate the recorders before the next one
+
+	deviceMissing   bool          // the configured audio_device is not connected
+	stopDeviceWatch chan struct{} // closed to stop watching for the audio device
+	quit            chan struct{} // closed by the "quit" command to shut down
+	pressed         bool          // the recording was started by "press" and runs until "release"
+	releaseTimer    *time.Timer   // stops the recording unless "press" comes again first
+	quitOnce        sync.Once
+
+	idleTimer  *time.Timer   // fires after idle_timeout_minutes without activity
+	lowPower   bool          // audio devices (and possibly the model) are released
+	modelReady chan struct{} // closed once a model loaded in the background is ready
+	modelTimer *time.Timer   // fires after model_unload_minutes without activity
+
+	residentModels map[string]*residentModel // resident_models by name
+	nextModel      string                    // resident model for the next recording ("hyprwhspr use")
+	recordingModel string                    // model chosen for the current recording, "" = mode_models or model
+}
+
+// residentModel is an additional model kept loaded next to the main one
+type residentModel struct {
+	transcriber *whisper.Transcriber
+	loaded      string // model file loaded, the English-only variant with english_model
+}
+
+// lastRecording is the most recent recording, kept so it can be processed again
+type lastRecording struct {
+	samples  []float32
+	loopback []float32
+	window   *hyprland.Window
+	markers  []markers.Marker
+	mode     string
+	model    string
+}
+
+// streamState tracks the progress of partial injection during a recording
+type streamState struct {
+	stop      chan struct{} // closed when the recording stops
+	done      chan struct{} // closed once the streaming loop has exited
+	committed int           // samples from the start of the recording already transcribed and injected
+	injected  bool          // whether any text has been injected yet
+	ticket    uint64        // injection queue place of the recording
+}
+
+// jsonOutput is set by --json: client commands print JSON instead of text
+var jsonOutput bool
+
+// Exit codes of the client commands
+const (
+	exitError       = 1 // The command failed
+	exitUnreachable = 2 // The daemon isn't running
+)
+
+// setupTestSeconds is the length of the setup wizard's test recording
+const setupTestSeconds = 4
+
+// controlReply is the JSON form of a daemon's reply to a control command
+type controlReply struct {
+	OK      bool   `json:"ok"`
+	Command string `json:"command"`
+	Result  string `json:"result,omitempty"` // Reply without its "OK: " prefix
+	Error   string `json:"error,omitempty"`
 }
 
 func main() {
+	// Global options apply to whichever command they are given to. --config
+	// and --socket are passed on as environment variables, so every config
+	// load sees them and the daemon's commands talk to the same instance.
+	args := os.Args[:1]
+	for i := 1; i < len(os.Args); i++ {
+		name, value, hasValue := strings.Cut(os.Args[i], "=")
+		switch {
+		case name == "--json" && !hasValue:
+			jsonOutput = true
+		case name == "--config" || name == "--socket":
+			if !hasValue {
+				if i+1 >= len(os.Args) {
+					fmt.Fprintf(os.Stderr, "%s needs a path\n", name)
+					os.Exit(1)
+				}
+				i++
+				value = os.Args[i]
+			}
+			if abs, err := filepath.Abs(value); err == nil {
+				value = abs
+			}
+			env := config.ConfigPathEnv
+			if name == "--socket" {
+				env = config.EnvName("socket_path")
+			}
+			os.Setenv(env, value)
+		default:
+			args = append(args, os.Args[i])
+		}
+	}
+	os.Args = args
+
 	// Check for subcommands
 	if len(os.Args) > 1 {
 		command := os.Args[1]
 
 		switch command {
-		case "start", "stop", "toggle", "status":
+		case "stop", "state", "cancel", "redo", "level", "readback", "quit", "reload":
 			// Control command - send to daemon
 			runControl(command)
 			return
+		case "start", "toggle", "press", "release", "status", "mode", "use", "compose", "profile", "profiles", "lang", "device", "theme", "marker":
+			// Recording with a mode or model, detailed status, compose mode, profile, language, device, sound theme and marker control - send to daemon
+			runControl(strings.Join(os.Args[1:], " "))
+			return
+		case "devices":
+			// List capture devices
+			runListDevices()
+			return
+		case "watch":
+			// Stream state changes from the daemon
+			runWatch()
+			return
+		case "waybar":
+			// Stream state changes as waybar JSON
+			runWaybar()
+			return
+		case "bench":
+			// Compare the speed of models on this machine
+			runBench(os.Args[2:])
+			return
+		case "hyprland-config":
+			// Print Hyprland binds for this installation
+			runHyprlandConfig(os.Args[2:])
+			return
+		case "doctor":
+			// Diagnose the setup
+			runDoctor()
+			return
+		case "setup":
+			// Interactive setup wizard
+			runSetup()
+			return
+		case "tui":
+			// Interactive dashboard
+			runTUI()
+			return
 		case "daemon":
 			// Explicit daemon mode
-			runDaemon()
+			runDaemon(os.Args[2:])
 			return
 		case "download":
 			// Download model command
@@ -76,7 +271,35 @@ func main() {
 				fmt.Fprintf(os.Stderr, "Usage: hyprwhspr model <model>\n")
 				os.Exit(1)
 			}
-			runSetModel(os.Args[2])
+			runControl("model " + os.Args[2])
+			return
+		case "presets":
+			// List whisper prompt presets
+			runPresets()
+			return
+		case "themes":
+			// List sound themes
+			runThemes()
+			return
+		case "config":
+			// Inspect the config file
+			runConfig(os.Args[2:])
+			return
+		case "transcribe":
+			// Transcribe audio files
+			runTranscribe(os.Args[2:])
+			return
+		case "stats":
+			// Show transcription statistics
+			runStats()
+			return
+		case "history":
+			// Show past transcriptions
+			runHistory(os.Args[2:])
+			return
+		case "audit":
+			// Show executed voice commands
+			runAudit(os.Args[2:])
 			return
 		case "help", "-h", "--help":
 			printUsage()
@@ -84,7 +307,16 @@ func main() {
 		case "version", "-v", "--version":
 			printVersion()
 			return
+		case "update":
+			// Check GitHub for a newer release
+			runUpdate(os.Args[2:])
+			return
 		default:
+			// Daemon options without the "daemon" command
+			if strings.HasPrefix(command, "-") {
+				runDaemon(os.Args[1:])
+				return
+			}
 			fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
 			printUsage()
 			os.Exit(1)
@@ -92,7 +324,7 @@ func main() {
 	}
 
 	// No arguments - run daemon by default
-	runDaemon()
+	runDaemon(nil)
 }
 
 func printUsage() {
@@ -104,22 +336,65 @@ func printUsage() {
 	fmt.Println("Daemon Commands:")
 	fmt.Println("  (none)         Start daemon (default)")
 	fmt.Println("  daemon         Start daemon explicitly")
+	fmt.Println("  --replace      Shut down the running daemon and take over (e.g. after an update)")
+	fmt.Println("  quit           Shut down the running daemon")
+	fmt.Println("  reload         Re-read the config, restarting only the components whose settings changed")
+	fmt.Println("  --log-metrics <file> Append per-dictation metrics (duration, VAD ratio, RTF, confidence) to a CSV")
 	fmt.Println("")
 	fmt.Println("Recording Commands:")
-	fmt.Println("  start          Start recording")
+	fmt.Println("  start [mode]   Start recording, optionally constrained to a mode (see mode)")
 	fmt.Println("  stop           Stop recording")
-	fmt.Println("  toggle         Toggle recording on/off")
+	fmt.Println("  toggle [mode]  Toggle recording on/off")
+	fmt.Println("  press [mode]   Start recording until release (hold-to-record, bind/bindr pairs)")
+	fmt.Println("  release        Stop a recording started with press")
 	fmt.Println("  status         Get current status")
+	fmt.Println("  cancel         Discard the transcription that is being processed")
+	fmt.Println("  redo           Process the last recording again")
+	fmt.Println("  marker [label] Bookmark the current moment of the recording")
+	fmt.Println("  mode [number|email|url|yesno|digits|command|<grammar>|none] Constrain the next dictation (no argument shows it)")
+	fmt.Println("  level          Print the input level of the recording as JSON")
+	fmt.Println("  readback       Speak the last dictation aloud (tts_command)")
+	fmt.Println("  compose [on|off|toggle|send|clear] Control compose mode (no argument shows the buffer)")
+	fmt.Println("  profile [name|none] Switch the named profile (no argument shows the active one)")
+	fmt.Println("  profiles       List the named profiles")
+	fmt.Println("  lang [code|auto|default] Force the transcription language until restart (no argument shows it)")
+	fmt.Println("  devices        List capture devices with their IDs")
+	fmt.Println("  device [id|index|default] Switch the microphone (no argument shows the current one)")
+	fmt.Println("  watch          Print state changes as they happen (idle/recording/processing/stuck)")
+	fmt.Println("  tui            Dashboard with the live state, input level and recent transcriptions")
+	fmt.Println("")
+	fmt.Println("Setup:")
+	fmt.Println("  setup          Pick a microphone and model, test a transcription and write the config")
+	fmt.Println("  hyprland-config [--mod SUPER] [--key D] Print binds (toggle, push to talk, model submap) and exec-once")
+	fmt.Println("  doctor         Check injection tools, microphone, models, socket and CUDA, with fixes")
 	fmt.Println("")
 	fmt.Println("Model Management:")
 	fmt.Println("  models         List available and downloaded models")
 	fmt.Println("  download <model> Download a whisper model")
 	fmt.Println("  delete <model>  Delete a downloaded model")
 	fmt.Println("  model <model>  Set the active whisper model")
+	fmt.Println("  bench [--clip file] [--runs N] [model...] Measure load time, real-time factor and memory (default: downloaded models)")
 	fmt.Println("")
 	fmt.Println("Other:")
+	fmt.Println("  waybar         Print the state as waybar JSON on every change")
+	fmt.Println("  presets        List whisper prompt presets")
+	fmt.Println("  themes         List sound themes")
+	fmt.Println("  theme [name|default] Switch the sound theme (no argument shows the current one)")
+	fmt.Println("  config validate Check the config for errors")
+	fmt.Println("  config get <key> Show a config value (e.g. vad_voice_threshold)")
+	fmt.Println("  config set <key> <value> Change a config value and reload the daemon")
+	fmt.Println("  transcribe --dir <path> Transcribe all audio files in a directory to .txt/.srt")
+	fmt.Println("  stats          Show transcription latency per model")
+	fmt.Println("  history [--limit N] [--search term] Show past transcriptions")
+	fmt.Println("  audit [--limit N] Show executed voice commands")
 	fmt.Println("  help           Show this help")
 	fmt.Println("  version        Show version")
+	fmt.Println("  update --check Check GitHub for a newer release (never installs anything)")
+	fmt.Println("")
+	fmt.Println("  --config <file> Use another config file (default ~/.config/hyprwhspr/config.json)")
+	fmt.Println("  --socket <path> Use another daemon socket, e.g. to run and control a second daemon")
+	fmt.Println("  --json         Print JSON instead of text (control commands, status, models, stats, history, audit, doctor).")
+	fmt.Println("                 Exit code 1 if the command failed, 2 if the daemon isn't running")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  hyprwhspr              # Start daemon")
@@ -156,6 +431,14 @@ func runListModels() {
 		os.Exit(1)
 	}
 	modelManager := models.NewManager(cfg.WhisperModelDir)
+	if jsonOutput {
+		infos, err := modelManager.ListModelInfo(cfg.Model)
+		if err != nil {
+			exitJSON(exitError, "models", err)
+		}
+		printJSON(infos)
+		return
+	}
 	modelManager.PrintModelInfo(cfg.Model)
 }
 
@@ -174,38 +457,43 @@ func runDeleteModel(modelName string) {
 	}
 }
 
-func runSetModel(modelName string) {
-	// Get socket path from config
-	cfgPath := config.GetConfigPath()
-	cfg, err := config.Load(cfgPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+func printVersion() {
+	fmt.Printf("hyprwhspr v%s\n", version)
+	fmt.Println("Speech-to-text daemon for Hyprland")
+}
+
+func runUpdate(args []string) {
+	flags := flag.NewFlagSet("update", flag.ExitOnError)
+	check := flags.Bool("check", false, "compare the installed version with the latest release")
+	flags.Parse(args)
+
+	if !*check {
+		fmt.Fprintln(os.Stderr, "hyprwhspr doesn't update itself - update through your package manager or rebuild from source.")
+		fmt.Fprintln(os.Stderr, "Usage: hyprwhspr update --check")
 		os.Exit(1)
 	}
-	socketPath := cfg.SocketPath
 
-	// Create IPC client
-	client := ipc.NewClient(socketPath)
-
-	// Send model command
-	response, err := client.SendCommand("model " + modelName)
+	release, err := update.Latest(10 * time.Second)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		fmt.Fprintf(os.Stderr, "❌ Update check failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Print response
-	fmt.Println(response)
-
-	// Exit with appropriate code
-	if len(response) >= 5 && response[:5] == "ERROR" {
-		os.Exit(1)
+	if !update.Newer(release.Version, version) {
+		fmt.Printf("✅ hyprwhspr v%s is up to date (latest release v%s)\n", version, release.Version)
+		return
 	}
-}
 
-func printVersion() {
-	fmt.Println("hyprwhspr v1.0.0-go")
-	fmt.Println("Speech-to-text daemon for Hyprland")
+	fmt.Printf("🆕 hyprwhspr v%s is available (installed: v%s)\n", release.Version, version)
+	if highlights := release.Highlights(8); len(highlights) > 0 {
+		fmt.Println("")
+		for _, item := range highlights {
+			fmt.Printf("  • %s\n", item)
+		}
+	}
+	if release.URL != "" {
+		fmt.Printf("\n%s\n", release.URL)
+	}
 }
 
 func runControl(command string) {
@@ -221,415 +509,3847 @@ func runControl(command string) {
 	// Create IPC client
 	client := ipc.NewClient(socketPath)
 
+	// The status has a JSON form of its own
+	if jsonOutput && command == "status" {
+		command = "status json"
+	}
+
 	// Send command
 	response, err := client.SendCommand(command)
 	if err != nil {
+		if jsonOutput {
+			exitJSON(exitUnreachable, command, err)
+		}
 		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitUnreachable)
 	}
+	failed := strings.HasPrefix(response, "ERROR")
 
 	// Print response
-	fmt.Println(response)
+	switch {
+	case !jsonOutput:
+		fmt.Println(response)
+	case command == "status json" && !failed:
+		fmt.Println(response)
+	default:
+		reply := controlReply{OK: !failed, Command: command}
+		if failed {
+			reply.Error = strings.TrimPrefix(strings.TrimPrefix(response, "ERROR"), ": ")
+		} else {
+			reply.Result = strings.TrimPrefix(strings.TrimPrefix(response, "OK"), ": ")
+		}
+		printJSON(reply)
+	}
 
 	// Exit with appropriate code
-	if len(response) >= 5 && response[:5] == "ERROR" {
-		os.Exit(1)
+	if failed {
+		os.Exit(exitError)
 	}
 }
 
-func runDaemon() {
-	fmt.Println("🚀 HYPRWHSPR STARTING UP!")
-	fmt.Println(strings.Repeat("=", 50))
+// printJSON writes v as a line of JSON to stdout
+func printJSON(v interface{}) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(exitError)
+	}
+}
 
-	// Load configuration
+// exitJSON prints the error of a command as JSON and exits with code
+func exitJSON(code int, command string, err error) {
+	printJSON(controlReply{Command: command, Error: err.Error()})
+	os.Exit(code)
+}
+
+func runTUI() {
 	cfgPath := config.GetConfigPath()
 	cfg, err := config.Load(cfgPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
 	}
-
-	// Create application
-	app := &App{
-		cfg: cfg,
+	if err := tui.Run(cfgPath, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	// Initialize config watcher
-	if err := app.initConfigWatcher(cfgPath); err != nil {
-		log.Printf("Failed to initialize config watcher: %v", err)
+func runWatch() {
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Initialize components
-	if err := app.initialize(); err != nil {
-		log.Fatalf("Failed to initialize: %v", err)
-	}
+	client := ipc.NewClient(cfg.SocketPath)
 
-	// Start IPC server
-	if err := app.ipcServer.Start(); err != nil {
-		log.Fatalf("Failed to start IPC server: %v", err)
+	// Print the current state first, then every event as it arrives
+	err = client.Watch("state", func(line string, isEvent bool) {
+		if isEvent && strings.HasPrefix(line, "level ") {
+			return // Too frequent to print, for VU meters only
+		}
+		if isEvent {
+			fmt.Println(strings.TrimPrefix(line, "state "))
+		} else {
+			fmt.Println(line)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	fmt.Println("✅ hyprwhspr initialized successfully")
-	fmt.Println("🎧 Running in daemon mode - use hyprwhspr to control recording")
-
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
-
-	fmt.Println("\n🛑 Shutting down hyprwhspr...")
-	app.cleanup()
+// waybarStatus is a line of waybar's custom module JSON protocol
+type waybarStatus struct {
+	Text    string `json:"text"`
+	Alt     string `json:"alt"`
+	Class   string `json:"class"`
+	Tooltip string `json:"tooltip"`
 }
 
-func (app *App) initialize() error {
-	// Initialize audio recorder
-	var err error
-	app.recorder, err = audio.NewRecorder(app.cfg.SampleRate, app.cfg.AudioDevice)
+// runWaybar prints the daemon state as waybar JSON, one line per change. It keeps
+// running (as "offline") while the daemon is down, so it can be used as a continuous
+// "exec" script without signals.
+func runWaybar() {
+	cfg, err := config.Load(config.GetConfigPath())
 	if err != nil {
-		return fmt.Errorf("failed to initialize audio recorder: %w", err)
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
 	}
+	client := ipc.NewClient(cfg.SocketPath)
 
-	// Initialize AEC and VAD if enabled
-	if app.cfg.EchoCancellation {
-		app.loopbackRec, err = audio.NewLoopbackRecorder(app.cfg.SampleRate)
-		if err != nil {
-			fmt.Printf("⚠️  Failed to initialize loopback recorder: %v\n", err)
-		} else {
-			aecConfig := audio.AECConfig{
-				FilterLength:    app.cfg.AECFilterLength,
-				StepSize:        app.cfg.AECStepSize,
-				LeakageFactor:   0.999,
-				EchoSuppression: app.cfg.AECEchoSuppression,
-			}
-			app.aecProc = audio.NewAECProcessor(aecConfig)
-			fmt.Println("✅ Echo cancellation enabled")
+	emit := func(state, detail string) {
+		tooltip := "hyprwhspr: " + state
+		if detail != "" {
+			tooltip += "\n" + detail
 		}
+		data, _ := json.Marshal(waybarStatus{Text: state, Alt: state, Class: state, Tooltip: tooltip})
+		fmt.Println(string(data))
 	}
 
-	if app.cfg.VoiceActivityDetection {
-		vadConfig := audio.VADConfig{
-			FrameSize:       512,
-			Overlap:         256,
-			EnergyThreshold: app.cfg.VADEnergyThreshold,
-			ZcrThreshold:    0.1,
-			VoiceThreshold:  app.cfg.VADVoiceThreshold,
-		}
-		app.vadProc = audio.NewVADProcessor(vadConfig)
-		fmt.Println("✅ Voice activity detection enabled")
+	for {
+		lastError := ""
+		err := client.Watch("state", func(line string, isEvent bool) {
+			if !isEvent {
+				emit(line, "")
+				return
+			}
+			event, payload, _ := strings.Cut(line, " ")
+			switch event {
+			case "error":
+				lastError = payload
+			case "state":
+				detail := ""
+				if payload == "error" {
+					detail = lastError
+				}
+				emit(payload, detail)
+			}
+		})
+		emit("offline", err.Error())
+		time.Sleep(2 * time.Second)
 	}
+}
 
-	// Initialize AEC and VAD if enabled
-	fmt.Printf("🔧 Initializing AEC/VAD - EchoCancellation: %v, VAD: %v\n", app.cfg.EchoCancellation, app.cfg.VoiceActivityDetection)
+func runListDevices() {
+	devices, err := audio.ListCaptureDevices()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list devices: %v\n", err)
+		os.Exit(1)
+	}
 
-	if app.cfg.EchoCancellation {
-		fmt.Println("🔧 Creating loopback recorder...")
-		app.loopbackRec, err = audio.NewLoopbackRecorder(app.cfg.SampleRate)
-		if err != nil {
-			fmt.Printf("❌ Failed to initialize loopback recorder: %v\n", err)
-			fmt.Println("❌ Echo cancellation disabled")
+	// Mark the configured device
+	selected := -1
+	if cfg, err := config.Load(config.GetConfigPath()); err == nil && cfg.AudioDevice != nil && *cfg.AudioDevice != "" {
+		if dev, err := audio.MatchDevice(devices, *cfg.AudioDevice); err == nil {
+			selected = dev.Index
 		} else {
-			fmt.Println("✅ Loopback recorder created")
-			aecConfig := audio.AECConfig{
-				FilterLength:    app.cfg.AECFilterLength,
-				StepSize:        app.cfg.AECStepSize,
-				LeakageFactor:   0.999,
-				EchoSuppression: app.cfg.AECEchoSuppression,
-			}
-			app.aecProc = audio.NewAECProcessor(aecConfig)
-			fmt.Println("✅ Echo cancellation enabled")
+			fmt.Printf("⚠️  audio_device: %v\n\n", err)
 		}
 	}
 
-	if app.cfg.VoiceActivityDetection {
-		fmt.Println("🔧 Creating VAD processor...")
-		vadConfig := audio.VADConfig{
-			FrameSize:       512,
-			Overlap:         256,
-			EnergyThreshold: app.cfg.VADEnergyThreshold,
-			ZcrThreshold:    0.1,
-			VoiceThreshold:  app.cfg.VADVoiceThreshold,
+	fmt.Println("🎤 Capture devices:")
+	for _, dev := range devices {
+		marker := " "
+		if dev.Index == selected || selected < 0 && dev.IsDefault {
+			marker = "★"
+		}
+		note := ""
+		if dev.IsMonitor {
+			note = " (system audio, not a microphone)"
+		}
+		if dev.IsDefault {
+			note += " [default]"
 		}
-		app.vadProc = audio.NewVADProcessor(vadConfig)
-		fmt.Println("✅ Voice activity detection enabled")
+		fmt.Printf("  %s [%d] %s%s\n        id: %s\n", marker, dev.Index, dev.Name, note, dev.ID)
 	}
+	fmt.Println()
+	fmt.Println("  Select one with 'hyprwhspr device <id or index>' (saved to audio_device).")
+}
 
-	// Initialize audio player for notifications
-	app.player, err = audio.NewPlayer(audio.PlayerConfig{
-		AudioFeedback:    app.cfg.AudioFeedback,
-		StartSoundVolume: app.cfg.StartSoundVolume,
-		StopSoundVolume:  app.cfg.StopSoundVolume,
-		StartSoundPath:   app.cfg.StartSoundPath,
-		StopSoundPath:    app.cfg.StopSoundPath,
-	})
+func runThemes() {
+	cfg, err := config.Load(config.GetConfigPath())
 	if err != nil {
-		return fmt.Errorf("failed to initialize audio player: %w", err)
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Initialize whisper transcriber
-	modelPath := filepath.Join(app.cfg.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", app.cfg.Model))
-	app.transcriber, err = whisper.New(modelPath, app.cfg.Threads, app.cfg.WhisperPrompt, app.cfg.AllowedLanguages)
-	if err != nil {
-		return fmt.Errorf("failed to initialize whisper: %w", err)
+	fmt.Println("🔊 Sound themes:")
+	marker := "•"
+	if cfg.SoundTheme == "" {
+		marker = "★"
 	}
-
-	// Initialize text injector
-	app.injector = inject.New()
-	fmt.Println(app.injector.GetStatus())
-
-	// Initialize command executor
-	app.cmdExecutor = command.NewExecutor(app.cfg.CommandMode, app.cfg.Commands)
-	fmt.Println(app.cmdExecutor.GetStatus())
-
-	// Create IPC server
-	app.ipcServer = ipc.NewServer(app.cfg.SocketPath, app.handleCommand)
-
-	return nil
+	fmt.Printf("  %s default\n", marker)
+	for _, name := range audio.SoundThemes() {
+		marker = "•"
+		if name == cfg.SoundTheme {
+			marker = "★"
+		}
+		fmt.Printf("  %s %s\n", marker, name)
+	}
+	fmt.Println()
+	fmt.Println("  Themes are directories in ~/.local/share/hyprwhspr/themes with start.ogg,")
+	fmt.Println("  stop.ogg, warning.ogg, limit.ogg, done.ogg and error.ogg (all optional).")
+	fmt.Println("  Select one with 'hyprwhspr theme <name>'.")
 }
 
-func (app *App) handleCommand(command string) string {
-	// Parse command with arguments
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return "ERROR: Empty command"
+func runPresets() {
+	cfg, err := config.Load(config.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
 	}
 
-	cmd := parts[0]
-	args := parts[1:]
-
-	switch cmd {
-	case "start":
-		if app.isRecording {
-			return "ERROR: Already recording"
-		}
-		if err := app.startRecording(); err != nil {
-			return fmt.Sprintf("ERROR: %v", err)
-		}
-		return "OK: Recording started"
-
-	case "stop":
-		if !app.isRecording {
-			return "ERROR: Not recording"
+	fmt.Println("📝 Whisper prompt presets:")
+	for _, name := range cfg.PresetNames() {
+		prompt, _ := cfg.PresetPrompt(name)
+		marker := "•"
+		if name == cfg.PromptPreset {
+			marker = "★"
 		}
-		if err := app.stopRecording(); err != nil {
-			return fmt.Sprintf("ERROR: %v", err)
+		if len(prompt) > 90 {
+			prompt = prompt[:87] + "..."
 		}
-		return "OK: Recording stopped"
+		fmt.Printf("  %s %-12s %s\n", marker, name, strings.ReplaceAll(prompt, "\n", " "))
+	}
+	fmt.Println()
+	fmt.Println("  Select one with 'hyprwhspr config set prompt_preset <name>' or per app in app_profiles.")
+}
 
-	case "toggle":
-		if app.isRecording {
-			if err := app.stopRecording(); err != nil {
-				return fmt.Sprintf("ERROR: %v", err)
-			}
-			return "OK: Recording stopped"
-		} else {
-			if err := app.startRecording(); err != nil {
-				return fmt.Sprintf("ERROR: %v", err)
-			}
-			return "OK: Recording started"
-		}
+func runConfig(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: hyprwhspr config validate [path] | get <key> | set <key> <value>\n")
+		os.Exit(1)
+	}
 
-	case "status":
-		if app.isRecording {
-			return "1"
-		} else {
-			return "0"
+	switch args[0] {
+	case "validate":
+		cfgPath := config.GetConfigPath()
+		if len(args) > 1 {
+			cfgPath = args[1]
 		}
-
-	case "model":
-		if len(args) < 1 {
-			return "ERROR: model requires a model name"
+		runConfigValidate(cfgPath)
+	case "get":
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Usage: hyprwhspr config get <key>\n")
+			os.Exit(1)
 		}
-		modelName := args[0]
-		if err := app.setModel(modelName); err != nil {
-			return fmt.Sprintf("ERROR: %v", err)
+		runConfigGet(args[1])
+	case "set":
+		if len(args) < 3 {
+			fmt.Fprintf(os.Stderr, "Usage: hyprwhspr config set <key> <value>\n")
+			os.Exit(1)
 		}
-		return fmt.Sprintf("OK: Model set to %s", modelName)
-
+		runConfigSet(args[1], strings.Join(args[2:], " "))
 	default:
-		return fmt.Sprintf("ERROR: Unknown command '%s'", cmd)
+		fmt.Fprintf(os.Stderr, "Unknown config command: %s\n", args[0])
+		os.Exit(1)
 	}
 }
 
-func (app *App) startRecording() error {
-	if app.isRecording {
-		return fmt.Errorf("already recording")
+func runConfigGet(key string) {
+	cfg, err := config.Load(config.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
 	}
-
-	app.isRecording = true
-
+	value, err := cfg.Get(key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(value)
+}
+
+func runConfigSet(key, value string) {
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	updated, err := cfg.Set(key, value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// Refuse values that make the changed option invalid
+	for _, issue := range updated.Validate() {
+		if issue.Field != key && !strings.HasPrefix(issue.Field, key+".") {
+			continue
+		}
+		if issue.Warning {
+			fmt.Printf("⚠️  %s\n", issue)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "❌ %s\n", issue)
+		os.Exit(1)
+	}
+
+	if err := updated.Save(cfgPath); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to save config: %v\n", err)
+		os.Exit(1)
+	}
+	newValue, _ := updated.Get(key)
+	fmt.Printf("✅ %s = %s\n", key, newValue)
+
+	// Tell a running daemon to pick up the change
+	if _, err := ipc.NewClient(updated.SocketPath).SendCommand("reload"); err == nil {
+		fmt.Println("🔄 Daemon reloaded")
+	}
+}
+
+func runConfigValidate(cfgPath string) {
+	fmt.Printf("🔍 Validating %s\n", cfgPath)
+
+	data, err := os.ReadFile(cfgPath)
+	if os.IsNotExist(err) {
+		fmt.Println("ℹ️  Config file does not exist, defaults are used")
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, override := range cfg.EnvOverrides() {
+		fmt.Printf("ℹ️  %s is overridden by the environment\n", override)
+	}
+
+	errors, warnings := 0, 0
+	for _, key := range config.UnknownKeys(data) {
+		fmt.Printf("⚠️  %s: unknown option (typo?)\n", key)
+		warnings++
+	}
+	for _, issue := range cfg.Validate() {
+		if issue.Warning {
+			fmt.Printf("⚠️  %s\n", issue)
+			warnings++
+		} else {
+			fmt.Printf("❌ %s\n", issue)
+			errors++
+		}
+	}
+
+	switch {
+	case errors > 0:
+		fmt.Printf("\n%d error(s), %d warning(s)\n", errors, warnings)
+		os.Exit(1)
+	case warnings > 0:
+		fmt.Printf("\n✅ Config is valid (%d warning(s))\n", warnings)
+	default:
+		fmt.Println("✅ Config is valid")
+	}
+}
+
+func runTranscribe(args []string) {
+	flags := flag.NewFlagSet("transcribe", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory with audio files to transcribe")
+	output := flags.String("output", "", "directory for the transcripts (default: next to the audio files)")
+	parallel := flags.Int("parallel", 1, "number of files transcribed at once (each loads the model)")
+	formats := flags.String("format", "txt,srt", "comma separated output formats (txt, srt)")
+	overwrite := flags.Bool("overwrite", false, "transcribe files again even if their transcripts exist")
+	model := flags.String("model", "", "model to use (default: configured model)")
+	flags.Parse(args)
+
+	if *dir == "" {
+		fmt.Fprintf(os.Stderr, "Usage: hyprwhspr transcribe --dir <path> [--output <path>] [--parallel N] [--format txt,srt] [--model name] [--overwrite]\n")
+		os.Exit(1)
+	}
+
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if *model != "" {
+		cfg.Model = *model
+	}
+
+	modelPath := filepath.Join(cfg.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", cfg.Model))
+	if _, err := os.Stat(modelPath); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Model %s is not downloaded (hyprwhspr download %s)\n", cfg.Model, cfg.Model)
+		os.Exit(1)
+	}
+
+	// Share the CPU threads between the parallel workers
+	threads := cfg.Threads
+	if *parallel > 1 {
+		threads = cfg.Threads / *parallel
+		if threads < 1 {
+			threads = 1
+		}
+	}
+
+	var outputFormats []string
+	for _, f := range strings.Split(*formats, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			outputFormats = append(outputFormats, f)
+		}
+	}
+
+	fmt.Printf("📂 Transcribing %s with %s (%d parallel)\n", *dir, cfg.Model, *parallel)
+	results, err := batch.Run(batch.Options{
+		Dir:        *dir,
+		OutputDir:  *output,
+		Parallel:   *parallel,
+		Formats:    outputFormats,
+		Overwrite:  *overwrite,
+		SampleRate: cfg.SampleRate,
+		NewTranscriber: func() (*whisper.Transcriber, error) {
+			transcriber, err := whisper.New(modelPath, threads, cfg.GPUDevice, cfg.Prompt(), cfg.AllowedLanguages)
+			if err == nil {
+				transcriber.SetLanguagePrompts(cfg.LanguagePrompts())
+				transcriber.SetDecoding(whisperDecoding(cfg.ForModel(cfg.Model)))
+				transcriber.SetNoSpeechThreshold(float32(cfg.NoSpeechThreshold))
+			}
+			return transcriber, err
+		},
+		PostProcess: func(text, language string) string {
+			return buildPostProcessors(cfg, language).Process(text)
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// Feed the local model benchmarks (parallel runs share the CPU and would skew them)
+	if *parallel == 1 {
+		modelManager := models.NewManager(cfg.WhisperModelDir)
+		for _, r := range results {
+			if r.Err == nil && !r.Skipped && r.Audio >= 2*time.Second {
+				modelManager.RecordBenchmark(cfg.Model, r.Wall.Seconds()/r.Audio.Seconds(), 0)
+			}
+		}
+	}
+
+	summary := batch.Summary(results)
+	fmt.Println()
+	fmt.Print(summary)
+
+	summaryDir := *output
+	if summaryDir == "" {
+		summaryDir = *dir
+	}
+	summaryPath := filepath.Join(summaryDir, batch.SummaryFile)
+	if err := os.WriteFile(summaryPath, []byte(summary), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to write summary: %v\n", err)
+	} else {
+		fmt.Printf("📄 Summary written to %s\n", summaryPath)
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+// benchResult is the measured performance of a model on the reference clip
+type benchResult struct {
+	Model          string  `json:"model"`
+	LoadSeconds    float64 `json:"load_seconds"`
+	RealTimeFactor float64 `json:"real_time_factor"` // Transcription time / clip length, best of the runs
+	PeakMemoryMB   float64 `json:"peak_memory_mb"`   // Memory the loaded model took at most while transcribing
+	Text           string  `json:"text"`
+	Error          string  `json:"error,omitempty"`
+}
+
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	clip := flags.String("clip", "", "audio file to transcribe (default: the bundled reference clip)")
+	runs := flags.Int("runs", 2, "transcriptions per model, the fastest counts")
+	flags.Parse(args)
+
+	cfg, err := config.Load(config.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	modelManager := models.NewManager(cfg.WhisperModelDir)
+
+	names := flags.Args()
+	if len(names) == 0 {
+		if names, err = modelManager.ListDownloadedModels(); err != nil || len(names) == 0 {
+			fmt.Fprintf(os.Stderr, "❌ No downloaded models (hyprwhspr download <model>)\n")
+			os.Exit(1)
+		}
+	}
+	if *clip == "" {
+		if *clip, err = audio.FindAsset("bench.wav"); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v, pass an audio file with --clip\n", err)
+			os.Exit(1)
+		}
+	}
+	samples, err := audio.DecodeFile(*clip, cfg.SampleRate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s: %v\n", *clip, err)
+		os.Exit(1)
+	}
+	if *runs < 1 {
+		*runs = 1
+	}
+	clipSeconds := float64(len(samples)) / float64(cfg.SampleRate)
+
+	// whisper logs every load and transcription, keep them out of the report
+	stdout := os.Stdout
+	devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if !jsonOutput {
+		fmt.Printf("⏱️  Benchmarking %d model(s) on %s (%.1fs, %d runs each)\n", len(names), filepath.Base(*clip), clipSeconds, *runs)
+	}
+	var results []benchResult
+	for _, model := range names {
+		if !jsonOutput {
+			fmt.Printf("   %s...\n", model)
+		}
+		if devNull != nil {
+			os.Stdout = devNull
+		}
+		result := benchModel(cfg, modelManager, model, samples, clipSeconds, *runs)
+		os.Stdout = stdout
+		if result.Error == "" {
+			modelManager.RecordBenchmark(model, result.RealTimeFactor, result.PeakMemoryMB)
+		}
+		results = append(results, result)
+	}
+	if devNull != nil {
+		devNull.Close()
+	}
+
+	if jsonOutput {
+		printJSON(results)
+		return
+	}
+	fmt.Println()
+	fmt.Printf("  %-12s %8s %8s %10s  %s\n", "MODEL", "LOAD", "RTF", "MEMORY", "TEXT")
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Printf("  %-12s ❌ %s\n", r.Model, r.Error)
+			continue
+		}
+		fmt.Printf("  %-12s %7.2fs %7.2fx %7.0f MB  %s\n", r.Model, r.LoadSeconds, r.RealTimeFactor, r.PeakMemoryMB, truncateText(r.Text, 50))
+	}
+	fmt.Println()
+	fmt.Println("  RTF is transcription time / audio length, below 1 is faster than real time.")
+	fmt.Println("  Pick the most accurate model that stays well below 1 for your dictations.")
+}
+
+// benchModel loads a model and transcribes the clip with it runs times
+func benchModel(cfg *config.Config, modelManager *models.Manager, model string, samples []float32, clipSeconds float64, runs int) benchResult {
+	result := benchResult{Model: model}
+	if !modelManager.IsModelDownloaded(model) {
+		result.Error = "not downloaded (hyprwhspr download " + model + ")"
+		return result
+	}
+
+	// Sample the memory while the model is loaded, its peak over the baseline is what it takes
+	baseline := models.ProcessMemoryMB()
+	peak := baseline
+	var peakMu sync.Mutex
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				peakMu.Lock()
+				if mb := models.ProcessMemoryMB(); mb > peak {
+					peak = mb
+				}
+				peakMu.Unlock()
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-sampled
+	}()
+
+	start := time.Now()
+	transcriber, err := whisper.New(modelManager.GetModelPath(model), cfg.Threads, cfg.GPUDevice, cfg.Prompt(), cfg.AllowedLanguages)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer transcriber.Close()
+	result.LoadSeconds = time.Since(start).Seconds()
+	transcriber.SetLanguagePrompts(cfg.LanguagePrompts())
+	transcriber.SetDecoding(whisperDecoding(cfg.ForModel(model)))
+
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		res, err := transcriber.Transcribe(samples, whisper.Options{Language: fixedLanguage(cfg)})
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		rtf := time.Since(start).Seconds() / clipSeconds
+		if i == 0 || rtf < result.RealTimeFactor {
+			result.RealTimeFactor = rtf
+		}
+		result.Text = strings.TrimSpace(res.Text)
+	}
+
+	peakMu.Lock()
+	if mb := models.ProcessMemoryMB(); mb > peak {
+		peak = mb
+	}
+	result.PeakMemoryMB = peak - baseline
+	peakMu.Unlock()
+	return result
+}
+
+func runHyprlandConfig(args []string) {
+	flags := flag.NewFlagSet("hyprland-config", flag.ExitOnError)
+	mod := flags.String("mod", "SUPER", "modifier of the binds")
+	key := flags.String("key", "D", "key of the binds")
+	flags.Parse(args)
+
+	cfg, err := config.Load(config.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The binary as it runs now, with the instance it was pointed at
+	bin, err := os.Executable()
+	if err != nil {
+		bin = "hyprwhspr"
+	}
+	command := shellQuote(bin)
+	if path := os.Getenv(config.ConfigPathEnv); path != "" {
+		command += " --config " + shellQuote(path)
+	}
+	if path := os.Getenv(config.EnvName("socket_path")); path != "" {
+		command += " --socket " + shellQuote(path)
+	}
+
+	fmt.Println("# hyprwhspr - generated by 'hyprwhspr hyprland-config'")
+	fmt.Printf("# socket: %s\n", cfg.SocketPath)
+	fmt.Println("")
+	fmt.Println("# Start the daemon with Hyprland")
+	fmt.Printf("exec-once = %s daemon\n", command)
+	fmt.Println("")
+	fmt.Println("# Toggle recording")
+	fmt.Printf("bind = %s, %s, exec, %s toggle\n", *mod, *key, command)
+	fmt.Println("")
+	fmt.Println("# Push to talk: record while held")
+	fmt.Printf("bind = %s SHIFT, %s, exec, %s press\n", *mod, *key, command)
+	fmt.Printf("bindr = %s SHIFT, %s, exec, %s release\n", *mod, *key, command)
+
+	downloaded, _ := models.NewManager(cfg.WhisperModelDir).ListDownloadedModels()
+	if len(downloaded) > 9 {
+		downloaded = downloaded[:9]
+	}
+	if len(downloaded) < 2 {
+		return
+	}
+	fmt.Println("")
+	fmt.Printf("# Switch the model: %s ALT %s, then a number, Escape to leave\n", *mod, *key)
+	fmt.Printf("bind = %s ALT, %s, submap, hyprwhspr-model\n", *mod, *key)
+	fmt.Println("submap = hyprwhspr-model")
+	for i, model := range downloaded {
+		fmt.Printf("bind = , %d, exec, %s model %s\n", i+1, command, model)
+		fmt.Printf("bind = , %d, submap, reset\n", i+1)
+	}
+	fmt.Println("bind = , escape, submap, reset")
+	fmt.Println("submap = reset")
+}
+
+// shellQuote quotes a word for the shell Hyprland runs exec binds with, if needed
+func shellQuote(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+func runDoctor() {
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		if jsonOutput {
+			exitJSON(exitError, "doctor", err)
+		}
+		fmt.Fprintf(os.Stderr, "❌ config: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	// The recorder and whisper log to stdout, keep them out of the report
+	stdout := os.Stdout
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+		defer devNull.Close()
+	}
+	checks := doctor.Run(cfg)
+	os.Stdout = stdout
+
+	if jsonOutput {
+		printJSON(checks)
+	} else {
+		icons := map[string]string{doctor.Pass: "✅", doctor.Warn: "⚠️ ", doctor.Fail: "❌"}
+		for _, c := range checks {
+			fmt.Printf("%s %s: %s\n", icons[c.Status], c.Name, c.Detail)
+			if c.Hint != "" {
+				fmt.Printf("   → %s\n", c.Hint)
+			}
+		}
+	}
+	if doctor.Failed(checks) {
+		os.Exit(exitError)
+	}
+}
+
+func runStats() {
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	response, err := ipc.NewClient(cfg.SocketPath).SendCommand("stats")
+	if err != nil {
+		if jsonOutput {
+			exitJSON(exitUnreachable, "stats", err)
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(exitUnreachable)
+	}
+	if strings.HasPrefix(response, "ERROR") {
+		if jsonOutput {
+			exitJSON(exitError, "stats", errors.New(strings.TrimPrefix(response, "ERROR: ")))
+		}
+		fmt.Println(response)
+		os.Exit(1)
+	}
+	if jsonOutput {
+		fmt.Println(response)
+		return
+	}
+
+	var summary stats.Summary
+	if err := json.Unmarshal([]byte(response), &summary); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid stats response: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📊 Transcription statistics since %s\n", summary.Since.Local().Format("2006-01-02 15:04:05"))
+	if summary.Device != "" {
+		fmt.Printf("🖥️  Device: %s\n", summary.Device)
+	}
+	fmt.Println()
+	if summary.Total.Count == 0 {
+		fmt.Println("No transcriptions yet")
+		return
+	}
+
+	fmt.Printf("%-16s %6s %9s %9s %7s %8s %8s %7s\n", "MODEL", "COUNT", "AUDIO", "WALL", "RTF", "P50", "P95", "WORDS")
+	rows := summary.Models
+	if len(rows) > 1 {
+		rows = append(rows, summary.Total)
+	}
+	for _, m := range rows {
+		fmt.Printf("%-16s %6d %8.1fs %8.1fs %7.2f %6dms %6dms %7d\n",
+			m.Model, m.Count, m.AudioSeconds, m.WallSeconds, m.RealTime, m.P50Ms, m.P95Ms, m.Words)
+	}
+	fmt.Println("\nRTF = transcription time / audio length (lower is faster)")
+}
+
+func runHistory(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := flags.Int("limit", 20, "number of entries to show (0 = all)")
+	search := flags.String("search", "", "only show transcriptions containing this text")
+	flags.Parse(args)
+
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	entries, err := history.Read(cfg.HistoryPath, *limit, *search)
+	if err != nil {
+		if jsonOutput {
+			exitJSON(exitError, "history", err)
+		}
+		fmt.Fprintf(os.Stderr, "❌ Failed to read history: %v\n", err)
+		os.Exit(1)
+	}
+	if jsonOutput {
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		printJSON(entries)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No transcriptions found (%s)\n", cfg.HistoryPath)
+		return
+	}
+
+	for _, e := range entries {
+		info := fmt.Sprintf("%.1fs, %s", float64(e.DurationMs)/1000, e.Model)
+		if e.Language != "" {
+			info += ", " + e.Language
+		}
+		if e.WindowClass != "" {
+			info += ", " + e.WindowClass
+		}
+		if e.Command {
+			info += ", command"
+		}
+		fmt.Printf("%s  (%s)\n", e.Time.Local().Format("2006-01-02 15:04:05"), info)
+		fmt.Printf("    %s\n", e.Text)
+	}
+}
+
+func runAudit(args []string) {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	limit := flags.Int("limit", 20, "number of entries to show (0 = all)")
+	flags.Parse(args)
+
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	entries, err := command.ReadAuditLog(cfg.CommandAuditPath, *limit)
+	if err != nil {
+		if jsonOutput {
+			exitJSON(exitError, "audit", err)
+		}
+		fmt.Fprintf(os.Stderr, "❌ Failed to read audit log: %v\n", err)
+		os.Exit(1)
+	}
+	if jsonOutput {
+		if entries == nil {
+			entries = []command.AuditEntry{}
+		}
+		printJSON(entries)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No commands executed yet (%s)\n", cfg.CommandAuditPath)
+		return
+	}
+
+	for _, e := range entries {
+		status := "✅"
+		if e.ExitCode != 0 {
+			status = fmt.Sprintf("❌ exit %d", e.ExitCode)
+		}
+		fmt.Printf("%s  %-12s %s (%dms)\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Trigger, status, e.DurationMs)
+		fmt.Printf("    script: %s\n", e.Script)
+		if e.Arguments != "" {
+			fmt.Printf("    args:   %q\n", e.Arguments)
+		}
+		if e.WindowClass != "" {
+			fmt.Printf("    window: %s\n", e.WindowClass)
+		}
+		if e.Error != "" {
+			fmt.Printf("    error:  %s\n", strings.SplitN(e.Error, "\n", 2)[0])
+		}
+	}
+}
+
+func runDaemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	logMetrics := flags.String("log-metrics", "", "append per-dictation metrics to this CSV file")
+	replace := flags.Bool("replace", false, "ask a running daemon to shut down and take over")
+	flags.Parse(args)
+
+	fmt.Println("🚀 HYPRWHSPR STARTING UP!")
+	fmt.Println(strings.Repeat("=", 50))
+
+	// Load configuration
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	for _, override := range cfg.EnvOverrides() {
+		fmt.Printf("🌱 Environment override: %s\n", override)
+	}
+
+	// One daemon per socket
+	lock, err := ipc.AcquireLock(cfg.SocketPath)
+	if errors.Is(err, ipc.ErrDaemonRunning) && *replace {
+		lock, err = replaceDaemon(cfg.SocketPath)
+	}
+	if errors.Is(err, ipc.ErrDaemonRunning) {
+		log.Fatalf("❌ %v, stop it first or start with --replace", err)
+	} else if err != nil {
+		log.Fatalf("Failed to lock the socket: %v", err)
+	}
+	defer lock.Release()
+
+	// First run (no config and no model): set up a model instead of failing on the
+	// missing model file. With a config the model was chosen on purpose, don't
+	// download it behind the user's back or replace the config with the wizard.
+	if !models.NewManager(cfg.WhisperModelDir).IsModelDownloaded(cfg.Model) {
+		if _, err := os.Stat(cfgPath); !os.IsNotExist(err) {
+			notify.Send("hyprwhspr failed to start", fmt.Sprintf("Model '%s' is not downloaded. Run 'hyprwhspr download %s'.", cfg.Model, cfg.Model))
+			log.Fatalf("❌ Model '%s' is not downloaded (hyprwhspr download %s)", cfg.Model, cfg.Model)
+		}
+		if err := runOnboarding(cfg, cfgPath); err != nil {
+			log.Fatalf("First-run setup failed: %v", err)
+		}
+	}
+
+	// Create application
+	app := &App{
+		cfg:     cfg,
+		baseCfg: cfg,
+		cfgPath: cfgPath,
+		stats:   stats.NewTracker(),
+		quit:    make(chan struct{}),
+	}
+	if *logMetrics != "" {
+		app.metrics = stats.NewCSVLog(*logMetrics)
+		fmt.Printf("📈 Logging metrics to %s\n", *logMetrics)
+	}
+
+	// Start with the configured named profile
+	if cfg.Profile != "" {
+		if effective, err := cfg.WithProfile(cfg.Profile); err != nil {
+			fmt.Printf("⚠️  %v, starting without a profile\n", err)
+		} else {
+			app.cfg = effective
+			app.profile = cfg.Profile
+			fmt.Printf("🎛️  Profile: %s\n", cfg.Profile)
+		}
+	}
+
+	// Initialize config watcher
+	if err := app.initConfigWatcher(cfgPath); err != nil {
+		log.Printf("Failed to initialize config watcher: %v", err)
+	}
+
+	// Initialize components
+	if err := app.initialize(); err != nil {
+		log.Fatalf("Failed to initialize: %v", err)
+	}
+
+	// Start IPC server
+	if err := app.ipcServer.Start(); err != nil {
+		log.Fatalf("Failed to start IPC server: %v", err)
+	}
+
+	app.startTriggers()
+
+	fmt.Println("✅ hyprwhspr initialized successfully")
+	fmt.Println("🎧 Running in daemon mode - use hyprwhspr to control recording")
+	app.resetIdleTimer()
+	app.startDeviceWatch()
+
+	// Wait for interrupt signal or the "quit" command
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-sigChan:
+	case <-app.quit:
+	}
+
+	fmt.Println("\n🛑 Shutting down hyprwhspr...")
+	app.cleanup()
+}
+
+// testTranscription records a few seconds from the configured microphone and
+// transcribes them with the configured model
+func testTranscription(cfg *config.Config) error {
+	modelPath := models.NewManager(cfg.WhisperModelDir).GetModelPath(cfg.Model)
+	transcriber, err := whisper.New(modelPath, cfg.Threads, cfg.GPUDevice, cfg.Prompt(), cfg.AllowedLanguages)
+	if err != nil {
+		return err
+	}
+	defer transcriber.Close()
+	transcriber.SetLanguagePrompts(cfg.LanguagePrompts())
+	transcriber.SetDecoding(whisperDecoding(cfg.ForModel(cfg.Model)))
+	transcriber.SetNoSpeechThreshold(float32(cfg.NoSpeechThreshold))
+
+	recorder, err := audio.NewRecorder(cfg.SampleRate, cfg.AudioDevice)
+	if err != nil {
+		return err
+	}
+	defer recorder.Close()
+	recorder.SetChannels(cfg.CaptureChannels, cfg.CaptureChannel)
+
+	fmt.Printf("🗣️  Say something - recording for %d seconds...\n", setupTestSeconds)
+	if err := recorder.Start(); err != nil {
+		return err
+	}
+	time.Sleep(setupTestSeconds * time.Second)
+	samples, err := recorder.Stop()
+	if err != nil {
+		return err
+	}
+
+	level := audio.MeasureLevel(samples)
+	if level.PeakdB < -50 {
+		fmt.Printf("⚠️  The microphone is very quiet (peak %.0f dBFS), check the input volume or pick another device\n", level.PeakdB)
+	}
+	start := time.Now()
+	result, err := transcriber.Transcribe(samples, whisper.Options{Language: fixedLanguage(cfg)})
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(result.Text)
+	if text == "" {
+		fmt.Println("🤷 Nothing recognized, try speaking louder or closer to the microphone")
+		return nil
+	}
+	fmt.Printf("📝 \"%s\" (%s, %.1fs)\n", text, result.Language, time.Since(start).Seconds())
+	return nil
+}
+
+// replaceTimeout is how long --replace waits for the running daemon to exit
+const replaceTimeout = 10 * time.Second
+
+// replaceDaemon asks the daemon serving socketPath to shut down and takes its
+// lock once it has exited
+func replaceDaemon(socketPath string) (*ipc.Lock, error) {
+	fmt.Println("🔁 Asking the running daemon to shut down...")
+	response, err := ipc.NewClient(socketPath).SendCommand("quit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the running daemon: %w", err)
+	}
+	if strings.HasPrefix(response, "ERROR") {
+		return nil, fmt.Errorf("the running daemon refused to shut down: %s", response)
+	}
+
+	deadline := time.Now().Add(replaceTimeout)
+	for {
+		lock, err := ipc.AcquireLock(socketPath)
+		if !errors.Is(err, ipc.ErrDaemonRunning) {
+			return lock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the running daemon didn't shut down within %v", replaceTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// isTerminal returns whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// prompt asks a question on the terminal and returns the trimmed answer (or def if empty)
+func prompt(reader *bufio.Reader, question, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return def
+	}
+	return answer
+}
+
+// runOnboarding sets up a first-time user with the setup wizard. Without a
+// terminal (e.g. started by systemd) it downloads the configured model and
+// reports progress via desktop notifications, then writes the config.
+func runOnboarding(cfg *config.Config, cfgPath string) error {
+	modelManager := models.NewManager(cfg.WhisperModelDir)
+
+	if !isTerminal(os.Stdin) {
+		fmt.Printf("👋 First run: model '%s' not found, downloading it...\n", cfg.Model)
+		notify.Send("hyprwhspr setup", fmt.Sprintf("Downloading whisper model '%s'. Dictation will be ready when the download finishes.", cfg.Model))
+		if err := modelManager.DownloadModelWithProgress(cfg.Model); err != nil {
+			notify.Send("hyprwhspr setup failed", fmt.Sprintf("Could not download model '%s': %v\nRun 'hyprwhspr' in a terminal to set up interactively.", cfg.Model, err))
+			return err
+		}
+		if err := cfg.Save(cfgPath); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		notify.Send("hyprwhspr ready", fmt.Sprintf("Model '%s' downloaded. Bind 'hyprwhspr toggle' to a key to start dictating.", cfg.Model))
+		return nil
+	}
+
+	fmt.Println("👋 Welcome to hyprwhspr! Let's get you set up.")
+	fmt.Println("")
+	return runSetupWizard(cfg, cfgPath)
+}
+
+func runSetup() {
+	if !isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "hyprwhspr setup needs an interactive terminal\n")
+		os.Exit(1)
+	}
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("🧙 hyprwhspr setup - press Enter to keep the current choice.")
+	fmt.Println("")
+	if err := runSetupWizard(cfg, cfgPath); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := ipc.NewClient(cfg.SocketPath).SendCommand("status"); err == nil {
+		fmt.Println("🔄 The running daemon picks up the new config automatically.")
+	}
+}
+
+// runSetupWizard walks through picking and downloading a model, selecting a
+// microphone, a test transcription and checking the injection tools, then
+// writes the config and suggests a Hyprland bind
+func runSetupWizard(cfg *config.Config, cfgPath string) error {
+	modelManager := models.NewManager(cfg.WhisperModelDir)
+	reader := bufio.NewReader(os.Stdin)
+
+	// 1. Pick and download a model
+	fmt.Println("🤖 Whisper models:")
+	fmt.Println("  tiny    - Fastest, lowest accuracy (~39MB)")
+	fmt.Println("  base    - Good balance of speed and accuracy (~142MB)")
+	fmt.Println("  small   - Better accuracy, slower (~466MB)")
+	fmt.Println("  medium  - High accuracy, much slower (~1.5GB)")
+	fmt.Println("  Add '.en' (e.g. base.en) for faster English-only models.")
+	for {
+		model := prompt(reader, "Model to use", cfg.Model)
+		if modelManager.IsModelDownloaded(model) {
+			cfg.Model = model
+			break
+		}
+		if err := modelManager.DownloadModelWithProgress(model); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		cfg.Model = model
+		break
+	}
+	fmt.Println("")
+
+	// 2. Select a microphone
+	devices, err := audio.ListCaptureDevices()
+	if err != nil {
+		fmt.Printf("⚠️  Failed to list audio devices: %v\n", err)
+	} else if len(devices) > 0 {
+		fmt.Println("🎤 Capture devices:")
+		for _, dev := range devices {
+			note := ""
+			if dev.IsMonitor {
+				note = " (system audio, not a microphone)"
+			}
+			if dev.IsDefault {
+				note += " [default]"
+			}
+			fmt.Printf("  [%d] %s%s\n", dev.Index, dev.Name, note)
+		}
+		for {
+			answer := prompt(reader, "Microphone number (Enter for system default)", "")
+			if answer == "" {
+				cfg.AudioDevice = nil
+				break
+			}
+			index, err := strconv.Atoi(answer)
+			if err != nil || index < 0 || index >= len(devices) {
+				fmt.Println("❌ Invalid choice")
+				continue
+			}
+			id := devices[index].ID
+			cfg.AudioDevice = &id
+			break
+		}
+		fmt.Println("")
+	}
+
+	// 3. Test a short transcription with the model and microphone
+	if strings.HasPrefix(strings.ToLower(prompt(reader, "Record a test transcription now? (Y/n)", "y")), "y") {
+		if err := testTranscription(cfg); err != nil {
+			fmt.Printf("❌ Test failed: %v\n", err)
+		}
+		fmt.Println("")
+	}
+
+	// 4. Check the tools injecting text
+	fmt.Println("🔍 Injection tools:")
+	missing := false
+	for _, tool := range []struct{ name, purpose string }{
+		{"wl-copy", "wl-clipboard, pastes the text"},
+		{"wl-paste", "wl-clipboard, restores your clipboard"},
+		{"wtype", "presses the paste shortcut and types"},
+	} {
+		if _, err := exec.LookPath(tool.name); err != nil {
+			fmt.Printf("  ❌ %-8s not found (%s)\n", tool.name, tool.purpose)
+			missing = true
+		} else {
+			fmt.Printf("  ✅ %-8s (%s)\n", tool.name, tool.purpose)
+		}
+	}
+	if missing {
+		if _, err := exec.LookPath("ydotool"); err == nil {
+			fmt.Println("  ✅ ydotool  (used to press keys instead of wtype)")
+		}
+		fmt.Println("   Install them with e.g. 'sudo pacman -S wl-clipboard wtype'.")
+	}
+	fmt.Println("")
+
+	// 5. Test text injection
+	if strings.HasPrefix(strings.ToLower(prompt(reader, "Test text injection now? (y/N)", "n")), "y") {
+		fmt.Println("⌨️  Focus a text field - injecting in 3 seconds...")
+		time.Sleep(3 * time.Second)
+		injector := inject.New(cfg.InjectionBackend)
+		if err := injector.Inject("hyprwhspr works!", injectOptions(cfg)); err != nil {
+			fmt.Printf("❌ Injection failed: %v\n", err)
+			fmt.Println("   Make sure wl-clipboard and wtype (or ydotool) are installed.")
+		}
+		fmt.Println("")
+	}
+
+	// 6. Write the config
+	if err := cfg.Save(cfgPath); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	fmt.Printf("✅ Config written to %s\n", cfgPath)
+	fmt.Println("   Add to your Hyprland config:")
+	fmt.Println("   bind = SUPER, D, exec, hyprwhspr toggle")
+	fmt.Println("")
+
+	return nil
+}
+
+func (app *App) initialize() error {
+	// Initialize audio recorder
+	if err := app.initRecorder(); err != nil {
+		return fmt.Errorf("failed to initialize audio recorder: %w", err)
+	}
+
+	// Initialize AEC and VAD if enabled
+	fmt.Printf("🔧 Initializing AEC/VAD - EchoCancellation: %v, VAD: %v\n", app.cfg.EchoCancellation, app.cfg.VoiceActivityDetection)
+	app.initEchoCancellation()
+	app.initVAD()
+
+	// Initialize audio player for notifications
+	if err := app.initPlayer(); err != nil {
+		return fmt.Errorf("failed to initialize audio player: %w", err)
+	}
+
+	// Initialize whisper transcriber
+	if err := app.initTranscriber(); err != nil {
+		return fmt.Errorf("failed to initialize whisper: %w", err)
+	}
+	app.initResidentModels(false)
+
+	// Initialize text injector
+	app.injector = inject.New(app.cfg.InjectionBackend)
+	app.injectQueue = inject.NewQueue()
+	fmt.Println(app.injector.GetStatus())
+
+	// Initialize command executor, transcription history and recording archive
+	app.initCommands()
+	app.initHistory()
+	app.initOverlay()
+	app.initArchive()
+	fmt.Println(app.cmdExecutor.GetStatus())
+
+	// Start in compose mode if configured
+	if app.cfg.ComposeMode {
+		app.composer = compose.New()
+		fmt.Println("📝 Compose mode on - dictations are collected until you say \"send it\"")
+	}
+
+	// Create IPC server
+	app.ipcServer = ipc.NewServer(app.cfg.SocketPath, app.handleCommand)
+
+	return nil
+}
+
+// startTriggers starts the configured triggers. They run the same commands as
+// the IPC socket; a trigger that can't start is skipped with a warning.
+func (app *App) startTriggers() {
+	for _, spec := range app.cfg.Triggers {
+		t, err := trigger.New(trigger.Spec{Type: spec.Type, Device: spec.Device, Key: spec.Key, Mode: spec.Mode})
+		if err != nil {
+			fmt.Printf("⚠️  Trigger %s: %v\n", spec.Type, err)
+			continue
+		}
+		app.triggers = append(app.triggers, t)
+
+		// Portal shortcuts wait for the user to confirm them, don't hold up startup
+		go func() {
+			if err := t.Start(app.handleTrigger); err != nil {
+				fmt.Printf("⚠️  Trigger %s: %v\n", t.Name(), err)
+				return
+			}
+			fmt.Printf("🎛️  Trigger: %s\n", t.Name())
+		}()
+	}
+}
+
+// stopTriggers closes all triggers
+func (app *App) stopTriggers() {
+	for _, t := range app.triggers {
+		t.Close()
+	}
+	app.triggers = nil
+}
+
+// handleTrigger runs a trigger's command, logging refusals the user can't see
+func (app *App) handleTrigger(command string) string {
+	response := app.handleCommand(command)
+	if strings.HasPrefix(response, "ERROR") {
+		fmt.Printf("⚠️  Trigger %s: %s\n", command, response)
+	}
+	return response
+}
+
+// initRecorder (re)creates the microphone recorder
+func (app *App) initRecorder() error {
+	if app.recorder != nil {
+		app.recorder.Close()
+	}
+	var err error
+	app.recorder, err = audio.NewRecorder(app.cfg.SampleRate, app.cfg.AudioDevice)
+	if err != nil {
+		return err
+	}
+	app.recorder.SetDisconnectHandler(app.onMicDisconnected)
+	app.recorder.SetNativeRate(app.cfg.NativeSampleRate)
+	app.recorder.SetChannels(app.cfg.CaptureChannels, app.cfg.CaptureChannel)
+	app.recorder.SetMaxDuration(time.Duration(app.cfg.RecordingBufferMinutes) * time.Minute)
+	app.recorder.SetPreRoll(time.Duration(app.cfg.PreRollMs) * time.Millisecond)
+	if err := app.recorder.Listen(); err != nil {
+		fmt.Printf("⚠️  Failed to open the microphone for pre-roll: %v\n", err)
+	}
+	return nil
+}
+
+// initEchoCancellation (re)creates the loopback recorder and AEC processor if enabled
+func (app *App) initEchoCancellation() {
+	if app.loopbackRec != nil {
+		app.loopbackRec.Close()
+	}
+	app.loopbackRec = nil
+	if app.aecProc != nil {
+		app.aecProc.Close()
+	}
+	app.aecProc = nil
+	if !app.cfg.EchoCancellation {
+		return
+	}
+
+	fmt.Println("🔧 Creating loopback recorder...")
+	loopbackRec, err := audio.NewLoopbackRecorder(app.cfg.SampleRate)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize loopback recorder: %v\n", err)
+		fmt.Println("❌ Echo cancellation disabled")
+		return
+	}
+	fmt.Println("✅ Loopback recorder created")
+	loopbackRec.SetNativeRate(app.cfg.NativeSampleRate)
+	loopbackRec.SetMaxDuration(time.Duration(app.cfg.RecordingBufferMinutes) * time.Minute)
+	app.loopbackRec = loopbackRec
+	aecConfig := audio.AECConfig{
+		Backend:         app.cfg.AECBackend,
+		SampleRate:      app.cfg.SampleRate,
+		FilterLength:    app.cfg.AECFilterLength,
+		StepSize:        app.cfg.AECStepSize,
+		LeakageFactor:   0.999,
+		EchoSuppression: app.cfg.AECEchoSuppression,
+	}
+	aecProc, err := audio.NewAECProcessor(aecConfig)
+	if err != nil {
+		fmt.Printf("⚠️  AEC backend %s unavailable: %v, using nlms\n", app.cfg.AECBackend, err)
+		aecProc = audio.NewNLMSProcessor(aecConfig)
+	}
+	app.aecProc = aecProc
+	fmt.Println("✅ Echo cancellation enabled")
+}
+
+// initVAD (re)creates the voice activity detector if enabled
+func (app *App) initVAD() {
+	if app.sileroVAD != nil {
+		app.sileroVAD.Close()
+		app.sileroVAD = nil
+	}
+	app.vadProc = nil
+	if !app.cfg.VoiceActivityDetection {
+		return
+	}
+
+	if app.cfg.VADEngine == "silero" {
+		if err := app.initSileroVAD(); err != nil {
+			fmt.Printf("⚠️  Silero VAD unavailable, using the energy detector: %v\n", err)
+		} else {
+			fmt.Println("✅ Voice activity detection enabled (Silero)")
+			return
+		}
+	}
+
+	vadConfig := audio.VADConfig{
+		FrameSize:       512,
+		Overlap:         256,
+		EnergyThreshold: app.cfg.VADEnergyThreshold,
+		ZcrThreshold:    0.1,
+		VoiceThreshold:  app.cfg.VADVoiceThreshold,
+	}
+	app.vadProc = audio.NewVADProcessor(vadConfig)
+	fmt.Println("✅ Voice activity detection enabled")
+}
+
+// initSileroVAD loads the Silero model and uses it as the voice detector
+func (app *App) initSileroVAD() error {
+	modelPath := models.NewManager(app.cfg.WhisperModelDir).GetModelPath(app.cfg.VADModel)
+	vad, err := whisper.NewVAD(modelPath, app.cfg.Threads, app.cfg.VADVoiceThreshold)
+	if err != nil {
+		return fmt.Errorf("%w (run: hyprwhspr download %s)", err, app.cfg.VADModel)
+	}
+	app.sileroVAD = vad
+
+	app.vadProc = audio.VoiceDetectorFunc(func(samples []float32) []audio.VoiceSegment {
+		speech, err := vad.Detect(samples)
+		if err != nil {
+			// Better to transcribe some noise than to drop a dictation
+			fmt.Printf("⚠️  VAD: %v, keeping the whole recording\n", err)
+			ms := float64(len(samples)) * 1000 / float64(app.cfg.SampleRate)
+			return []audio.VoiceSegment{{Start: 0, End: ms, Duration: ms}}
+		}
+		segments := make([]audio.VoiceSegment, len(speech))
+		for i, seg := range speech {
+			start := float64(seg.Start) / float64(time.Millisecond)
+			end := float64(seg.End) / float64(time.Millisecond)
+			segments[i] = audio.VoiceSegment{Start: start, End: end, Duration: end - start}
+		}
+		return segments
+	})
+	return nil
+}
+
+// audioChain builds the pre-processing stages configured in audio_pipeline.
+// Stages whose feature is disabled (echo_cancellation, voice_activity_detection)
+// are left out. The VAD stage stores the share of speech in voiceRatio and the
+// speech it found in voiceSegments.
+func (app *App) audioChain(cfg *config.Config, voiceRatio *float64, voiceSegments *[]audio.VoiceSegment) audio.Chain {
+	var chain audio.Chain
+	for _, stage := range cfg.AudioPipeline {
+		switch stage {
+		case "aec":
+			if app.aecProc == nil {
+				fmt.Println("⚠️  AEC: Disabled (aecProc is nil)")
+				continue
+			}
+			chain = append(chain, app.aecProc)
+		case "vad":
+			if app.vadProc != nil && cfg.VoiceActivityDetection {
+				chain = append(chain, audio.VoiceFilter{Detector: app.vadProc, Ratio: voiceRatio, Segments: voiceSegments})
+			}
+		case "highpass":
+			chain = append(chain, audio.NewHighPass(cfg.HighPassCutoffHz, cfg.SampleRate))
+		case "denoise":
+			denoiser, err := audio.NewDenoiseProcessor(cfg.DenoiseBackend, cfg.DenoiseStrength, cfg.SampleRate)
+			if err != nil {
+				fmt.Printf("⚠️  Denoise backend %s unavailable: %v, using spectral\n", cfg.DenoiseBackend, err)
+				denoiser = audio.NewDenoiser(cfg.DenoiseStrength, cfg.SampleRate)
+			}
+			chain = append(chain, denoiser)
+		case "agc":
+			chain = append(chain, audio.NewAGC(0.9, cfg.AGCMaxGain))
+		default:
+			fmt.Printf("⚠️  Unknown audio_pipeline stage %q, skipping\n", stage)
+		}
+	}
+	return chain
+}
+
+// initPlayer (re)creates the audio feedback player
+func (app *App) initPlayer() error {
+	if app.player != nil {
+		app.player.Close()
+	}
+	var err error
+	app.player, err = audio.NewPlayer(audio.PlayerConfig{
+		AudioFeedback:    app.cfg.AudioFeedback,
+		StartSoundVolume: app.cfg.StartSoundVolume,
+		StopSoundVolume:  app.cfg.StopSoundVolume,
+		StartSoundPath:   app.cfg.StartSoundPath,
+		StopSoundPath:    app.cfg.StopSoundPath,
+
+		WarningSoundVolume: app.cfg.WarningSoundVolume,
+		WarningSoundPath:   app.cfg.WarningSoundPath,
+
+		SoundTheme: app.cfg.SoundTheme,
+	})
+	return err
+}
+
+// initTranscriber (re)loads the whisper model. With lazy_load_model a model
+// that isn't loaded yet stays unloaded until the next recording.
+func (app *App) initTranscriber() error {
+	loaded := app.waitForModel() == nil
+	if loaded {
+		app.transcriber.Close()
+		app.transcriber = nil
+	}
+	if app.cfg.LazyLoadModel && !loaded {
+		fmt.Println("💤 Whisper model is loaded when the first recording starts")
+		return nil
+	}
+	model := app.englishModel(app.cfg.Model)
+	transcriber, err := app.loadTranscriber(model)
+	if err != nil {
+		return err
+	}
+	app.transcriber = transcriber
+	app.activeModel = model
+	return nil
+}
+
+// englishModel returns the model to load instead of the configured one: its
+// English-only variant if only English is transcribed and english_model allows it.
+// The .en models are faster and more accurate for English than the multilingual ones.
+func (app *App) englishModel(model string) string {
+	variant := models.EnglishVariant(model)
+	if variant == "" || !app.cfg.EnglishOnly() {
+		return model
+	}
+
+	modelManager := models.NewManager(app.cfg.WhisperModelDir)
+	switch app.cfg.EnglishModel {
+	case "suggest":
+		fmt.Printf("💡 Only English is transcribed, '%s' is faster and more accurate (set english_model to \"use\" or \"download\")\n", variant)
+		return model
+	case "use":
+		if !modelManager.IsModelDownloaded(variant) {
+			fmt.Printf("💡 English-only model '%s' is not downloaded, using '%s' (hyprwhspr download %s)\n", variant, model, variant)
+			return model
+		}
+	case "download":
+		if !modelManager.IsModelDownloaded(variant) {
+			fmt.Printf("📥 Downloading English-only model '%s'...\n", variant)
+			if err := modelManager.DownloadModelWithProgress(variant); err != nil {
+				fmt.Printf("⚠️  Failed to download '%s', using '%s': %v\n", variant, model, err)
+				return model
+			}
+		}
+	default:
+		return model
+	}
+	fmt.Printf("🔤 Using English-only model '%s'\n", variant)
+	return variant
+}
+
+// initCommands (re)creates the command executor
+func (app *App) initCommands() {
+	appCommands := make(map[string]map[string]command.Command, len(app.cfg.AppCommands))
+	for class, commands := range app.cfg.AppCommands {
+		appCommands[class] = executorCommands(commands)
+	}
+	app.cmdExecutor = command.NewExecutor(app.cfg.CommandMode, executorCommands(app.cfg.Commands), appCommands)
+	app.cmdExecutor.SetRedact(app.cfg.RedactTranscripts)
+	app.cmdExecutor.SetFuzzyThreshold(app.cfg.CommandFuzzyThreshold)
+	app.cmdExecutor.SetKeyboard(commandKeyboard{app})
+	app.cmdExecutor.SetRecognizedHandler(app.commandRecognized)
+	if app.cfg.CommandAuditLog {
+		app.cmdExecutor.SetAuditLog(command.NewAuditLog(app.cfg.CommandAuditPath))
+	}
+	app.speaker = tts.NewSpeaker(app.cfg.TTSCommand)
+}
+
+// executorCommands converts configured commands for the command executor
+func executorCommands(commands map[string]config.Command) map[string]command.Command {
+	converted := make(map[string]command.Command, len(commands))
+	for word, cmd := range commands {
+		converted[word] = command.Command{
+			Exec: cmd.Exec, Stdin: cmd.Stdin, Plain: cmd.Plain, Aliases: cmd.Aliases,
+			Open: cmd.Open, Launch: cmd.Launch, Keys: cmd.Keys, Type: cmd.Type, Dispatch: cmd.Dispatch,
+		}
+	}
+	return converted
+}
+
+// commandKeyboard presses keys and inserts text for the keys and type command
+// actions with the injector
+type commandKeyboard struct {
+	app *App
+}
+
+func (k commandKeyboard) PressKeys(chord string) error {
+	return k.app.injector.PressKeys(chord)
+}
+
+func (k commandKeyboard) InsertText(text string) error {
+	cfg := k.app.cfg
+	if window := k.app.activeWindow(); window != nil {
+		cfg = cfg.ForWindowClass(window.Class, window.InitialClass)
+	}
+	return k.app.injector.Inject(text, injectOptions(cfg))
+}
+
+// initOverlay (re)creates the on-screen indicator if it is enabled
+func (app *App) initOverlay() {
+	if app.overlay != nil {
+		app.overlay.Close()
+		app.overlay = nil
+	}
+	if !app.cfg.Overlay {
+		return
+	}
+	o, err := overlay.New(app.cfg.OverlayPosition)
+	if err != nil {
+		fmt.Printf("⚠️  Overlay unavailable: %v\n", err)
+		return
+	}
+	app.overlay = o
+	app.overlay.SetState(app.state())
+	fmt.Printf("🔴 Overlay enabled (%s)\n", app.cfg.OverlayPosition)
+}
+
+// initHistory (re)creates the transcription history store if enabled
+func (app *App) initHistory() {
+	app.history = nil
+	if app.cfg.History {
+		app.history = history.NewStore(app.cfg.HistoryPath)
+	}
+}
+
+// initArchive (re)creates the recording archive if enabled
+func (app *App) initArchive() {
+	app.archive = nil
+	if app.cfg.ArchiveRecordings {
+		app.archive = audio.NewArchive(audio.ArchiveConfig{
+			Dir:       app.cfg.RecordingsDir,
+			MaxCount:  app.cfg.ArchiveMaxCount,
+			MaxSizeMB: app.cfg.ArchiveMaxSizeMB,
+		})
+	}
+}
+
+func (app *App) handleCommand(command string) string {
+	// Parse command with arguments
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return "ERROR: Empty command"
+	}
+
+	cmd := parts[0]
+	args := parts[1:]
+
+	switch cmd {
+	case "start":
+		if app.isRecording {
+			return "ERROR: Already recording"
+		}
+		if len(args) > 0 {
+			if err := app.setMode(args[0]); err != nil {
+				return fmt.Sprintf("ERROR: %v", err)
+			}
+		}
+		if err := app.startRecording(); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return "OK: Recording started"
+
+	case "press":
+		return app.press(args)
+
+	case "release":
+		return app.release()
+
+	case "stop":
+		if !app.isRecording {
+			return "ERROR: Not recording"
+		}
+		if err := app.stopRecording(); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return "OK: Recording stopped"
+
+	case "toggle":
+		if app.isRecording {
+			if err := app.stopRecording(); err != nil {
+				return fmt.Sprintf("ERROR: %v", err)
+			}
+			return "OK: Recording stopped"
+		} else if app.isProcessing && app.cfg.ToggleCancelsProcessing {
+			if err := app.cancelProcessing(); err != nil {
+				return fmt.Sprintf("ERROR: %v", err)
+			}
+			return "OK: Processing cancelled"
+		} else {
+			if len(args) > 0 {
+				if err := app.setMode(args[0]); err != nil {
+					return fmt.Sprintf("ERROR: %v", err)
+				}
+			}
+			if err := app.startRecording(); err != nil {
+				return fmt.Sprintf("ERROR: %v", err)
+			}
+			return "OK: Recording started"
+		}
+
+	case "status":
+		// Scripts check for "1" while recording, the details are opt-in
+		if len(args) > 0 && args[0] == "json" {
+			data, err := json.Marshal(app.status())
+			if err != nil {
+				return fmt.Sprintf("ERROR: %v", err)
+			}
+			return string(data)
+		}
+		if app.isRecording {
+			return "1"
+		} else {
+			return "0"
+		}
+
+	case "state":
+		return app.state()
+
+	case "mode":
+		if len(args) < 1 {
+			if app.nextMode == "" {
+				return "none"
+			}
+			return app.nextMode
+		}
+		if err := app.setMode(args[0]); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Mode of the next dictation set to %s", args[0])
+
+	case "use":
+		if len(args) < 1 {
+			if app.nextModel == "" {
+				return "none"
+			}
+			return app.nextModel
+		}
+		if err := app.useModel(args[0]); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Next dictation transcribed with %s", args[0])
+
+	case "readback":
+		if err := app.readBack(); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return "OK: Reading back the last dictation"
+
+	case "level":
+		level, ok := app.recorder.Level()
+		if !ok {
+			return "ERROR: Not recording"
+		}
+		data, err := json.Marshal(level)
+		if err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return string(data)
+
+	case "quit":
+		// Reply first, the connection closes with the server
+		app.quitOnce.Do(func() {
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				close(app.quit)
+			}()
+		})
+		return "OK: Shutting down"
+
+	case "reload":
+		reloaded, unchanged, err := app.reloadConfig()
+		switch {
+		case err != nil:
+			return fmt.Sprintf("ERROR: %v", err)
+		case unchanged:
+			return "OK: Config unchanged"
+		case len(reloaded) == 0:
+			return "OK: Config reloaded, no component needed restarting"
+		}
+		return "OK: Config reloaded, restarted " + strings.Join(reloaded, ", ")
+
+	case "cancel":
+		if err := app.cancelProcessing(); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return "OK: Processing cancelled"
+
+	case "redo":
+		if err := app.redoProcessing(); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return "OK: Processing last recording again"
+
+	case "stats":
+		summary := app.stats.Summary()
+		if app.transcriber != nil {
+			summary.Device = app.transcriber.Device()
+		}
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return string(data)
+
+	case "compose":
+		return app.handleCompose(args)
+
+	case "profile":
+		if len(args) < 1 {
+			if app.profile == "" {
+				return "none"
+			}
+			return app.profile
+		}
+		if err := app.switchProfile(args[0]); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Profile set to %s", args[0])
+
+	case "profiles":
+		return strings.Join(app.baseCfg.ProfileNames(), " ")
+
+	case "lang":
+		if len(args) < 1 {
+			if language := fixedLanguage(app.cfg); language != "" {
+				return language
+			}
+			return "auto"
+		}
+		if err := app.setLanguage(args[0]); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Language set to %s", args[0])
+
+	case "device":
+		if len(args) < 1 {
+			if app.cfg.AudioDevice == nil {
+				return "default"
+			}
+			return *app.cfg.AudioDevice
+		}
+		id, err := app.setDevice(strings.Join(args, " "))
+		if err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Device set to %s", id)
+
+	case "theme":
+		if len(args) < 1 {
+			if app.cfg.SoundTheme == "" {
+				return "default"
+			}
+			return app.cfg.SoundTheme
+		}
+		theme := strings.Join(args, " ")
+		if err := app.setSoundTheme(theme); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Sound theme set to %s", theme)
+
+	case "marker":
+		m, err := app.addMarker(strings.Join(args, " "))
+		if err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Marker %d at %s", len(app.markers), markers.Timestamp(m.Offset))
+
+	case "model":
+		if len(args) < 1 {
+			return "ERROR: model requires a model name"
+		}
+		modelName := args[0]
+		if err := app.setModel(modelName); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+		return fmt.Sprintf("OK: Model set to %s", modelName)
+
+	default:
+		return fmt.Sprintf("ERROR: Unknown command '%s'", cmd)
+	}
+}
+
+func (app *App) startRecording() error {
+	if app.isRecording {
+		return fmt.Errorf("already recording")
+	}
+
+	app.isRecording = true
+	app.pressed = false
+	app.recordingStart = time.Now()
+	app.markers = nil
+	app.recordingMode, app.nextMode = app.nextMode, ""
+	if app.recordingMode != "" {
+		fmt.Printf("🔢 Constrained dictation: %s\n", app.recordingMode)
+	}
+	app.recordingModel, app.nextModel = app.nextModel, ""
+	if app.recordingModel != "" {
+		fmt.Printf("🧠 Transcribing with %s\n", app.recordingModel)
+	}
+	if app.speaker != nil {
+		app.speaker.Stop() // Don't record the readback
+	}
+	app.ticket = app.injectQueue.Ticket()
+	app.wake()
+	app.resetIdleTimer()
+	if app.audioChanged {
+		app.reinitializeAudio()
+	}
+
 	// Start loopback recording if AEC is enabled
 	if app.loopbackRec != nil {
-		if err := app.loopbackRec.Start(); err != nil {
-			fmt.Printf("⚠️  Failed to start loopback recording: %v\n", err)
-			app.loopbackRec = nil
-			app.aecProc = nil
+		if err := app.loopbackRec.Start(); err != nil {
+			fmt.Printf("⚠️  Failed to start loopback recording: %v\n", err)
+			app.loopbackRec = nil
+			app.aecProc.Close()
+			app.aecProc = nil
+		}
+	}
+
+	// Play start sound
+	if app.player != nil {
+		app.player.PlayStart()
+	}
+
+	// Notify waybar and connected clients of recording state change
+	app.notifyStateChange()
+
+	// Warn when the recording runs long, stop it at max_recording_seconds
+	app.stopWarnings = make(chan struct{})
+	if len(app.cfg.RecordingWarningSeconds) > 0 {
+		go app.watchRecordingDuration(app.cfg.RecordingWarningSeconds, app.stopWarnings)
+	}
+	if app.cfg.MaxRecordingSeconds > 0 {
+		go app.limitRecording(time.Duration(app.cfg.MaxRecordingSeconds)*time.Second, app.stopWarnings)
+	}
+	if app.cfg.DeadMicSeconds > 0 {
+		go app.watchDeadMic(time.Duration(app.cfg.DeadMicSeconds)*time.Second, app.cfg.DeadMicThresholdDB, app.stopWarnings)
+	}
+	if app.cfg.LevelEventsMs > 0 && app.ipcServer != nil {
+		go app.broadcastLevels(time.Duration(app.cfg.LevelEventsMs)*time.Millisecond, app.stopWarnings)
+	}
+
+	if err := app.recorder.Start(); err != nil {
+		// Don't stay stuck in the recording state, the next attempt starts over
+		app.isRecording = false
+		if app.stopWarnings != nil {
+			close(app.stopWarnings)
+			app.stopWarnings = nil
+		}
+		if app.loopbackRec != nil {
+			app.loopbackRec.Stop()
+		}
+		app.injectQueue.Done(app.ticket)
+		app.notifyStateChange()
+		return err
+	}
+
+	// Experimental: inject finalized segments while still recording (not while composing
+	// or in a constrained mode, whose value is only complete at the end, nor with another
+	// model than the main one). Echo cancellation needs the loopback recording, which is
+	// only complete at the end, and a command has to be recognized before any of the
+	// dictation is typed, so both turn streaming off.
+	if app.cfg.StreamingInjection && app.composer == nil && app.recordingMode == "" && app.recordingModel == "" &&
+		app.aecProc == nil && !app.cmdExecutor.IsEnabled() {
+		app.stream = &streamState{stop: make(chan struct{}), done: make(chan struct{}), ticket: app.ticket}
+		go app.streamTranscription(app.stream)
+	}
+
+	return nil
+}
+
+// streamTranscription periodically transcribes the audio recorded since the last
+// injection and injects every segment except the last one, which may still change
+// as more audio arrives. Injected text is never corrected afterwards.
+func (app *App) streamTranscription(stream *streamState) {
+	defer close(stream.done)
+
+	interval := time.Duration(app.cfg.StreamingIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sampleRate := app.cfg.SampleRate
+	fmt.Printf("🌊 Streaming injection enabled (every %v)\n", interval)
+
+	for {
+		select {
+		case <-stream.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Wait for enough new audio to produce at least two segments. committed
+		// counts from the start of the recording, the snapshot starts later once
+		// the recording buffer is full.
+		samples, dropped := app.recorder.Snapshot()
+		if stream.committed < dropped {
+			stream.committed = dropped
+		}
+		if len(samples)-(stream.committed-dropped) < 2*sampleRate {
+			continue
+		}
+		pending := samples[stream.committed-dropped:]
+
+		window := app.activeWindow()
+		cfg := app.cfg
+		if window != nil {
+			cfg = app.cfg.ForWindowClass(window.Class, window.InitialClass)
+		}
+
+		// The same pre-processing as the final transcription. The stages keep the
+		// length of the audio (VAD mutes instead of cutting), so segment times
+		// still point into pending.
+		voiceRatio := -1.0
+		processed, err := app.audioChain(cfg, &voiceRatio, nil).Process(pending, nil)
+		if err != nil {
+			if !errors.Is(err, audio.ErrNoVoice) {
+				fmt.Printf("⚠️  Streaming audio processing failed: %v\n", err)
+			}
+			continue
+		}
+
+		if err := app.waitForModel(); err != nil {
+			continue
+		}
+		result, err := app.transcriber.Transcribe(processed, whisper.Options{Prompt: cfg.Prompt(), LanguagePrompts: cfg.LanguagePrompts(), Language: fixedLanguage(cfg)})
+		if err != nil {
+			fmt.Printf("⚠️  Streaming transcription failed: %v\n", err)
+			continue
+		}
+		if len(result.Segments) < 2 {
+			continue
+		}
+
+		final := result.Segments[:len(result.Segments)-1]
+		end := int(final[len(final)-1].End.Seconds() * float64(sampleRate))
+		if end <= 0 || end > len(pending) {
+			continue
+		}
+
+		partial := &whisper.Result{Language: result.Language, Segments: final}
+		for _, seg := range final {
+			partial.Text += seg.Text
+		}
+		app.injectQueue.Wait(stream.ticket)
+		if app.injectPartial(app.newResult(partial, "", window, end), cfg, stream.injected) {
+			stream.injected = true
+		}
+		stream.committed += end
+	}
+}
+
+// injectPartial post-processes and injects a streamed piece of text, separating it
+// from previously injected text with a space. Returns whether text was injected.
+func (app *App) injectPartial(res *transcript.Result, cfg *config.Config, continued bool) bool {
+	if res.Language == "" && cfg.Language != nil {
+		res.Language = *cfg.Language
+	}
+	res.Text = buildPostProcessors(cfg, res.Language).Process(res.Text)
+	if res.Text == "" {
+		return false
+	}
+	fmt.Printf("🌊 Streaming: %s\n", app.logText(res.Text))
+	app.saveHistory(res, false)
+
+	text := res.Text
+	if continued {
+		text = " " + text
+	}
+	if err := app.injectResult(text, "", res, cfg); err != nil {
+		return false
+	}
+	app.broadcastResult(res)
+	return true
+}
+
+// watchRecordingDuration plays escalating warnings as the recording passes each threshold
+func (app *App) watchRecordingDuration(thresholds []int, stop <-chan struct{}) {
+	sorted := append([]int(nil), thresholds...)
+	sort.Ints(sorted)
+
+	start := time.Now()
+	level := 0
+	for _, seconds := range sorted {
+		if seconds <= 0 {
+			continue
+		}
+		wait := time.Until(start.Add(time.Duration(seconds) * time.Second))
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		level++
+		elapsed := time.Duration(seconds) * time.Second
+		fmt.Printf("⏰ Still recording after %s\n", elapsed)
+		if app.player != nil {
+			app.player.PlayWarning(level)
+		}
+		notify.Send("Still recording", fmt.Sprintf("The microphone has been recording for %s", elapsed))
+	}
+}
+
+// watchDeadMic warns once per recording when the microphone delivers nothing
+// above thresholdDB for window, e.g. because it is muted or the wrong device is
+// selected, instead of leaving an empty transcription unexplained
+func (app *App) watchDeadMic(window time.Duration, thresholdDB float64, stop <-chan struct{}) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		level, measured, ok := app.recorder.RecentLevel(window)
+		if !ok || measured < window || level.PeakdB >= thresholdDB {
+			continue
+		}
+
+		device := "the default microphone"
+		if app.cfg.AudioDevice != nil && *app.cfg.AudioDevice != "" && !app.recorder.Fallback() {
+			device = *app.cfg.AudioDevice
+		}
+		fmt.Printf("🔇 No audio from %s for %v (peak %.0f dBFS), is it muted?\n", device, window, level.PeakdB)
+		notify.Send("Microphone is silent", fmt.Sprintf("No audio from %s for %v. Is it muted or the wrong device?", device, window))
+		if app.ipcServer != nil {
+			app.ipcServer.Broadcast("device", "silent")
+		}
+		return
+	}
+}
+
+// broadcastLevels sends the input level as a "level" event while recording
+func (app *App) broadcastLevels(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		// Skip the work while no widget is listening
+		if app.ipcServer.ClientCount() == 0 {
+			continue
+		}
+		level, ok := app.recorder.Level()
+		if !ok {
+			continue
+		}
+		if data, err := json.Marshal(level); err == nil {
+			app.ipcServer.Broadcast("level", string(data))
+		}
+	}
+}
+
+// limitRecording stops the recording once it reaches max_recording_seconds
+func (app *App) limitRecording(limit time.Duration, stop <-chan struct{}) {
+	select {
+	case <-stop:
+		return
+	case <-time.After(limit):
+	}
+	select {
+	case <-stop:
+		return // Stopped at the same moment
+	default:
+	}
+	if !app.isRecording {
+		return
+	}
+
+	fmt.Printf("⏹️  Recording reached the %v limit, stopping\n", limit)
+	if app.player != nil {
+		app.player.PlayLimit()
+	}
+	notify.Send("Recording limit reached", fmt.Sprintf("The recording was stopped after %v and is being transcribed.", limit))
+	if err := app.stopRecording(); err != nil {
+		fmt.Printf("⚠️  Failed to stop recording: %v\n", err)
+	}
+}
+
+func (app *App) stopRecording() error {
+	app.isRecording = false
+	app.resetIdleTimer()
+	if app.stopWarnings != nil {
+		close(app.stopWarnings)
+		app.stopWarnings = nil
+	}
+
+	// Play stop sound
+	if app.player != nil {
+		app.player.PlayStop()
+	}
+
+	// Get recorded audio
+	samples, err := app.recorder.Stop()
+	if err != nil {
+		app.injectQueue.Done(app.ticket)
+		return err
+	}
+
+	// Get loopback audio if available
+	var loopbackSamples []float32
+	if app.loopbackRec != nil {
+		loopbackSamples, err = app.loopbackRec.Stop()
+		if err != nil {
+			fmt.Printf("⚠️  Failed to stop loopback recording: %v\n", err)
+		}
+	}
+
+	// System audio is only captured from "start" on, align it with the pre-roll
+	if preRolled := app.recorder.PreRolled(); preRolled > 0 && len(loopbackSamples) > 0 {
+		loopbackSamples = append(make([]float32, preRolled), loopbackSamples...)
+	}
+
+	// Keep a copy of the raw recording
+	if app.archive != nil && len(samples) > 0 {
+		go func(archive *audio.Archive, sampleRate int) {
+			path, err := archive.Save(samples, sampleRate)
+			if err != nil {
+				fmt.Printf("⚠️  Failed to archive recording: %v\n", err)
+				return
+			}
+			fmt.Printf("💾 Recording saved: %s\n", path)
+		}(app.archive, app.cfg.SampleRate)
+	}
+
+	// Remember which window the user was dictating into
+	window := app.activeWindow()
+
+	marks := app.markers
+	mode, model := app.recordingMode, app.recordingModel
+	app.lastRecording = &lastRecording{samples: samples, loopback: loopbackSamples, window: window, markers: marks, mode: mode, model: model}
+
+	// Process audio in background
+	gen := app.beginProcessing(len(samples))
+	ticket := app.ticket
+
+	stream := app.stream
+	app.stream = nil
+	if stream == nil {
+		go app.processAudio(samples, loopbackSamples, window, marks, mode, model, false, gen, ticket)
+		return nil
+	}
+
+	// Streaming: wait for the last partial injection, then process what's left
+	close(stream.stop)
+	dropped := app.recorder.Dropped()
+	go func() {
+		<-stream.done
+		// Position of the first sample not injected yet in samples
+		committed := stream.committed - dropped
+		if committed < 0 {
+			committed = 0
+		}
+		if committed >= len(samples) {
+			app.injectQueue.Done(ticket)
+			app.endProcessing(gen)
+			return
+		}
+		if committed < len(loopbackSamples) {
+			loopbackSamples = loopbackSamples[committed:]
+		} else {
+			loopbackSamples = nil
+		}
+		offset := time.Duration(committed) * time.Second / time.Duration(app.cfg.SampleRate)
+		app.processAudio(samples[committed:], loopbackSamples, window, markers.Shift(marks, offset), mode, model, stream.injected, gen, ticket)
+	}()
+
+	return nil
+}
+
+// discardRecording stops the recording without processing it. The archive and
+// the last recording for "redo" are left alone.
+func (app *App) discardRecording() error {
+	app.isRecording = false
+	app.resetIdleTimer()
+	if app.stopWarnings != nil {
+		close(app.stopWarnings)
+		app.stopWarnings = nil
+	}
+
+	_, err := app.recorder.Stop()
+	if app.loopbackRec != nil {
+		if _, err := app.loopbackRec.Stop(); err != nil {
+			fmt.Printf("⚠️  Failed to stop loopback recording: %v\n", err)
+		}
+	}
+
+	// Release the injection queue once a streaming injection is finished
+	ticket := app.ticket
+	if stream := app.stream; stream != nil {
+		app.stream = nil
+		close(stream.stop)
+		go func() {
+			<-stream.done
+			app.injectQueue.Done(ticket)
+		}()
+	} else {
+		app.injectQueue.Done(ticket)
+	}
+	app.notifyStateChange()
+	return err
+}
+
+// press starts a hold-to-record recording. Key repeat and a press right after
+// the release (a bouncing key) continue the recording instead.
+func (app *App) press(args []string) string {
+	if app.releaseTimer != nil && app.releaseTimer.Stop() {
+		app.releaseTimer = nil
+		app.pressed = true
+		return "OK: Recording continued"
+	}
+	if app.isRecording {
+		if app.pressed {
+			return "OK: Already recording"
+		}
+		return "ERROR: Already recording"
+	}
+	if len(args) > 0 {
+		if err := app.setMode(args[0]); err != nil {
+			return fmt.Sprintf("ERROR: %v", err)
+		}
+	}
+	if err := app.startRecording(); err != nil {
+		return fmt.Sprintf("ERROR: %v", err)
+	}
+	app.pressed = true
+	return "OK: Recording started"
+}
+
+// release stops a recording started by press after press_debounce_ms. A hold
+// shorter than hold_min_ms was a tap, its recording is discarded.
+func (app *App) release() string {
+	if !app.pressed || !app.isRecording {
+		return "OK: Not recording"
+	}
+	app.pressed = false
+	tap := time.Since(app.recordingStart) < time.Duration(app.cfg.HoldMinMs)*time.Millisecond
+	app.releaseTimer = time.AfterFunc(time.Duration(app.cfg.PressDebounceMs)*time.Millisecond, func() {
+		app.releaseTimer = nil
+		if !app.isRecording {
+			return
+		}
+		if tap {
+			if err := app.discardRecording(); err != nil {
+				fmt.Printf("❌ Failed to stop recording: %v\n", err)
+			}
+			fmt.Printf("👆 Key held shorter than %dms, recording discarded\n", app.cfg.HoldMinMs)
+			return
+		}
+		if err := app.stopRecording(); err != nil {
+			fmt.Printf("❌ Failed to stop recording: %v\n", err)
+		}
+	})
+	if tap {
+		return "OK: Recording discarded"
+	}
+	return "OK: Recording stopped"
+}
+
+// beginProcessing marks the start of a processing run for a recording of the given
+// length, starts its watchdog and returns the run's generation
+func (app *App) beginProcessing(samples int) uint64 {
+	app.generation++
+	gen := app.generation
+	app.isProcessing = true
+	app.processingStuck = false
+	app.notifyStateChange()
+
+	recordingDuration := time.Duration(samples) * time.Second / time.Duration(app.cfg.SampleRate)
+	go app.watchProcessing(gen, recordingDuration)
+	return gen
+}
+
+// endProcessing marks a processing run as finished, unless it was cancelled or superseded
+func (app *App) endProcessing(gen uint64) {
+	if app.generation != gen {
+		return
+	}
+	app.isProcessing = false
+	app.processingStuck = false
+	app.notifyStateChange()
+	app.resetIdleTimer()
+}
+
+// cancelled returns whether a processing run was cancelled or superseded by a redo
+func (app *App) cancelled(gen uint64) bool {
+	return app.generation != gen
+}
+
+// watchProcessing warns when a processing run takes much longer than the recording itself
+func (app *App) watchProcessing(gen uint64, recordingDuration time.Duration) {
+	timeout := time.Duration(app.cfg.WatchdogFactor * float64(recordingDuration))
+	if minTimeout := time.Duration(app.cfg.WatchdogMinSeconds) * time.Second; timeout < minTimeout {
+		timeout = minTimeout
+	}
+	if timeout <= 0 {
+		return
+	}
+
+	time.Sleep(timeout)
+	if !app.isProcessing || app.cancelled(gen) {
+		return
+	}
+
+	app.processingStuck = true
+	fmt.Printf("⏳ Processing is taking longer than %v - use 'hyprwhspr cancel' or 'hyprwhspr redo'\n", timeout.Round(time.Second))
+	app.notifyStateChange()
+	notify.Send("Transcription seems stuck",
+		fmt.Sprintf("Still processing after %v. Run 'hyprwhspr cancel' to discard it or 'hyprwhspr redo' to try again.", timeout.Round(time.Second)))
+}
+
+// cancelProcessing discards the result of the running processing run
+func (app *App) cancelProcessing() error {
+	if !app.isProcessing {
+		return fmt.Errorf("nothing is being processed")
+	}
+	app.generation++
+	app.isProcessing = false
+	app.processingStuck = false
+	app.notifyStateChange()
+	fmt.Println("🚫 Processing cancelled")
+	return nil
+}
+
+// redoProcessing processes the last recording again, discarding a running run.
+// A transcription that is still running has to finish before the new one starts.
+func (app *App) redoProcessing() error {
+	if app.isRecording {
+		return fmt.Errorf("recording in progress")
+	}
+	last := app.lastRecording
+	if last == nil {
+		return fmt.Errorf("no recording to redo")
+	}
+	fmt.Println("🔁 Processing last recording again")
+	app.wake()
+	gen := app.beginProcessing(len(last.samples))
+	go app.processAudio(last.samples, last.loopback, last.window, last.markers, last.mode, last.model, false, gen, app.injectQueue.Ticket())
+	return nil
+}
+
+// composeDictation applies a dictation to the compose buffer and returns the
+// composed text once it is sent
+func (app *App) composeDictation(composer *compose.Buffer, text string) (string, bool) {
+	action, composed := composer.Handle(text)
+	switch action {
+	case compose.Sent:
+		if composed == "" {
+			fmt.Println("📝 Compose buffer is empty, nothing to send")
+			return "", false
+		}
+		fmt.Printf("📨 Sending composed text: %s\n", app.logText(composed))
+		app.broadcastCompose("")
+		return composed, true
+	case compose.Appended:
+		fmt.Println("📝 Added to compose buffer")
+	case compose.Removed:
+		fmt.Println("✂️  Removed the last dictation from the compose buffer")
+	case compose.Replaced:
+		fmt.Println("✏️  Replaced text in the compose buffer")
+	case compose.Cleared:
+		fmt.Println("🧹 Compose buffer cleared")
+	case compose.NoMatch:
+		fmt.Println("⚠️  Nothing to edit in the compose buffer")
+		notify.Send("Compose", "Nothing to edit: "+app.logText(text))
+	}
+	buffer := composer.Text()
+	fmt.Printf("📝 Compose buffer: %s\n", app.logText(buffer))
+	app.broadcastCompose(buffer)
+	return "", false
+}
+
+// broadcastCompose tells connected clients (overlays, widgets) the current compose buffer
+func (app *App) broadcastCompose(buffer string) {
+	if app.ipcServer != nil {
+		app.ipcServer.Broadcast("compose", buffer)
+	}
+}
+
+// handleCompose handles the "compose" IPC command: on, off, toggle, send, clear or show
+func (app *App) handleCompose(args []string) string {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if action == "toggle" {
+		action = "on"
+		if app.composer != nil {
+			action = "off"
+		}
+	}
+
+	composer := app.composer
+	switch action {
+	case "on":
+		if composer == nil {
+			app.composer = compose.New()
+			fmt.Println("📝 Compose mode on")
+		}
+		return "OK: Compose mode on"
+	case "off":
+		app.composer = nil
+		if composer != nil && composer.Text() != "" {
+			fmt.Printf("🧹 Compose mode off, discarded: %s\n", app.logText(composer.Text()))
+		}
+		app.broadcastCompose("")
+		return "OK: Compose mode off"
+	}
+
+	if composer == nil {
+		return "ERROR: Compose mode is off"
+	}
+	switch action {
+	case "show":
+		return composer.Text()
+	case "clear":
+		composer.Clear()
+		app.broadcastCompose("")
+		return "OK: Compose buffer cleared"
+	case "send":
+		text := composer.Send()
+		if text == "" {
+			return "ERROR: Compose buffer is empty"
+		}
+		app.broadcastCompose("")
+		go func() {
+			window := app.activeWindow()
+			cfg := app.cfg
+			if window != nil {
+				cfg = app.cfg.ForWindowClass(window.Class, window.InitialClass)
+			}
+			res := &transcript.Result{Text: text, Raw: text, Backend: app.activeModel, Profile: app.profile, Window: window}
+			err := app.injectDictation(res, cfg)
+			app.broadcastResult(res)
+			app.setResult(app.generation, err)
+		}()
+		return "OK: Composed text sent"
+	default:
+		return fmt.Sprintf("ERROR: Unknown compose command '%s'", action)
+	}
+}
+
+// resetIdleTimer restarts the countdowns to low-power mode and to unloading the model
+func (app *App) resetIdleTimer() {
+	app.resetModelTimer()
+	timeout := time.Duration(app.cfg.IdleTimeoutMinutes) * time.Minute
+	if timeout <= 0 {
+		if app.idleTimer != nil {
+			app.idleTimer.Stop()
+		}
+		return
+	}
+	if app.idleTimer == nil {
+		app.idleTimer = time.AfterFunc(timeout, app.enterLowPower)
+		return
+	}
+	app.idleTimer.Reset(timeout)
+}
+
+// enterLowPower releases the audio devices, and the model if idle_unload_model is set,
+// after the daemon has been idle for idle_timeout_minutes. Everything is restored
+// on the next recording.
+func (app *App) enterLowPower() {
+	if app.isRecording || app.isProcessing {
+		app.resetIdleTimer()
+		return
+	}
+	if app.lowPower {
+		return
+	}
+	app.lowPower = true
+	fmt.Printf("💤 Idle for %d minutes, entering low-power mode\n", app.cfg.IdleTimeoutMinutes)
+
+	if app.recorder != nil {
+		app.recorder.Suspend()
+	}
+	if app.loopbackRec != nil {
+		app.loopbackRec.Suspend()
+	}
+	if app.player != nil {
+		app.player.Suspend()
+	}
+	// Listing the devices creates an audio context every few seconds
+	app.stopDeviceWatcher()
+	if app.cfg.IdleUnloadModel && app.transcriber != nil {
+		app.transcriber.Close()
+		app.transcriber = nil
+		fmt.Println("💤 Whisper model unloaded")
+	}
+}
+
+// wake leaves low-power mode. Audio devices are initialized again on their next use,
+// an unloaded model is loaded in the background while the user speaks.
+func (app *App) wake() {
+	if app.lowPower {
+		app.lowPower = false
+		fmt.Println("⚡ Leaving low-power mode")
+		app.startDeviceWatch()
+	}
+	app.loadModel()
+}
+
+// resetModelTimer restarts the countdown to unloading the model
+func (app *App) resetModelTimer() {
+	timeout := time.Duration(app.cfg.ModelUnloadMinutes) * time.Minute
+	if timeout <= 0 {
+		if app.modelTimer != nil {
+			app.modelTimer.Stop()
+		}
+		return
+	}
+	if app.modelTimer == nil {
+		app.modelTimer = time.AfterFunc(timeout, app.unloadModel)
+		return
+	}
+	app.modelTimer.Reset(timeout)
+}
+
+// unloadModel frees the whisper model after model_unload_minutes without
+// activity, it is loaded again when the next recording starts
+func (app *App) unloadModel() {
+	if app.isRecording || app.isProcessing || app.modelLoading() {
+		app.resetModelTimer()
+		return
+	}
+	if app.transcriber == nil {
+		return
+	}
+	app.transcriber.Close()
+	app.transcriber = nil
+	fmt.Printf("💤 Idle for %d minutes, whisper model unloaded\n", app.cfg.ModelUnloadMinutes)
+}
+
+// modelLoading returns whether a model is being loaded in the background
+func (app *App) modelLoading() bool {
+	if app.modelReady == nil {
+		return false
+	}
+	select {
+	case <-app.modelReady:
+		return false
+	default:
+		return true
+	}
+}
+
+// loadModel loads the model in the background if it was unloaded or not
+// loaded yet, waitForModel waits for it
+func (app *App) loadModel() {
+	if app.transcriber != nil || app.modelLoading() {
+		return
+	}
+	ready := make(chan struct{})
+	app.modelReady = ready
+	go func() {
+		defer close(ready)
+		model := app.englishModel(app.cfg.Model)
+		transcriber, err := app.loadTranscriber(model)
+		if err != nil {
+			fmt.Printf("❌ Failed to load whisper model: %v\n", err)
+			return
+		}
+		app.transcriber = transcriber
+		app.activeModel = model
+	}()
+}
+
+// waitForModel waits until a model loaded in the background is ready
+func (app *App) waitForModel() error {
+	if ready := app.modelReady; ready != nil {
+		<-ready
+	}
+	if app.transcriber == nil {
+		return fmt.Errorf("no whisper model loaded")
+	}
+	return nil
+}
+
+// status returns the details of the daemon's state
+func (app *App) status() ipc.Status {
+	status := ipc.Status{
+		State:     app.state(),
+		Recording: app.isRecording,
+		Queue:     app.injectQueue.Pending(),
+		Model:     app.activeModel,
+		Language:  fixedLanguage(app.cfg),
+		Mode:      app.nextMode,
+		Profile:   app.profile,
+		Injection: app.injector.Backend(),
+	}
+	if app.isRecording {
+		status.RecordingSeconds = time.Since(app.recordingStart).Round(100 * time.Millisecond).Seconds()
+		status.Mode = app.recordingMode
+	}
+	if status.Model == "" {
+		status.Model = app.cfg.Model
+	}
+	if app.transcriber != nil && !app.modelLoading() {
+		status.ModelLoaded = true
+		status.Device = app.transcriber.Device()
+	}
+	if status.Language == "" {
+		status.Language = "auto"
+	}
+	return status
+}
+
+// state returns the current daemon state: "recording", "processing", "stuck",
+// "success" or "error" (briefly after processing) or "idle"
+func (app *App) state() string {
+	switch {
+	case app.isRecording:
+		return "recording"
+	case app.isProcessing && app.processingStuck:
+		return "stuck"
+	case app.isProcessing:
+		return "processing"
+	case app.result != "":
+		return app.result
+	default:
+		return "idle"
+	}
+}
+
+// setResult shows the outcome of a processing run as the "success" or "error" state
+// for result_state_seconds, unless the run was cancelled. Errors are broadcast as an
+// "error" event with the message first.
+func (app *App) setResult(gen uint64, err error) {
+	if app.cancelled(gen) {
+		return
+	}
+
+	if app.player != nil {
+		if err != nil {
+			app.player.PlayError()
+		} else {
+			app.player.PlayDone()
+		}
+	}
+	if app.cfg.ResultStateSeconds <= 0 {
+		return
+	}
+
+	result := "success"
+	if err != nil {
+		result = "error"
+		if app.ipcServer != nil {
+			app.ipcServer.Broadcast("error", err.Error())
+		}
+	}
+
+	if app.resultTimer != nil {
+		app.resultTimer.Stop()
+	}
+	app.result = result
+	app.resultTimer = time.AfterFunc(time.Duration(app.cfg.ResultStateSeconds)*time.Second, func() {
+		app.result = ""
+		app.notifyStateChange()
+	})
+
+	// While processing, endProcessing announces the result
+	if !app.isProcessing {
+		app.notifyStateChange()
+	}
+}
+
+// notifyStateChange signals waybar, updates the overlay and broadcasts the new state
+// to all connected IPC clients
+func (app *App) notifyStateChange() {
+	exec.Command("pkill", "-RTMIN+9", "waybar").Run()
+	if app.overlay != nil {
+		app.overlay.SetState(app.state())
+	}
+	if app.ipcServer != nil {
+		app.ipcServer.Broadcast("state", app.state())
+	}
+}
+
+// activeWindow queries Hyprland for the focused window, returning nil outside Hyprland
+func (app *App) activeWindow() *hyprland.Window {
+	if !hyprland.IsAvailable() {
+		return nil
+	}
+	window, err := hyprland.ActiveWindow()
+	if err != nil {
+		fmt.Printf("⚠️  Failed to query active window: %v\n", err)
+		return nil
+	}
+	if window != nil {
+		fmt.Printf("🪟 Active window: %s\n", window)
+	}
+	return window
+}
+
+// processAudio transcribes and injects a recording. marks are the markers set with the
+// hotkey, mode the constrained dictation mode ("" = free dictation), model the resident
+// model chosen with "hyprwhspr use" ("" = mode_models or model), continued is set
+// when streaming injection already inserted the beginning of the recording, gen is the
+// processing run and ticket its place in the injection queue.
+func (app *App) processAudio(samples []float32, loopbackSamples []float32, window *hyprland.Window, marks []markers.Marker, mode, model string, continued bool, gen, ticket uint64) {
+	var failure error          // shown as the "error" state, nil shows "success"
+	var res *transcript.Result // set once whisper transcribed something
+	defer app.injectQueue.Done(ticket)
+	defer app.endProcessing(gen)
+	defer func() { app.setResult(gen, failure) }()
+	defer func() {
+		if res != nil {
+			app.broadcastResult(res)
 		}
+	}()
+
+	// Resolve per-application overrides for the window we are dictating into
+	cfg := app.cfg
+	if window != nil {
+		cfg = app.cfg.ForWindowClass(window.Class, window.InitialClass)
 	}
 
-	// Play start sound
-	if app.player != nil {
-		app.player.PlayStart()
+	// Debug: Print sample counts
+	fmt.Printf("🔍 DEBUG: Mic samples: %d, Loopback samples: %d\n", len(samples), len(loopbackSamples))
+
+	// A silent microphone gives nothing to transcribe, say why instead
+	if !continued && cfg.DeadMicSeconds > 0 && len(samples) >= cfg.DeadMicSeconds*app.cfg.SampleRate {
+		if level := audio.MeasureLevel(samples); level.PeakdB < cfg.DeadMicThresholdDB {
+			fmt.Printf("🔇 Recording is silent (peak %.0f dBFS), skipping transcription\n", level.PeakdB)
+			failure = fmt.Errorf("microphone is silent (muted or wrong device?)")
+			return
+		}
 	}
 
-	// Notify waybar of recording state change
-	exec.Command("pkill", "-RTMIN+9", "waybar").Run()
+	// Pre-process (echo cancellation, filters, VAD)
+	voiceRatio := -1.0
+	var voiceSegments []audio.VoiceSegment
+	preprocessStart := time.Now()
+	samplesToTranscribe, err := app.audioChain(cfg, &voiceRatio, &voiceSegments).Process(samples, loopbackSamples)
+	preprocess := time.Since(preprocessStart)
+	if err != nil {
+		if errors.Is(err, audio.ErrNoVoice) {
+			app.logMetrics(len(samples), voiceRatio, nil)
+		} else {
+			fmt.Printf("❌ Audio processing failed: %v\n", err)
+		}
+		failure = err
+		return
+	}
+
+	// Transcribe
+	transcriber, model, err := app.transcriberFor(model, mode)
+	if err != nil {
+		fmt.Printf("❌ Transcription failed: %v\n", err)
+		failure = err
+		return
+	}
+	prompt, languagePrompts := cfg.Prompt(), cfg.LanguagePrompts()
+	if constraintPrompt := postprocess.ConstraintPrompt(mode); constraintPrompt != "" {
+		prompt, languagePrompts = constraintPrompt, nil
+	}
+	grammar, err := app.modeGrammar(mode, cfg, window)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		failure = err
+		return
+	}
+	opts := whisper.Options{
+		Prompt:          prompt,
+		LanguagePrompts: languagePrompts,
+		Language:        fixedLanguage(cfg),
+		Grammar:         grammar,
+		GrammarPenalty:  float32(cfg.GrammarPenalty),
+	}
+	// Dictations mixing languages are transcribed segment by segment
+	var spans []whisper.Span
+	if cfg.SegmentLanguageDetection && opts.Language == "" && len(cfg.AllowedLanguages) > 1 && mode == "" {
+		spans = speechSpans(voiceSegments, cfg.SampleRate, len(samplesToTranscribe))
+	}
+	transcribeStart := time.Now()
+	var result *whisper.Result
+	priority.Run(processingPriority(cfg), func() {
+		if len(spans) > 1 {
+			fmt.Printf("🌐 Detecting the language of %d segments\n", len(spans))
+			result, err = transcriber.TranscribeSpans(samplesToTranscribe, spans, opts)
+		} else {
+			result, err = transcriber.Transcribe(samplesToTranscribe, opts)
+		}
+	})
+	if err != nil {
+		fmt.Printf("❌ Transcription failed: %v\n", err)
+		failure = err
+		return
+	}
+	res = app.newResult(result, mode, window, len(samples))
+	res.Backend = model
+	res.Timings.Preprocess = preprocess
+	res.Timings.Transcribe = time.Since(transcribeStart)
+	app.recordStats(model, len(samplesToTranscribe), res.Timings.Transcribe, res.Text)
+	app.logMetrics(len(samples), voiceRatio, res)
+
+	// Spoken markers are removed from the text before anything else sees it
+	marks = append(append([]markers.Marker(nil), marks...), markers.FindSpoken(result, cfg.MarkerPhrase)...)
+	if len(marks) > 0 {
+		app.reportMarkers(result, marks)
+	}
+
+	if res.Text == "" {
+		fmt.Println("⚠️  No transcription generated")
+		failure = fmt.Errorf("no speech recognized")
+		return
+	}
+	if app.hallucinated(res, samples, cfg) {
+		fmt.Printf("👻 Dropping likely hallucination: %s\n", app.logText(res.Text))
+		failure = fmt.Errorf("no speech recognized")
+		return
+	}
+
+	if app.cfg.RedactTranscripts {
+		fmt.Printf("📝 Transcription: %s, %.1fs audio\n", app.logText(res.Text), res.Timings.Audio.Seconds())
+	} else {
+		fmt.Printf("📝 Transcription: %s\n", res.Text)
+	}
+
+	if app.cancelled(gen) {
+		fmt.Println("🚫 Processing was cancelled, discarding transcription")
+		return
+	}
+
+	// Clean up the transcript before commands and injection
+	if res.Language == "" && cfg.Language != nil {
+		res.Language = *cfg.Language
+	}
+	postProcessStart := time.Now()
+	chain := buildPostProcessors(cfg, res.Language)
+	switch {
+	case grammar != nil:
+		// The grammar already decided on the exact text
+		chain = nil
+		res.Text = strings.TrimSpace(res.Text)
+	case mode != "":
+		// The value is all that's left, the other processors would only get in its way
+		constraint, err := postprocess.NewConstraint(mode, res.Language, cfg.Locale)
+		if err != nil {
+			failure = err
+			return
+		}
+		chain = postprocess.Chain{constraint}
+	}
+	if len(chain) > 0 {
+		res.Text = chain.Process(res.Text)
+		if res.Text == "" && mode != "" {
+			fmt.Printf("⚠️  No %s recognized\n", mode)
+			failure = fmt.Errorf("no %s recognized", mode)
+			return
+		}
+		if res.Text == "" {
+			fmt.Println("⚠️  Nothing left after post-processing")
+			failure = fmt.Errorf("nothing left after post-processing")
+			return
+		}
+		fmt.Printf("✨ Post-processed (%s): %s\n", strings.Join(chain.Names(), ", "), app.logText(res.Text))
+	}
+	res.Timings.PostProcess = time.Since(postProcessStart)
 
-	return app.recorder.Start()
+	// Point out words the decoder was unsure about so they can be double-checked
+	// in the log, the preview, the overlay and the dashboard
+	if threshold := float32(app.cfg.LowConfidenceThreshold); threshold > 0 {
+		res.Uncertain = result.LowConfidence(threshold)
+		if n := len(res.Uncertain); n > 0 && !app.cfg.RedactTranscripts {
+			fmt.Printf("🔎 Low confidence (%d word(s) < %.0f%%): %s\n", n, threshold*100, result.Highlight(threshold, "⟨", "⟩"))
+		}
+	}
+
+	// Earlier dictations that are still processing go first
+	if app.injectQueue.Wait(ticket) {
+		fmt.Println("⏳ Waited for an earlier dictation to be injected")
+		if app.cancelled(gen) {
+			fmt.Println("🚫 Processing was cancelled, discarding transcription")
+			return
+		}
+	}
+
+	// Streamed dictations continue the injected text and are never commands
+	if continued {
+		app.saveHistory(res, false)
+		if cfg.StripTrailingPeriod {
+			res.Text = postprocess.TrailingPeriodStripper{}.Process(res.Text)
+		}
+		if err := app.injectResult(" "+res.Text, cfg.InjectSuffix, res, cfg); err != nil {
+			failure = err
+		}
+		app.lastText += " " + res.Text
+		return
+	}
+
+	// The command grammar only lets whisper hear command words, run it
+	if mode == "command" {
+		execution, err := app.cmdExecutor.Execute(res.Text, window)
+		if execution == nil && err == nil {
+			err = fmt.Errorf("no command recognized")
+		}
+		if err != nil {
+			fmt.Printf("❌ Command execution failed: %v\n", err)
+			failure = err
+		}
+		if execution != nil {
+			res.Action = "command"
+			app.confirmCommand(cfg, execution, err)
+			app.saveHistory(res, true)
+		}
+		return
+	}
+
+	// Constrained values go straight into the field, they are never commands
+	// and neither rewritten nor prefixed
+	if mode != "" {
+		app.saveHistory(res, false)
+		if err := app.injectResult(res.Text, "", res, cfg); err != nil {
+			failure = err
+			return
+		}
+		app.lastText = res.Text
+		return
+	}
+
+	// Read the last dictation aloud instead of injecting the request
+	if cfg.ReadbackPhrase != "" && normalizePhrase(res.Text) == normalizePhrase(cfg.ReadbackPhrase) {
+		res.Action = "readback"
+		if err := app.readBack(); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			failure = err
+		}
+		return
+	}
+
+	// Check if it's a command
+	execution, err := app.cmdExecutor.Execute(res.Text, window)
+	if err != nil {
+		fmt.Printf("❌ Command execution failed: %v\n", err)
+		failure = err
+		// Fall through to text injection on error
+	}
+	if execution != nil {
+		res.Action = "command"
+		app.confirmCommand(cfg, execution, err)
+		app.saveHistory(res, true)
+		fmt.Println("✅ Command executed successfully")
+		return
+	}
+
+	// Compose mode: collect dictations and voice edits until "send it"
+	if composer := app.composer; composer != nil {
+		text, sent := app.composeDictation(composer, res.Text)
+		if !sent {
+			res.Action = "composed"
+			return
+		}
+		res.Text = text
+	}
+
+	if err := app.injectDictation(res, cfg); err != nil {
+		failure = err
+	}
 }
 
-func (app *App) stopRecording() error {
-	app.isRecording = false
+// newResult starts the result of a transcription of samples recorded for window
+func (app *App) newResult(result *whisper.Result, mode string, window *hyprland.Window, samples int) *transcript.Result {
+	res := transcript.New(result)
+	res.Mode = mode
+	res.Backend = app.activeModel
+	res.Profile = app.profile
+	res.Window = window
+	res.Timings.Audio = time.Duration(samples) * time.Second / time.Duration(app.cfg.SampleRate)
+	return res
+}
 
-	// Play stop sound
+// injectResult injects text for a result, timing the injection. text is the
+// result's text with whatever the injection adds (e.g. a separating space),
+// suffix the inject_suffix ending a dictation ("" within one).
+func (app *App) injectResult(text, suffix string, res *transcript.Result, cfg *config.Config) error {
+	opts := injectOptions(app.injectionConfig(res, cfg))
+	switch suffix {
+	case "space":
+		text += " "
+	case "newline":
+		text += "\n"
+	case "enter":
+		opts.Enter = true
+	}
+
+	start := time.Now()
+	err := app.injector.Inject(text, opts)
+	res.Timings.Inject = time.Since(start)
+	if err != nil {
+		fmt.Printf("❌ Text injection failed: %v\n", err)
+		return err
+	}
+	res.Action = "injected"
+	return nil
+}
+
+// injectionConfig returns the config whose injection settings (mode, paste
+// shortcut) apply to a result. The window is looked up again at injection
+// time: if the focus moved to another app while processing, its app profile
+// decides how the text gets in.
+func (app *App) injectionConfig(res *transcript.Result, cfg *config.Config) *config.Config {
+	window := app.activeWindow()
+	if window == nil || res.Window != nil && window.Address == res.Window.Address {
+		return cfg
+	}
+	fmt.Printf("🪟 Focus moved to %s, injecting with its settings\n", window.Class)
+	return app.cfg.ForWindowClass(window.Class, window.InitialClass)
+}
+
+// broadcastResult sends a finished dictation to connected clients as a
+// "transcription" event, without the text with redact_transcripts. Dictations
+// that came to nothing (failed, discarded) are not sent.
+func (app *App) broadcastResult(res *transcript.Result) {
+	if app.ipcServer == nil || res.Action == "" {
+		return
+	}
+	if app.cfg.RedactTranscripts {
+		res = res.Redacted()
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	app.ipcServer.Broadcast("transcription", string(data))
+}
+
+// confirmCommand plays and speaks the command_feedback of an executed command.
+// Failures always play the error sound (via setResult), the configured
+// say_error is spoken in addition.
+func (app *App) confirmCommand(cfg *config.Config, execution *command.Execution, err error) {
+	feedback, ok := cfg.CommandFeedback[execution.Trigger]
+	if !ok {
+		return
+	}
+
+	say := feedback.Say
+	if err != nil {
+		say = feedback.SayError
+	} else if app.player != nil {
+		app.player.PlayFile(feedback.Sound)
+	}
+	if feedback.Notify {
+		if err != nil {
+			go notify.Send(fmt.Sprintf("Command '%s' failed", execution.Trigger), fmt.Sprintf("Exit code %d: %v", execution.ExitCode, err))
+		} else {
+			go notify.Send(fmt.Sprintf("Command '%s' done", execution.Trigger), "Exit code 0")
+		}
+	}
+	app.speak(strings.ReplaceAll(say, "{args}", execution.Arguments), false)
+}
+
+// commandRecognized plays the recognized_sound of a command and announces it
+// with a notification, before the command runs
+func (app *App) commandRecognized(execution *command.Execution) {
+	feedback, ok := app.cfg.CommandFeedback[execution.Trigger]
+	if !ok {
+		return
+	}
 	if app.player != nil {
-		app.player.PlayStop()
+		app.player.PlayFile(feedback.RecognizedSound)
 	}
+	if feedback.Notify {
+		go notify.Send(fmt.Sprintf("Running '%s'", execution.Trigger), app.logText(execution.Arguments))
+	}
+}
 
-	// Notify waybar of recording state change
-	exec.Command("pkill", "-RTMIN+9", "waybar").Run()
+// addMarker bookmarks the current position of the recording
+func (app *App) addMarker(label string) (markers.Marker, error) {
+	if !app.isRecording {
+		return markers.Marker{}, fmt.Errorf("not recording")
+	}
+	m := markers.Marker{Offset: app.recorder.Duration(), Label: label}
+	app.markers = append(app.markers, m)
 
-	// Get recorded audio
-	samples, err := app.recorder.Stop()
+	fmt.Printf("🔖 Marker %d: %s\n", len(app.markers), m)
+	if app.player != nil {
+		app.player.PlayWarning(1)
+	}
+	if app.ipcServer != nil {
+		app.ipcServer.Broadcast("marker", m.String())
+	}
+	return m, nil
+}
+
+// reportMarkers lists the markers of a transcription with the text around them
+// and exports the transcript with the markers as subtitles
+func (app *App) reportMarkers(result *whisper.Result, marks []markers.Marker) {
+	markers.Sort(marks)
+	fmt.Printf("🔖 %d marker(s):\n", len(marks))
+	for _, m := range marks {
+		context := ""
+		if !app.cfg.RedactTranscripts {
+			context = markers.Context(m, result.Segments)
+		}
+		if m.Spoken {
+			fmt.Printf("   %s (spoken) %s\n", m, context)
+		} else {
+			fmt.Printf("   %s %s\n", m, context)
+		}
+	}
+
+	path, err := markers.Export(app.cfg.MarkersDir, result.Segments, marks)
 	if err != nil {
+		fmt.Printf("⚠️  Failed to export markers: %v\n", err)
+		return
+	}
+	fmt.Printf("💾 Markers saved: %s\n", path)
+}
+
+// Speech spans for segment_language_detection: VAD segments closer than
+// spanMergeGap are one span, and spans are at least spanMinLength long, because
+// language detection needs a few words to be reliable
+const (
+	spanMergeGap  = 500 * time.Millisecond
+	spanMinLength = 1500 * time.Millisecond
+	spanPadding   = 200 * time.Millisecond // Same as the VAD stage keeps around speech
+)
+
+// speechSpans converts the voice segments of a recording with total samples into
+// the spans transcribed in their own language
+func speechSpans(segments []audio.VoiceSegment, sampleRate, total int) []whisper.Span {
+	toSamples := func(d time.Duration) int { return int(d * time.Duration(sampleRate) / time.Second) }
+	toDuration := func(ms float64) time.Duration { return time.Duration(ms * float64(time.Millisecond)) }
+
+	var spans []whisper.Span
+	for _, seg := range segments {
+		start := toSamples(toDuration(seg.Start) - spanPadding)
+		end := toSamples(toDuration(seg.End) + spanPadding)
+		if start < 0 {
+			start = 0
+		}
+		if end > total {
+			end = total
+		}
+		if n := len(spans); n > 0 {
+			last := &spans[n-1]
+			if start-last.End < toSamples(spanMergeGap) || last.End-last.Start < toSamples(spanMinLength) {
+				last.End = end
+				continue
+			}
+		}
+		spans = append(spans, whisper.Span{Start: start, End: end})
+	}
+	// A short last span joins the one before it
+	if n := len(spans); n > 1 && spans[n-1].End-spans[n-1].Start < toSamples(spanMinLength) {
+		spans[n-2].End = spans[n-1].End
+		spans = spans[:n-1]
+	}
+	return spans
+}
+
+// injectDictation rewrites a finished dictation with the LLM (if enabled), saves it
+// to the history and injects it into the focused window
+func (app *App) injectDictation(res *transcript.Result, cfg *config.Config) error {
+	// Optionally let an LLM clean up the text
+	if cfg.LLMEnabled {
+		rewriter, err := llm.New(llmConfig(cfg))
+		if err != nil {
+			fmt.Printf("⚠️  LLM post-processing disabled: %v\n", err)
+		} else {
+			fmt.Printf("🤖 Rewriting with %s...\n", rewriter.Name())
+			start := time.Now()
+			res.Text = rewriter.Process(res.Text)
+			res.Timings.PostProcess += time.Since(start)
+			fmt.Printf("🤖 LLM result: %s\n", app.logText(res.Text))
+		}
+	}
+
+	// Save before injecting so the text survives a lost focus
+	app.saveHistory(res, false)
+
+	// Inject text normally
+	text := res.Text
+	if cfg.StripTrailingPeriod {
+		text = postprocess.TrailingPeriodStripper{}.Process(text)
+	}
+	if cfg.TextPrefix != "" {
+		prefix := postprocess.Prefix{Template: cfg.TextPrefix, App: res.App()}
+		text = prefix.Process(text)
+	}
+	// A runaway recording must not flood the focused window
+	if cfg.MaxInjectChars > 0 && utf8.RuneCountInString(text) > cfg.MaxInjectChars {
+		var ok bool
+		if text, ok = app.limitInjection(text, cfg); !ok {
+			return nil
+		}
+	}
+
+	if cfg.Preview != "off" {
+		var ok bool
+		if text, ok = app.previewInjection(text, res.Uncertain, cfg); !ok {
+			return nil
+		}
+	}
+
+	// Hear the text before it lands, e.g. to cancel a misrecognition
+	res.Text = text
+	if cfg.Readback == "before" {
+		app.speak(text, true)
+	}
+	if err := app.injectResult(text, cfg.InjectSuffix, res, cfg); err != nil {
 		return err
 	}
+	app.lastText = text
+	app.showUncertain(text, res.Uncertain)
+	if cfg.Readback == "after" {
+		app.speak(text, false)
+	}
+	return nil
+}
 
-	// Get loopback audio if available
-	var loopbackSamples []float32
-	if app.loopbackRec != nil {
-		loopbackSamples, err = app.loopbackRec.Stop()
+// limitInjection handles a transcription longer than max_inject_chars as
+// oversize_action says. Returns the text to inject, or false to inject nothing.
+// The full text is in the history either way.
+func (app *App) limitInjection(text string, cfg *config.Config) (string, bool) {
+	length := utf8.RuneCountInString(text)
+	fmt.Printf("📏 Transcription has %d characters, more than max_inject_chars (%d)\n", length, cfg.MaxInjectChars)
+
+	action := cfg.OversizeAction
+	if action == "confirm" {
+		choice, err := notify.Ask("Long transcription", fmt.Sprintf("%d characters. Insert them into the focused window?", length), 30*time.Second,
+			notify.Action{Key: "inject", Label: "Insert"},
+			notify.Action{Key: "truncate", Label: fmt.Sprintf("Insert first %d", cfg.MaxInjectChars)},
+			notify.Action{Key: "clipboard", Label: "Copy only"})
+		if err != nil {
+			fmt.Printf("⚠️  Can't ask for confirmation: %v\n", err)
+		}
+		switch choice {
+		case "inject":
+			return text, true
+		case "truncate":
+			action = "truncate"
+		default:
+			// Dismissed or no answer: nothing lands in the window
+			action = "clipboard"
+		}
+	}
+
+	if action == "truncate" {
+		truncated := truncateText(text, cfg.MaxInjectChars)
+		fmt.Printf("✂️  Injecting the first %d of %d characters\n", utf8.RuneCountInString(truncated), length)
+		return truncated, true
+	}
+
+	if err := app.injector.Inject(text, inject.Options{Mode: "clipboard"}); err != nil {
+		fmt.Printf("❌ Failed to copy the transcription: %v\n", err)
+		return "", false
+	}
+	notify.Send("Long transcription copied", fmt.Sprintf("%d characters were copied to the clipboard instead of being inserted.", length))
+	return "", false
+}
+
+// previewInjection shows the transcription as preview says and waits for it to be
+// confirmed, possibly after editing it. Returns the text to inject, or false when it
+// was discarded or not answered within preview_timeout_seconds. The uncertain
+// (low confidence) words are marked for double-checking.
+func (app *App) previewInjection(text string, uncertain []string, cfg *config.Config) (string, bool) {
+	timeout := time.Duration(cfg.PreviewTimeoutSeconds) * time.Second
+	edit := cfg.Preview == "menu"
+	if cfg.Preview == "notify" {
+		choice, err := notify.Ask("Insert transcription?", transcript.Highlight(text, uncertain, "⟨", "⟩"), timeout,
+			notify.Action{Key: "inject", Label: "Insert"},
+			notify.Action{Key: "edit", Label: "Edit"},
+			notify.Action{Key: "discard", Label: "Discard"})
+		if err != nil {
+			fmt.Printf("⚠️  Can't ask for confirmation: %v\n", err)
+		}
+		switch choice {
+		case "inject":
+			return text, true
+		case "edit":
+			edit = true
+		}
+	}
+
+	if edit {
+		launcher, err := menu.Find(cfg.PreviewMenu)
+		if err != nil {
+			fmt.Printf("⚠️  Can't edit the transcription: %v\n", err)
+		} else if edited, ok, err := menu.Edit(launcher, editPrompt(text, uncertain), text, timeout); err != nil {
+			fmt.Printf("⚠️  Can't edit the transcription: %v\n", err)
+		} else if ok {
+			if edited != text {
+				fmt.Printf("✏️  Edited: %s\n", app.logText(edited))
+			}
+			return edited, true
+		}
+	}
+
+	fmt.Println("🗑️  Transcription discarded")
+	return "", false
+}
+
+// showUncertain shows the injected text in the overlay with the words to
+// double-check highlighted, if any of them made it into the text
+func (app *App) showUncertain(text string, uncertain []string) {
+	if app.overlay == nil || len(uncertain) == 0 {
+		return
+	}
+	line := strings.Join(strings.Fields(text), " ")
+	if marks := transcript.Spans(line, uncertain); len(marks) > 0 {
+		app.overlay.ShowResult(line, marks)
+	}
+}
+
+// editPrompt is the prompt of the preview menu. The input field holds the text to
+// insert, so the words to double-check are listed in the prompt instead.
+func editPrompt(text string, uncertain []string) string {
+	var words []string
+	for _, span := range transcript.Spans(text, uncertain) {
+		words = append(words, text[span[0]:span[1]])
+	}
+	if len(words) == 0 {
+		return "Insert"
+	}
+	return truncateText("Insert (check "+strings.Join(words, ", "), 60) + ")"
+}
+
+// truncateText shortens text to at most limit characters, ending at a word
+// boundary with "…"
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndexAny(cut, " \n\t"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
+
+// setMode selects the constrained mode of the next recording ("none" for free dictation)
+func (app *App) setMode(mode string) error {
+	if mode == "none" {
+		app.nextMode = ""
+		return nil
+	}
+	if postprocess.ConstraintPrompt(mode) == "" {
+		grammar, err := app.modeGrammar(mode, app.cfg, nil)
+		if err != nil {
+			return err
+		}
+		if grammar == nil {
+			return fmt.Errorf("unknown mode '%s' (available: %s, none)", mode, strings.Join(app.modeNames(), ", "))
+		}
+	}
+	app.nextMode = mode
+	return nil
+}
+
+// useModel picks the model transcribing the next recording, "none" goes back
+// to mode_models and model
+func (app *App) useModel(model string) error {
+	if model == "none" {
+		app.nextModel = ""
+		return nil
+	}
+	if model != app.cfg.Model && app.residentModels[model] == nil {
+		names := []string{app.cfg.Model}
+		for name := range app.residentModels {
+			names = append(names, name)
+		}
+		sort.Strings(names[1:])
+		return fmt.Errorf("model '%s' is not loaded (loaded: %s; add it to resident_models)", model, strings.Join(names, ", "))
+	}
+	app.nextModel = model
+	return nil
+}
+
+// modeNames lists the constrained modes: value modes, built-in grammars and
+// the grammars config
+func (app *App) modeNames() []string {
+	names := append([]string(nil), postprocess.ConstraintModes...)
+	var grammars []string
+	for name := range whisper.BuiltinGrammars {
+		grammars = append(grammars, name)
+	}
+	for name := range app.cfg.Grammars {
+		if whisper.BuiltinGrammars[name] == "" && name != "command" {
+			grammars = append(grammars, name)
+		}
+	}
+	sort.Strings(grammars)
+	return append(append(names, grammars...), "command")
+}
+
+// modeGrammar returns the decoding grammar of a mode: a built-in one, the
+// command words for "command" or a file from the grammars config. Returns nil
+// for modes without a grammar.
+func (app *App) modeGrammar(mode string, cfg *config.Config, window *hyprland.Window) (*whisper.Grammar, error) {
+	var src string
+	switch {
+	case mode == "command":
+		triggers := app.cmdExecutor.Triggers(window)
+		if !app.cmdExecutor.IsEnabled() || len(triggers) == 0 {
+			return nil, fmt.Errorf("the command mode needs command_mode and configured commands")
+		}
+		src = whisper.VocabularyGrammar(triggers, true)
+	case whisper.BuiltinGrammars[mode] != "":
+		src = whisper.BuiltinGrammars[mode]
+	case cfg.Grammars[mode] != "":
+		path := cfg.Grammars[mode]
+		if strings.HasPrefix(path, "~/") {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, path[2:])
+		}
+		data, err := os.ReadFile(path)
 		if err != nil {
-			fmt.Printf("⚠️  Failed to stop loopback recording: %v\n", err)
+			return nil, fmt.Errorf("failed to read grammar %s: %w", mode, err)
 		}
+		src = string(data)
+	default:
+		return nil, nil
 	}
 
-	// Process audio in background
-	go app.processAudio(samples, loopbackSamples)
+	grammar, err := whisper.ParseGrammar(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grammar %s: %w", mode, err)
+	}
+	return grammar, nil
+}
 
+// readBack speaks the last injected dictation
+func (app *App) readBack() error {
+	if app.lastText == "" {
+		return fmt.Errorf("nothing to read back yet")
+	}
+	fmt.Printf("🗣️  Reading back the last dictation (%d words)\n", len(strings.Fields(app.lastText)))
+	app.speak(app.lastText, false)
 	return nil
 }
 
-func (app *App) processAudio(samples []float32, loopbackSamples []float32) {
-	app.isProcessing = true
-	defer func() {
-		app.isProcessing = false
-	}()
-
-	// Debug: Print sample counts
-	fmt.Printf("🔍 DEBUG: Mic samples: %d, Loopback samples: %d\n", len(samples), len(loopbackSamples))
-
-	// Apply AEC if available
-	processedSamples := samples
-	if app.aecProc != nil && len(loopbackSamples) > 0 {
-		fmt.Println("🔊 AEC: Processing with echo cancellation...")
-		// Ensure both samples have same length
-		minLen := len(samples)
-		if len(loopbackSamples) < minLen {
-			minLen = len(loopbackSamples)
-		}
-
-		if minLen > 0 {
-			micSamples := samples[:minLen]
-			farEndSamples := loopbackSamples[:minLen]
-			processedSamples = app.aecProc.ProcessFrame(micSamples, farEndSamples)
-			fmt.Printf("✅ AEC: Processed %d samples\n", minLen)
-		}
-	} else if app.aecProc == nil {
-		fmt.Println("⚠️  AEC: Disabled (aecProc is nil)")
-	} else if len(loopbackSamples) == 0 {
-		fmt.Println("⚠️  AEC: No loopback samples captured!")
+// speak reads text aloud with tts_command, waiting until it was spoken if wait is set.
+// Empty text is ignored.
+func (app *App) speak(text string, wait bool) {
+	speaker := app.speaker
+	if speaker == nil {
+		return
 	}
-
-	// Apply VAD if available
-	samplesToTranscribe := processedSamples
-	if app.vadProc != nil {
-		voiceSegments := app.vadProc.GetVoiceSegments(processedSamples)
-		if len(voiceSegments) == 0 {
-			fmt.Println("⚠️  VAD: No voice detected - skipping transcription (only background/output audio)")
-			return
+	say := func() {
+		if err := speaker.Say(text); err != nil {
+			fmt.Printf("⚠️  Text-to-speech failed: %v\n", err)
 		}
-		fmt.Printf("✅ VAD: Detected %d voice segment(s)\n", len(voiceSegments))
-
-		// Instead of extracting segments, mute non-voice parts in-place
-		// This preserves timing and structure for Whisper
-		sampleRate := float64(app.cfg.SampleRate)
-		paddingMs := 200.0 // Add 200ms padding before/after each segment
-		paddingSamples := int(paddingMs * sampleRate / 1000.0)
+	}
+	if wait {
+		say()
+	} else {
+		go say()
+	}
+}
 
-		// Create a copy to modify
-		mutedSamples := make([]float32, len(processedSamples))
-		copy(mutedSamples, processedSamples)
+// hallucinated reports whether a transcription is nothing but a phrase whisper
+// makes up for silence and noise, while the recording was short or quiet
+func (app *App) hallucinated(res *transcript.Result, samples []float32, cfg *config.Config) bool {
+	if !postprocess.IsHallucination(res.Text, cfg.HallucinationPhrases) {
+		return false
+	}
+	return res.Timings.Audio.Seconds() <= cfg.HallucinationMaxSeconds ||
+		audio.MeasureLevel(samples).RMSdB < cfg.HallucinationQuietDB
+}
 
-		// Create a mask: true = keep audio, false = mute
-		keepMask := make([]bool, len(processedSamples))
+// normalizePhrase lowercases a dictation and strips surrounding punctuation,
+// so "Read that back." matches "read that back"
+func normalizePhrase(text string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(text), ".,!?;: "))
+}
 
-		// Mark voice segments (with padding) to keep
-		for i, seg := range voiceSegments {
-			startSample := int(seg.Start*sampleRate/1000.0) - paddingSamples
-			endSample := int(seg.End*sampleRate/1000.0) + paddingSamples
+// loadTranscriber loads a whisper model and measures the memory it takes
+func (app *App) loadTranscriber(model string) (*whisper.Transcriber, error) {
+	before := models.ProcessMemoryMB()
+	transcriber, err := app.openTranscriber(model)
+	if err != nil {
+		return nil, err
+	}
+	// Memory of a previously freed model may be reused, only trust a growing process
+	app.modelMemoryMB = 0
+	if delta := models.ProcessMemoryMB() - before; delta > 0 {
+		app.modelMemoryMB = delta
+	}
+	return transcriber, nil
+}
 
-			// Bounds check
-			if startSample < 0 {
-				startSample = 0
-			}
-			if endSample > len(processedSamples) {
-				endSample = len(processedSamples)
-			}
+// openTranscriber loads a whisper model with the settings of the config
+func (app *App) openTranscriber(model string) (*whisper.Transcriber, error) {
+	modelPath := filepath.Join(app.cfg.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", model))
+	transcriber, err := whisper.New(modelPath, app.cfg.Threads, app.cfg.GPUDevice, app.cfg.Prompt(), app.cfg.AllowedLanguages)
+	if err != nil {
+		return nil, err
+	}
+	app.configureTranscriber(transcriber, model, app.cfg)
+	return transcriber, nil
+}
 
-			// Mark this range to keep
-			for j := startSample; j < endSample; j++ {
-				keepMask[j] = true
-			}
+// configureTranscriber applies the settings that don't need the model to be
+// loaded again
+func (app *App) configureTranscriber(transcriber *whisper.Transcriber, model string, cfg *config.Config) {
+	transcriber.SetLanguageCache(time.Duration(cfg.LanguageCacheSeconds) * time.Second)
+	transcriber.SetAllowedLanguages(cfg.AllowedLanguages)
+	transcriber.SetLanguagePrompts(cfg.LanguagePrompts())
+	transcriber.SetDecoding(whisperDecoding(cfg.ForModel(model)))
+	transcriber.SetNoSpeechThreshold(float32(cfg.NoSpeechThreshold))
+}
 
-			fmt.Printf("   Segment %d: %.1fms-%.1fms (%.1fms duration, keeping with %.0fms padding)\n",
-				i+1, seg.Start, seg.End, seg.Duration, paddingMs*2)
+// initResidentModels (re)loads the resident_models, keeping the ones already
+// loaded unless reload is set
+func (app *App) initResidentModels(reload bool) {
+	resident := make(map[string]*residentModel, len(app.cfg.ResidentModels))
+	for _, name := range app.cfg.ResidentModels {
+		if name == app.cfg.Model || resident[name] != nil {
+			continue
+		}
+		if m := app.residentModels[name]; m != nil && !reload {
+			resident[name] = m
+			delete(app.residentModels, name)
+			continue
+		}
+		model := app.englishModel(name)
+		transcriber, err := app.openTranscriber(model)
+		if err != nil {
+			fmt.Printf("❌ Failed to load resident model %s: %v\n", name, err)
+			continue
 		}
+		resident[name] = &residentModel{transcriber: transcriber, loaded: model}
+	}
+	for _, m := range app.residentModels {
+		m.transcriber.Close()
+	}
+	app.residentModels = resident
+}
 
-		// Mute (zero out) all non-voice parts
-		mutedCount := 0
-		for i := range mutedSamples {
-			if !keepMask[i] {
-				mutedSamples[i] = 0.0
-				mutedCount++
-			}
+// transcriberFor returns the transcriber and name of the model transcribing a
+// recording: the model chosen with "hyprwhspr use", the one of its mode in
+// mode_models, or the main model
+func (app *App) transcriberFor(model, mode string) (*whisper.Transcriber, string, error) {
+	if model == "" {
+		model = app.cfg.ModeModels[mode]
+	}
+	if model != "" && model != app.cfg.Model {
+		if m := app.residentModels[model]; m != nil {
+			return m.transcriber, m.loaded, nil
 		}
+		fmt.Printf("⚠️  Model %s is not loaded, using %s\n", model, app.cfg.Model)
+	}
+	if err := app.waitForModel(); err != nil {
+		return nil, "", err
+	}
+	return app.transcriber, app.activeModel, nil
+}
 
-		keptSamples := len(mutedSamples) - mutedCount
-		fmt.Printf("📊 VAD: Keeping %d samples, muted %d samples (%.1f%% voice)\n",
-			keptSamples, mutedCount, float64(keptSamples)/float64(len(mutedSamples))*100)
+// whisperDecoding returns the decoding settings of the config, see
+// Config.ForModel for a model's overrides
+func whisperDecoding(cfg *config.Config) whisper.Decoding {
+	return whisper.Decoding{
+		BeamSize:       cfg.BeamSize,
+		BestOf:         cfg.BestOf,
+		Temperature:    float32(cfg.Temperature),
+		TemperatureInc: float32(cfg.TemperatureInc),
+		EntropyThold:   float32(cfg.EntropyThold),
+		LogprobThold:   float32(cfg.LogprobThold),
+	}
+}
 
-		samplesToTranscribe = mutedSamples
+// recordStats adds a transcription by model to the runtime statistics
+func (app *App) recordStats(model string, samples int, wall time.Duration, text string) {
+	sample := stats.Sample{
+		Model: model,
+		Audio: time.Duration(samples) * time.Second / time.Duration(app.cfg.SampleRate),
+		Wall:  wall,
+		Words: len(strings.Fields(text)),
 	}
+	app.stats.Record(sample)
+	realTimeFactor := wall.Seconds() / sample.Audio.Seconds()
+	fmt.Printf("⏱️  Transcribed %.1fs of audio in %v (%.2fx real time)\n",
+		sample.Audio.Seconds(), wall.Round(time.Millisecond), realTimeFactor)
 
-	// Transcribe
-	text, err := app.transcriber.Transcribe(samplesToTranscribe)
-	if err != nil {
-		fmt.Printf("❌ Transcription failed: %v\n", err)
+	// Very short clips are dominated by fixed overhead and would skew the measurement
+	if sample.Audio >= 2*time.Second {
+		modelManager := models.NewManager(app.cfg.WhisperModelDir)
+		// Only the main model's memory was measured
+		memoryMB := 0.0
+		if model == app.activeModel {
+			memoryMB = app.modelMemoryMB
+		}
+		if err := modelManager.RecordBenchmark(sample.Model, realTimeFactor, memoryMB); err != nil {
+			fmt.Printf("⚠️  Failed to save benchmark: %v\n", err)
+		}
+	}
+}
+
+// logMetrics appends a dictation to the metrics CSV (--log-metrics). res is nil
+// when nothing was transcribed, speechRatio is negative without VAD.
+func (app *App) logMetrics(samples int, speechRatio float64, res *transcript.Result) {
+	if app.metrics == nil {
 		return
 	}
+	utterance := stats.Utterance{
+		Time:        time.Now(),
+		Audio:       time.Duration(samples) * time.Second / time.Duration(app.cfg.SampleRate),
+		SpeechRatio: speechRatio,
+		Model:       app.activeModel,
+	}
+	if res != nil {
+		utterance.Model = res.Backend
+		utterance.Wall = res.Timings.Transcribe
+		utterance.Confidence = res.Confidence
+		utterance.Words = res.Words()
+		utterance.Language = res.Language
+	}
+	if err := app.metrics.Append(utterance); err != nil {
+		fmt.Printf("⚠️  Failed to log metrics: %v\n", err)
+	}
+}
 
-	if text == "" {
-		fmt.Println("⚠️  No transcription generated")
+// saveHistory persists a transcription to the history store (if enabled)
+func (app *App) saveHistory(res *transcript.Result, isCommand bool) {
+	if app.history == nil {
 		return
 	}
+	entry := history.Entry{
+		Time:       time.Now(),
+		Text:       res.Text,
+		DurationMs: res.Timings.Audio.Milliseconds(),
+		Model:      res.Backend,
+		Language:   res.Language,
+		Profile:    res.Profile,
+		Confidence: res.Confidence,
+		Command:    isCommand,
+	}
+	if res.Window != nil {
+		entry.WindowClass = res.Window.Class
+		entry.WindowTitle = res.Window.Title
+	}
+	if err := app.history.Add(entry); err != nil {
+		fmt.Printf("⚠️  Failed to save history: %v\n", err)
+	}
+}
 
-	fmt.Printf("📝 Transcription: %s\n", text)
+// processingPriority returns the priority transcriptions run with
+func processingPriority(cfg *config.Config) priority.Options {
+	return priority.Options{
+		Nice:     cfg.ProcessingNice,
+		IOClass:  cfg.ProcessingIOClass,
+		MaxProcs: cfg.ProcessingMaxProcs,
+	}
+}
 
-	// Check if it's a command
-	wasCommand, err := app.cmdExecutor.Execute(text)
-	if err != nil {
-		fmt.Printf("❌ Command execution failed: %v\n", err)
-		// Fall through to text injection on error
+// injectOptions returns the injection settings for the effective config
+func injectOptions(cfg *config.Config) inject.Options {
+	return inject.Options{
+		Mode:          cfg.InjectionMode,
+		File:          cfg.OutputFile,
+		PasteShortcut: cfg.PasteShortcut,
+		Verify:        cfg.VerifyInjection,
+		VerifyTimeout: time.Duration(cfg.InjectionVerifyTimeoutMs) * time.Millisecond,
+		ChunkSize:     cfg.InjectChunkChars,
+		ChunkDelay:    time.Duration(cfg.InjectChunkDelayMs) * time.Millisecond,
 	}
+}
 
-	if wasCommand {
-		fmt.Println("✅ Command executed successfully")
-		return
+// llmConfig returns the LLM settings for the effective config
+func llmConfig(cfg *config.Config) llm.Config {
+	return llm.Config{
+		Provider:     cfg.LLMProvider,
+		Endpoint:     cfg.LLMEndpoint,
+		Model:        cfg.LLMModel,
+		APIKey:       cfg.LLMAPIKey,
+		SystemPrompt: cfg.LLMSystemPrompt,
+		Timeout:      time.Duration(cfg.LLMTimeoutSeconds) * time.Second,
 	}
+}
 
-	// Not a command, inject text normally
-	if err := app.injector.Inject(text); err != nil {
-		fmt.Printf("❌ Text injection failed: %v\n", err)
+// buildPostProcessors assembles the text post-processing chain for the effective config
+// and the language the text was transcribed in
+func buildPostProcessors(cfg *config.Config, language string) postprocess.Chain {
+	// Typography follows the configured locale, or the language that was spoken
+	locale := cfg.Locale
+	if locale == "" {
+		locale = language
 	}
+
+	var chain postprocess.Chain
+	if cfg.RemoveFillers {
+		chain = append(chain, postprocess.NewFillerFilter(cfg.FillerWords))
+	}
+	if len(cfg.Vocabulary) > 0 {
+		chain = append(chain, postprocess.NewVocabulary(cfg.Vocabulary))
+	}
+	if cfg.NormalizeNumbers && languageEnabled(cfg.NormalizeNumbersLanguages, language) {
+		if normalizer := postprocess.NewNumberNormalizer(language, locale); normalizer != nil {
+			chain = append(chain, normalizer)
+		}
+	}
+	if cfg.SmartQuotes {
+		if quotes := postprocess.NewSmartQuotes(locale); quotes != nil {
+			chain = append(chain, quotes)
+		}
+	}
+	return chain
+}
+
+// languageEnabled returns whether language is in the list (an empty list allows all)
+func languageEnabled(languages []string, language string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	for _, l := range languages {
+		if strings.EqualFold(l, language) {
+			return true
+		}
+	}
+	return false
 }
 
 func (app *App) setModel(modelName string) error {
@@ -645,17 +4365,19 @@ func (app *App) setModel(modelName string) error {
 	}
 
 	// Initialize new transcriber with the specified model
-	modelPath := filepath.Join(app.cfg.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", modelName))
-	transcriber, err := whisper.New(modelPath, app.cfg.Threads, app.cfg.WhisperPrompt, app.cfg.AllowedLanguages)
+	model := app.englishModel(modelName)
+	transcriber, err := app.loadTranscriber(model)
 	if err != nil {
-		return fmt.Errorf("failed to initialize whisper with model '%s': %w", modelName, err)
+		return fmt.Errorf("failed to initialize whisper with model '%s': %w", model, err)
 	}
 
 	app.transcriber = transcriber
+	app.activeModel = model
 	app.cfg.Model = modelName
+	app.baseCfg.Model = modelName
 
 	// Save the updated model to config
-	if err := app.cfg.Save(config.GetConfigPath()); err != nil {
+	if err := app.baseCfg.Save(app.cfgPath); err != nil {
 		fmt.Printf("⚠️  Failed to save model to config: %v\n", err)
 	}
 
@@ -663,7 +4385,249 @@ func (app *App) setModel(modelName string) error {
 	return nil
 }
 
+// setLanguage forces the transcription language ("auto" = detect it within
+// allowed_languages) until the daemon restarts, "default" goes back to the
+// language of the config
+func (app *App) setLanguage(language string) error {
+	language = strings.ToLower(language)
+	if language == "default" {
+		language = ""
+	} else if language != "auto" && !whisper.IsLanguage(language) {
+		return fmt.Errorf("unknown language %q", language)
+	}
+
+	newCfg, err := app.baseCfg.WithProfile(app.profile)
+	if err != nil {
+		return err
+	}
+	old := app.cfg
+	app.language = language
+	app.cfg = app.withLanguage(newCfg)
+	app.reinitializeComponents(old)
+
+	language = fixedLanguage(app.cfg)
+	if language == "" {
+		language = "auto"
+	}
+	if language == "auto" && len(app.cfg.AllowedLanguages) > 0 {
+		fmt.Printf("🌐 Language: auto (%s)\n", strings.Join(app.cfg.AllowedLanguages, ", "))
+	} else {
+		fmt.Printf("🌐 Language: %s\n", language)
+	}
+	if app.ipcServer != nil {
+		app.ipcServer.Broadcast("language", language)
+	}
+	return nil
+}
+
+// withLanguage returns cfg with the language set by "hyprwhspr lang" applied
+func (app *App) withLanguage(cfg *config.Config) *config.Config {
+	if app.language == "" {
+		return cfg
+	}
+	overridden := *cfg
+	if app.language == "auto" {
+		overridden.Language = nil
+	} else {
+		language := app.language
+		overridden.Language = &language
+	}
+	return &overridden
+}
+
+// switchProfile applies a named profile ("none" for the plain config), recreating
+// only the components whose settings differ (e.g. the model)
+func (app *App) switchProfile(name string) error {
+	if name == "none" {
+		name = ""
+	}
+	newCfg, err := app.baseCfg.WithProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if newCfg.Model != app.cfg.Model && !models.NewManager(newCfg.WhisperModelDir).IsModelDownloaded(newCfg.Model) {
+		return fmt.Errorf("model '%s' is not downloaded. Use 'hyprwhspr download %s' first", newCfg.Model, newCfg.Model)
+	}
+
+	old := app.cfg
+	app.cfg = app.withLanguage(newCfg)
+	app.reinitializeComponents(old)
+	app.profile = name
+	if name == "" {
+		fmt.Println("🎛️  Profile cleared")
+	} else {
+		fmt.Printf("🎛️  Switched to profile '%s'\n", name)
+	}
+	if app.ipcServer != nil {
+		app.ipcServer.Broadcast("profile", name)
+	}
+	return nil
+}
+
+// logText returns transcribed text for logs and notifications, or only its
+// word count if redact_transcripts is set
+func (app *App) logText(text string) string {
+	if app.cfg.RedactTranscripts {
+		return fmt.Sprintf("[redacted, %d words]", len(strings.Fields(text)))
+	}
+	return text
+}
+
+// fixedLanguage returns the transcription language of a config (empty = detect)
+func fixedLanguage(cfg *config.Config) string {
+	if cfg.Language == nil {
+		return ""
+	}
+	return *cfg.Language
+}
+
+// setDevice switches the microphone and saves its ID (stable, unlike the index)
+// to the config. "default" selects the system default.
+func (app *App) setDevice(selector string) (string, error) {
+	var device *string
+	id := "default"
+	if selector != "default" {
+		devices, err := audio.ListCaptureDevices()
+		if err != nil {
+			return "", fmt.Errorf("failed to list devices: %w", err)
+		}
+		dev, err := audio.MatchDevice(devices, selector)
+		if err != nil {
+			return "", err
+		}
+		id = dev.ID
+		device = &id
+		fmt.Printf("🎤 Microphone: %s (%s)\n", dev.Name, dev.ID)
+	}
+
+	app.cfg.AudioDevice = device
+	app.baseCfg.AudioDevice = device
+	if err := app.baseCfg.Save(app.cfgPath); err != nil {
+		fmt.Printf("⚠️  Failed to save device to config: %v\n", err)
+	}
+
+	if app.isRecording {
+		fmt.Println("🎤 Audio device changes apply to the next recording")
+		app.audioChanged = true
+	} else {
+		app.reinitializeAudio()
+	}
+	return id, nil
+}
+
+// onMicDisconnected is called when the microphone stops during a recording
+// (unplugged, Bluetooth dropped). The audio captured so far is transcribed.
+func (app *App) onMicDisconnected() {
+	if !app.isRecording {
+		return
+	}
+	fmt.Println("🔌 Microphone disconnected during recording, transcribing what was captured")
+	notify.Send("Microphone disconnected", "The recording was stopped. The next recording uses the default microphone until it is back.")
+	if app.ipcServer != nil {
+		app.ipcServer.Broadcast("device", "disconnected")
+	}
+	if err := app.stopRecording(); err != nil {
+		fmt.Printf("⚠️  Failed to stop recording: %v\n", err)
+	}
+	app.audioChanged = true
+}
+
+// startDeviceWatch starts watching for the audio device unless it is watched already
+func (app *App) startDeviceWatch() {
+	if app.stopDeviceWatch != nil {
+		return
+	}
+	app.stopDeviceWatch = make(chan struct{})
+	go app.watchDevice(app.stopDeviceWatch)
+}
+
+// stopDeviceWatcher stops watching for the audio device, e.g. in low-power mode
+func (app *App) stopDeviceWatcher() {
+	if app.stopDeviceWatch != nil {
+		close(app.stopDeviceWatch)
+		app.stopDeviceWatch = nil
+	}
+}
+
+// watchDevice checks every few seconds whether the configured audio_device is
+// connected. Recordings fall back to the default device while it is missing;
+// when it returns the recorder is recreated so the next recording uses it again.
+// A recording notices a lost device itself (onMicDisconnected), so it isn't
+// checked while recording, and not at all in low-power mode.
+func (app *App) watchDevice(stop chan struct{}) {
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if app.isRecording || app.lowPower {
+			continue
+		}
+		selector := app.cfg.AudioDevice
+		if selector == nil || *selector == "" {
+			app.deviceMissing = false
+			continue
+		}
+		devices, err := audio.ListCaptureDevices()
+		if err != nil {
+			continue
+		}
+		_, err = audio.MatchDevice(devices, *selector)
+		missing := err != nil
+
+		switch {
+		case missing && !app.deviceMissing:
+			fmt.Printf("🔌 Microphone %s disconnected, recording from the default device until it is back\n", *selector)
+			notify.Send("Microphone disconnected", fmt.Sprintf("%s is gone, using the default microphone until it is back.", *selector))
+			if app.ipcServer != nil {
+				app.ipcServer.Broadcast("device", "fallback "+*selector)
+			}
+		case !missing && app.deviceMissing:
+			fmt.Printf("🔌 Microphone %s reconnected\n", *selector)
+			notify.Send("Microphone reconnected", fmt.Sprintf("Recording from %s again.", *selector))
+			if app.ipcServer != nil {
+				app.ipcServer.Broadcast("device", "connected "+*selector)
+			}
+			// The old audio context may not know the device, start with a fresh one
+			app.audioChanged = true
+		}
+		app.deviceMissing = missing
+	}
+}
+
+// setSoundTheme switches the sound theme ("default" for the built-in sounds)
+// and saves it to the config
+func (app *App) setSoundTheme(theme string) error {
+	if theme == "default" {
+		theme = ""
+	} else if _, err := audio.FindSoundTheme(theme); err != nil {
+		return err
+	}
+
+	app.cfg.SoundTheme = theme
+	app.baseCfg.SoundTheme = theme
+	if err := app.baseCfg.Save(app.cfgPath); err != nil {
+		fmt.Printf("⚠️  Failed to save sound theme to config: %v\n", err)
+	}
+
+	if err := app.initPlayer(); err != nil {
+		return fmt.Errorf("failed to load sound theme: %w", err)
+	}
+	app.player.PlayStart()
+	return nil
+}
+
 func (app *App) cleanup() {
+	if app.idleTimer != nil {
+		app.idleTimer.Stop()
+	}
+	app.stopDeviceWatcher()
 	if app.cfgWatcher != nil {
 		app.cfgWatcher.Stop()
 	}
@@ -676,12 +4640,28 @@ func (app *App) cleanup() {
 	if app.loopbackRec != nil {
 		app.loopbackRec.Close()
 	}
+	if app.aecProc != nil {
+		app.aecProc.Close()
+	}
 	if app.player != nil {
 		app.player.Close()
 	}
 	if app.transcriber != nil {
 		app.transcriber.Close()
 	}
+	if app.sileroVAD != nil {
+		app.sileroVAD.Close()
+	}
+	for _, m := range app.residentModels {
+		m.transcriber.Close()
+	}
+	if app.injector != nil {
+		app.injector.Close()
+	}
+	if app.overlay != nil {
+		app.overlay.Close()
+	}
+	app.stopTriggers()
 	fmt.Println("✅ Cleanup completed")
 }
 
@@ -695,96 +4675,188 @@ func (app *App) initConfigWatcher(configPath string) error {
 	return watcher.Start()
 }
 
+// reloadConfig reads the config file again and applies it, returning the
+// components it restarted. An invalid config is refused and the running one kept.
+func (app *App) reloadConfig() (reloaded []string, unchanged bool, err error) {
+	newCfg, err := config.Load(app.cfgPath)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, issue := range newCfg.Validate() {
+		if !issue.Warning {
+			return nil, false, fmt.Errorf("%s, keeping the running config", issue)
+		}
+	}
+	if reflect.DeepEqual(newCfg, app.baseCfg) {
+		return nil, true, nil
+	}
+	return app.applyConfig(newCfg), false, nil
+}
+
+// onConfigChange applies the config file after the watcher saw it change
 func (app *App) onConfigChange(newCfg *config.Config) {
+	app.applyConfig(newCfg)
+}
+
+// applyConfig makes newCfg the running config and returns the components it
+// restarted
+func (app *App) applyConfig(newCfg *config.Config) []string {
+	// The watcher and an explicit reload may both report the same change
+	if reflect.DeepEqual(newCfg, app.baseCfg) {
+		fmt.Println("🔄 Config unchanged, nothing to reload")
+		return nil
+	}
+
 	fmt.Println("🔄 Config file changed, reloading...")
 
+	// Keep the profile switched to at runtime, unless the startup profile was changed
+	profile := app.profile
+	if newCfg.Profile != app.baseCfg.Profile {
+		profile = newCfg.Profile
+	}
+	app.baseCfg = newCfg
+	effective, err := newCfg.WithProfile(profile)
+	if err != nil {
+		fmt.Printf("⚠️  %v, continuing without a profile\n", err)
+		effective, profile = newCfg, ""
+	}
+	app.profile = profile
+	newCfg = app.withLanguage(effective)
+
+	// Follow compose_mode changes, but keep a compose mode toggled at runtime otherwise
+	if newCfg.ComposeMode != app.cfg.ComposeMode {
+		state := "off"
+		if newCfg.ComposeMode {
+			state = "on"
+		}
+		app.handleCompose([]string{state})
+	}
+
 	// Update the config
+	old := app.cfg
 	app.cfg = newCfg
 
-	// Reinitialize components that depend on config
-	app.reinitializeComponents()
+	// Reinitialize components whose settings changed
+	reloaded := app.reinitializeComponents(old)
 
 	fmt.Println("✅ Config reloaded successfully")
+	return reloaded
 }
 
-func (app *App) reinitializeComponents() {
-	// Close existing components
-	if app.recorder != nil {
-		app.recorder.Close()
+// reinitializeComponents recreates only the components whose settings differ
+// between old and the current config and returns their names. Everything else
+// reads app.cfg when used.
+func (app *App) reinitializeComponents(old *config.Config) []string {
+	cfg := app.cfg
+	var reloaded []string
+	changed := func(before, after []interface{}) bool {
+		return !reflect.DeepEqual(before, after)
 	}
-	if app.loopbackRec != nil {
-		app.loopbackRec.Close()
+
+	// Audio devices are busy while recording, recreate them for the next recording
+	if changed([]interface{}{old.SampleRate, old.NativeSampleRate, old.CaptureChannels, old.CaptureChannel, old.RecordingBufferMinutes, old.PreRollMs, old.AudioDevice, old.EchoCancellation, old.AECBackend, old.AECFilterLength, old.AECStepSize, old.AECEchoSuppression},
+		[]interface{}{cfg.SampleRate, cfg.NativeSampleRate, cfg.CaptureChannels, cfg.CaptureChannel, cfg.RecordingBufferMinutes, cfg.PreRollMs, cfg.AudioDevice, cfg.EchoCancellation, cfg.AECBackend, cfg.AECFilterLength, cfg.AECStepSize, cfg.AECEchoSuppression}) {
+		if app.isRecording {
+			fmt.Println("🎤 Audio device changes apply to the next recording")
+			app.audioChanged = true
+		} else {
+			app.reinitializeAudio()
+		}
+		reloaded = append(reloaded, "audio devices")
 	}
-	if app.player != nil {
-		app.player.Close()
+
+	if changed([]interface{}{old.VoiceActivityDetection, old.VADEngine, old.VADModel, old.VADEnergyThreshold, old.VADVoiceThreshold},
+		[]interface{}{cfg.VoiceActivityDetection, cfg.VADEngine, cfg.VADModel, cfg.VADEnergyThreshold, cfg.VADVoiceThreshold}) {
+		fmt.Println("🔄 Reloading voice activity detection")
+		app.initVAD()
+		reloaded = append(reloaded, "VAD")
 	}
-	if app.transcriber != nil {
-		app.transcriber.Close()
+
+	if changed([]interface{}{old.AudioFeedback, old.StartSoundVolume, old.StopSoundVolume, old.StartSoundPath, old.StopSoundPath, old.WarningSoundVolume, old.WarningSoundPath, old.SoundTheme},
+		[]interface{}{cfg.AudioFeedback, cfg.StartSoundVolume, cfg.StopSoundVolume, cfg.StartSoundPath, cfg.StopSoundPath, cfg.WarningSoundVolume, cfg.WarningSoundPath, cfg.SoundTheme}) {
+		fmt.Println("🔄 Reloading audio feedback")
+		if err := app.initPlayer(); err != nil {
+			fmt.Printf("❌ Failed to reinitialize audio player: %v\n", err)
+		}
+		reloaded = append(reloaded, "audio feedback")
 	}
 
-	// Reinitialize audio recorder
-	var err error
-	app.recorder, err = audio.NewRecorder(app.cfg.SampleRate, app.cfg.AudioDevice)
-	if err != nil {
-		fmt.Printf("❌ Failed to reinitialize audio recorder: %v\n", err)
-		return
+	// Prompts and languages apply without reloading, only these need a new model context
+	reloadModels := changed([]interface{}{old.Model, old.WhisperModelDir, old.Threads, old.GPUDevice, old.EnglishModel, old.EnglishOnly()},
+		[]interface{}{cfg.Model, cfg.WhisperModelDir, cfg.Threads, cfg.GPUDevice, cfg.EnglishModel, cfg.EnglishOnly()})
+	if reloadModels {
+		fmt.Println("🔄 Reloading whisper model")
+		if err := app.initTranscriber(); err != nil {
+			fmt.Printf("❌ Failed to reinitialize whisper: %v\n", err)
+		}
+		reloaded = append(reloaded, "model")
+	} else if app.transcriber != nil {
+		app.configureTranscriber(app.transcriber, app.activeModel, cfg)
+	}
+	if reloadModels || !reflect.DeepEqual(old.ResidentModels, cfg.ResidentModels) {
+		app.initResidentModels(reloadModels)
+		if len(cfg.ResidentModels) > 0 {
+			reloaded = append(reloaded, "resident models")
+		}
+	} else {
+		for _, m := range app.residentModels {
+			app.configureTranscriber(m.transcriber, m.loaded, cfg)
+		}
 	}
 
-	// Reinitialize AEC and VAD if enabled
-	app.aecProc = nil
-	app.vadProc = nil
-	app.loopbackRec = nil
+	if changed([]interface{}{old.CommandMode, old.Commands, old.AppCommands, old.CommandFuzzyThreshold, old.CommandAuditLog, old.CommandAuditPath, old.RedactTranscripts, old.TTSCommand},
+		[]interface{}{cfg.CommandMode, cfg.Commands, cfg.AppCommands, cfg.CommandFuzzyThreshold, cfg.CommandAuditLog, cfg.CommandAuditPath, cfg.RedactTranscripts, cfg.TTSCommand}) {
+		app.initCommands()
+		fmt.Println(app.cmdExecutor.GetStatus())
+		reloaded = append(reloaded, "commands")
+	}
 
-	if app.cfg.EchoCancellation {
-		app.loopbackRec, err = audio.NewLoopbackRecorder(app.cfg.SampleRate)
-		if err != nil {
-			fmt.Printf("⚠️  Failed to reinitialize loopback recorder: %v\n", err)
-		} else {
-			aecConfig := audio.AECConfig{
-				FilterLength:    app.cfg.AECFilterLength,
-				StepSize:        app.cfg.AECStepSize,
-				LeakageFactor:   0.999,
-				EchoSuppression: app.cfg.AECEchoSuppression,
-			}
-			app.aecProc = audio.NewAECProcessor(aecConfig)
-			fmt.Println("✅ Echo cancellation re-enabled")
-		}
+	if old.InjectionBackend != cfg.InjectionBackend {
+		app.injector.Close()
+		app.injector = inject.New(cfg.InjectionBackend)
+		fmt.Println(app.injector.GetStatus())
+		reloaded = append(reloaded, "injector")
 	}
 
-	if app.cfg.VoiceActivityDetection {
-		vadConfig := audio.VADConfig{
-			FrameSize:       512,
-			Overlap:         256,
-			EnergyThreshold: app.cfg.VADEnergyThreshold,
-			ZcrThreshold:    0.1,
-			VoiceThreshold:  app.cfg.VADVoiceThreshold,
-		}
-		app.vadProc = audio.NewVADProcessor(vadConfig)
-		fmt.Println("✅ Voice activity detection re-enabled")
+	if changed([]interface{}{old.History, old.HistoryPath}, []interface{}{cfg.History, cfg.HistoryPath}) {
+		app.initHistory()
+		reloaded = append(reloaded, "history")
 	}
 
-	// Reinitialize audio player
-	app.player, err = audio.NewPlayer(audio.PlayerConfig{
-		AudioFeedback:    app.cfg.AudioFeedback,
-		StartSoundVolume: app.cfg.StartSoundVolume,
-		StopSoundVolume:  app.cfg.StopSoundVolume,
-		StartSoundPath:   app.cfg.StartSoundPath,
-		StopSoundPath:    app.cfg.StopSoundPath,
-	})
-	if err != nil {
-		fmt.Printf("❌ Failed to reinitialize audio player: %v\n", err)
-		return
+	if changed([]interface{}{old.ArchiveRecordings, old.RecordingsDir, old.ArchiveMaxCount, old.ArchiveMaxSizeMB},
+		[]interface{}{cfg.ArchiveRecordings, cfg.RecordingsDir, cfg.ArchiveMaxCount, cfg.ArchiveMaxSizeMB}) {
+		app.initArchive()
+		reloaded = append(reloaded, "archive")
 	}
 
-	// Reinitialize whisper transcriber
-	modelPath := filepath.Join(app.cfg.WhisperModelDir, fmt.Sprintf("ggml-%s.bin", app.cfg.Model))
-	app.transcriber, err = whisper.New(modelPath, app.cfg.Threads, app.cfg.WhisperPrompt, app.cfg.AllowedLanguages)
-	if err != nil {
-		fmt.Printf("❌ Failed to reinitialize whisper: %v\n", err)
-		return
+	if old.IdleTimeoutMinutes != cfg.IdleTimeoutMinutes || old.ModelUnloadMinutes != cfg.ModelUnloadMinutes {
+		app.resetIdleTimer()
 	}
 
-	// Reinitialize command executor
-	app.cmdExecutor = command.NewExecutor(app.cfg.CommandMode, app.cfg.Commands)
-	fmt.Println(app.cmdExecutor.GetStatus())
+	if old.Overlay != cfg.Overlay || old.OverlayPosition != cfg.OverlayPosition {
+		app.initOverlay()
+		reloaded = append(reloaded, "overlay")
+	}
+
+	if !reflect.DeepEqual(old.Triggers, cfg.Triggers) {
+		fmt.Println("🔄 Restarting triggers")
+		app.stopTriggers()
+		app.startTriggers()
+		reloaded = append(reloaded, "triggers")
+	}
+
+	if old.SocketPath != cfg.SocketPath {
+		fmt.Println("⚠️  socket_path changes apply after restarting the daemon")
+	}
+	return reloaded
+}
+
+// reinitializeAudio recreates the microphone and loopback recorders
+func (app *App) reinitializeAudio() {
+	fmt.Println("🔄 Reloading audio devices")
+	app.audioChanged = false
+	if err := app.initRecorder(); err != nil {
+		fmt.Printf("❌ Failed to reinitialize audio recorder: %v\n", err)
+	}
+	app.initEchoCancellation()
 }